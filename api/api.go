@@ -0,0 +1,7 @@
+// Package api holds the OpenAPI description of the HTTP API.
+package api
+
+import _ "embed"
+
+//go:embed openapi.json
+var OpenAPI []byte
@@ -2,15 +2,38 @@ package main
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"rate-limiter-go/internal/audit"
+	"rate-limiter-go/internal/cluster"
+	"rate-limiter-go/internal/domain"
 	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/health"
+	"rate-limiter-go/internal/metrics"
+	"rate-limiter-go/internal/policy"
 	"rate-limiter-go/internal/repository"
 	"rate-limiter-go/internal/repository/memory"
 	redisRepo "rate-limiter-go/internal/repository/redis"
+	"rate-limiter-go/internal/server"
+	"rate-limiter-go/internal/tracing"
 	"rate-limiter-go/internal/usecase"
 	"rate-limiter-go/pkg/config"
+	"rate-limiter-go/pkg/netutil"
+	"rate-limiter-go/pkg/response"
+	"syscall"
 	"time"
+	_ "time/tzdata"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/redis/go-redis/v9"
@@ -20,62 +43,528 @@ func main() {
 
 	cfg := config.LoadConfig()
 
+	initLogger(cfg)
+	shutdownTracing := initTracing(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	var repo repository.RateLimiterRepository
+	var closeBackend func() error
 
 	if cfg.UseRedis {
 
 		repo = initRedisRepository(cfg)
-		log.Println("Using Redis for rate limiting")
+		closeBackend = repo.(io.Closer).Close
+		slog.Info("using Redis for rate limiting")
 
 	} else {
 
-		repo = initMemoryRepository(cfg)
-		log.Println("Using memory for rate limiting")
+		repo, closeBackend = initMemoryRepository(ctx, cfg)
+		slog.Info("using memory for rate limiting")
+	}
+
+	if sizer, ok := repo.(repository.Sizer); ok {
+		metrics.RegisterStoreSize(sizer.Sizes)
 	}
 
-	useCase := usecase.NewRateLimiterUseCase(repo)
-	rateLimiterHandler := handler.NewRateLimiterHandler(useCase)
+	backend := "memory"
+	if cfg.UseRedis {
+		backend = "redis"
+	}
+
+	pinger := repo.(repository.Pinger)
+	breaker := health.NewBreaker(cfg.BreakerThreshold, time.Duration(cfg.BreakerCooldownSec)*time.Second)
+	repo = repository.NewInstrumentedRepository(repo, backend, breaker.Record)
+
+	clustered := !cfg.UseRedis && len(cfg.Peers) > 0
+
+	useCase := usecase.NewRateLimiterUseCaseWithConfig(repo, usecase.Config{
+		Penalty: initPenaltyConfig(cfg),
+		Audit:   initAudit(cfg),
+		Cluster: clustered,
+		Adaptive: domain.AdaptiveConfig{
+			LatencyTarget:  time.Duration(cfg.AdaptiveLatencyMs) * time.Millisecond,
+			ErrorThreshold: float64(cfg.AdaptiveErrorPercent) / 100,
+			Interval:       time.Duration(cfg.AdaptiveIntervalSec) * time.Second,
+			MinFactor:      float64(cfg.AdaptiveMinPercent) / 100,
+			Increase:       float64(cfg.AdaptiveIncreasePct) / 100,
+			Decrease:       float64(cfg.AdaptiveDecreasePct) / 100,
+		},
+	})
+	localUseCase := useCase
+
+	if clustered {
+
+		if err := cluster.ValidateMembers(cfg.NodeAddr, cfg.Peers); err != nil {
+			fatal("invalid cluster config, NODE_ADDR must be one of PEERS", err)
+		}
+		if cfg.ClusterSecret == "" {
+			fatal("invalid cluster config", errors.New("CLUSTER_SECRET is required with PEERS"))
+		}
+
+		onPeerFailure := cluster.FailureMode(cfg.ClusterPeerFailure)
+		if !cluster.IsValidFailureMode(onPeerFailure) {
+			fatal("invalid cluster config", fmt.Errorf("CLUSTER_PEER_FAILURE %q", cfg.ClusterPeerFailure))
+		}
+
+		ring := cluster.NewRing(cfg.NodeAddr, cfg.Peers)
+		client := cluster.NewClient(time.Duration(cfg.ClusterTimeoutMs)*time.Millisecond, cfg.ClusterSecret)
+		useCase = usecase.NewClusterRateLimiterUseCase(localUseCase, ring, client, onPeerFailure)
+		slog.Info("cluster mode enabled", "self", ring.Self(), "peers", ring.Peers(), "peer_failure", onPeerFailure)
+	}
+
+	// Every node loads the same files, so they are applied locally instead of
+	// being replayed on peers that may not be up yet.
+	if cfg.RouteRulesFile != "" {
+		loadRouteRules(localUseCase, cfg.RouteRulesFile)
+	}
+
+	var configVersion func() string
+	if cfg.PolicyFile != "" {
+		configVersion = initPolicy(ctx, cfg, localUseCase).Version
+	}
+
+	checker := health.NewChecker(backend, pinger, breaker, time.Duration(cfg.HealthTimeoutMs)*time.Millisecond, configVersion)
+
+	trustedProxies, err := netutil.ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		fatal("invalid trusted proxies", err)
+	}
+
+	limiterMode := handler.LimiterMode(cfg.LimiterMode)
+	if !handler.IsValidLimiterMode(limiterMode) {
+		fatal("invalid limiter config", fmt.Errorf("LIMITER_MODE %q", cfg.LimiterMode))
+	}
+
+	e := newServer(trustedProxies)
+	admin := e
+	if cfg.AdminPort != "" {
+		admin = newServer(trustedProxies)
+	}
+
+	server.RegisterRoutes(e, admin, server.Services{
+		UseCase:       useCase,
+		LocalUseCase:  localUseCase,
+		Checker:       checker,
+		Clustered:     clustered,
+		ClusterSecret: cfg.ClusterSecret,
+		AdminAuth:     handler.AdminAuthMiddleware(initAdminAuth(cfg)),
+		Limiter: handler.RateLimiterMiddlewareWithConfig(useCase, handler.RateLimiterConfig{
+			Headers:      handler.HeaderMode(cfg.RateLimitHeaders),
+			KeyExtractor: initKeyExtractor(cfg, cfg.ClientKeyStrategy),
+			RuleMatch:    domain.RuleMatchMode(cfg.RouteRuleMatch),
+			Mode:         limiterMode,
+			Concurrency: handler.ConcurrencyConfig{
+				Limit:    cfg.ConcurrencyLimit,
+				LeaseTTL: time.Duration(cfg.ConcurrencyLeaseSec) * time.Second,
+				Scope:    cfg.ConcurrencyScope,
+			},
+		}),
+	})
+
+	addr := ":" + cfg.ServerPort
+	if cfg.ProxyProtocol {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			fatal("failed to listen", err)
+		}
+		e.Listener = netutil.NewProxyProtocolListener(ln, trustedProxies)
+		slog.Info("PROXY protocol enabled for trusted proxies")
+	}
+
+	errs := make(chan error, 3)
+
+	go serve(errs, "server", addr, func() error { return e.Start(addr) })
+
+	if admin != e {
+		adminAddr := ":" + cfg.AdminPort
+		start := func() error { return admin.Start(adminAddr) }
+		if tlsConfig := initAdminTLS(cfg); tlsConfig != nil {
+			admin.TLSServer.Addr = adminAddr
+			admin.TLSServer.TLSConfig = tlsConfig
+			start = func() error { return admin.StartServer(admin.TLSServer) }
+		}
+		go serve(errs, "admin server", adminAddr, start)
+	}
+
+	var socket *http.Server
+	if cfg.UnixSocket != "" {
+		socket = serveUnixSocket(errs, cfg.UnixSocket, e)
+	}
+
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	case err := <-errs:
+		slog.Error("server failed, shutting down", "error", err)
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSec)*time.Second)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to drain server", "error", err)
+	}
+	if admin != e {
+		if err := admin.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to drain admin server", "error", err)
+		}
+	}
+	if socket != nil {
+		if err := socket.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to drain unix socket server", "error", err)
+		}
+	}
+
+	if err := closeBackend(); err != nil {
+		slog.Error("failed to close backend", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+
+	slog.Info("server stopped")
+}
+
+func newServer(trustedProxies []*net.IPNet) *echo.Echo {
 
 	e := echo.New()
+	e.IPExtractor = handler.NewIPExtractor(trustedProxies)
+	e.HTTPErrorHandler = response.HTTPErrorHandler
 
-	e.Use(middleware.Logger())
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogMethod:   true,
+		LogURI:      true,
+		LogStatus:   true,
+		LogLatency:  true,
+		LogRemoteIP: true,
+		LogError:    true,
+		HandleError: true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			attrs := []slog.Attr{
+				slog.String("method", v.Method),
+				slog.String("uri", v.URI),
+				slog.Int("status", v.Status),
+				slog.Duration("latency", v.Latency),
+				slog.String("remote_ip", v.RemoteIP),
+			}
+			if v.Error != nil {
+				attrs = append(attrs, slog.String("error", v.Error.Error()))
+			}
+			slog.LogAttrs(c.Request().Context(), slog.LevelInfo, "request", attrs...)
+			return nil
+		},
+	}))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
-	e.GET("/", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{"status": "OK"})
-	})
+	return e
+}
 
-	api := e.Group("/api/v1")
-	api.GET("/rate-limit/:clientID", rateLimiterHandler.CheckRateLimit)
-	api.PUT("/rate-limit/:clientID", rateLimiterHandler.ConfigureRateLimit)
+// serve runs start until the server is shut down; any other outcome is
+// reported on errs.
+func serve(errs chan<- error, name, addr string, start func() error) {
 
-	protected := api.Group("/protected")
-	protected.Use(handler.RateLimiterMiddleware(useCase))
-	protected.GET("/data", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{
-			"message": "Protected data",
-		})
-	})
+	slog.Info(name+" starting", "addr", addr)
+	if err := start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs <- fmt.Errorf("%s: %w", name, err)
+	}
+}
+
+func serveUnixSocket(errs chan<- error, path string, e *echo.Echo) *http.Server {
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fatal("failed to remove stale unix socket", err)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		fatal("failed to listen on unix socket", err)
+	}
+
+	server := &http.Server{Handler: e}
+	go serve(errs, "unix socket server", path, func() error { return server.Serve(ln) })
+
+	return server
+}
+
+func loadRouteRules(useCase usecase.RateLimiterUseCase, path string) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatal("failed to read route rules", err)
+	}
+
+	var rules []handler.RuleRequest
+	if err := json.Unmarshal(data, &rules); err != nil {
+		fatal("failed to parse route rules", err)
+	}
 
-	log.Println("Server starting on :1234")
-	if err := e.Start(":1234"); err != nil {
-		log.Fatal(err)
+	for _, rule := range rules {
+		if !rule.Valid() {
+			fatal("invalid route rule", fmt.Errorf("rule %q", rule.ID))
+		}
+		if err := useCase.SaveRule(context.Background(), rule.ToRule()); err != nil {
+			fatal("failed to save route rule", err)
+		}
 	}
+
+	slog.Info("route rules loaded", "count", len(rules), "path", path)
 }
 
-func initMemoryRepository(cfg *config.Config) repository.RateLimiterRepository {
-	return memory.NewRateLimiterMemoryRepository(
+func initPolicy(ctx context.Context, cfg *config.Config, useCase usecase.RateLimiterUseCase) *policy.Manager {
+
+	manager := policy.NewManager(cfg.PolicyFile, useCase)
+	if err := manager.Reload(context.Background()); err != nil {
+		fatal("failed to load policy", err)
+	}
+
+	if cfg.PolicyReloadSec > 0 {
+		go manager.Watch(ctx, time.Duration(cfg.PolicyReloadSec)*time.Second)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			if err := manager.Reload(context.Background()); err != nil {
+				slog.Error("policy reload rejected, keeping previous policy", "error", err)
+			}
+		}
+	}()
+
+	slog.Info("policy loaded", "version", manager.Version(), "path", cfg.PolicyFile)
+
+	return manager
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func initLogger(cfg *config.Config) {
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		fatal("invalid log level", err)
+	}
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})))
+}
+
+// initAudit opens the audit log: stdout, a rotating file, or nothing when
+// AUDIT_LOG is empty.
+func initAudit(cfg *config.Config) *audit.Logger {
+
+	switch cfg.AuditLog {
+	case "":
+		return nil
+	case "stdout":
+		return audit.New(os.Stdout)
+	}
+
+	file, err := audit.NewRotatingFile(cfg.AuditLog, int64(cfg.AuditLogMaxMB)<<20, cfg.AuditLogMaxBackups)
+	if err != nil {
+		fatal("failed to open audit log", err)
+	}
+
+	slog.Info("audit log enabled", "path", cfg.AuditLog)
+
+	return audit.New(file)
+}
+
+func initAdminAuth(cfg *config.Config) handler.AdminAuthConfig {
+
+	var authConfig handler.AdminAuthConfig
+	var err error
+
+	if authConfig.Tokens, err = handler.ParseAdminCredentials(cfg.AdminTokens, true); err != nil {
+		fatal("invalid ADMIN_TOKENS", err)
+	}
+	if authConfig.HMACKeys, err = handler.ParseAdminCredentials(cfg.AdminHMACKeys, true); err != nil {
+		fatal("invalid ADMIN_HMAC_KEYS", err)
+	}
+	if authConfig.ClientCerts, err = handler.ParseAdminCredentials(cfg.AdminClientCerts, false); err != nil {
+		fatal("invalid ADMIN_CLIENT_CERTS", err)
+	}
+
+	if len(authConfig.ClientCerts) > 0 && cfg.AdminTLSClientCA == "" {
+		fatal("invalid admin auth", errors.New("ADMIN_CLIENT_CERTS requires ADMIN_TLS_CLIENT_CA"))
+	}
+	if cfg.AdminTLSCert != "" && cfg.AdminPort == "" {
+		fatal("invalid admin auth", errors.New("ADMIN_TLS_CERT requires ADMIN_PORT"))
+	}
+
+	authConfig.Disabled = cfg.AdminAuthDisabled
+	switch {
+	case authConfig.Enabled() && authConfig.Disabled:
+		fatal("invalid admin auth", errors.New("ADMIN_AUTH_DISABLED cannot be combined with admin credentials"))
+	case !authConfig.Enabled() && !authConfig.Disabled:
+		fatal("invalid admin auth", errors.New("set ADMIN_TOKENS, ADMIN_HMAC_KEYS or ADMIN_CLIENT_CERTS, or ADMIN_AUTH_DISABLED=true"))
+	case authConfig.Disabled:
+		slog.Warn("admin API is not authenticated because ADMIN_AUTH_DISABLED is set")
+	}
+
+	return authConfig
+}
+
+// initAdminTLS serves the admin port over TLS when a certificate is
+// configured, verifying client certificates against ADMIN_TLS_CLIENT_CA.
+func initAdminTLS(cfg *config.Config) *tls.Config {
+
+	if cfg.AdminTLSCert == "" {
+		if cfg.AdminTLSClientCA != "" {
+			fatal("invalid admin TLS", errors.New("ADMIN_TLS_CLIENT_CA requires ADMIN_TLS_CERT"))
+		}
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.AdminTLSCert, cfg.AdminTLSKey)
+	if err != nil {
+		fatal("failed to load admin TLS certificate", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.AdminTLSClientCA != "" {
+		data, err := os.ReadFile(cfg.AdminTLSClientCA)
+		if err != nil {
+			fatal("failed to read admin client CA", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			fatal("invalid admin client CA", errors.New("no certificates found"))
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig
+}
+
+func initTracing(cfg *config.Config) func(context.Context) error {
+
+	shutdown, err := tracing.Setup(context.Background(), cfg.TraceExporter, cfg.TraceServiceName)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+
+	if cfg.TraceExporter != "" && cfg.TraceExporter != tracing.ExporterNone {
+		slog.Info("tracing enabled", "exporter", cfg.TraceExporter)
+	}
+
+	return shutdown
+}
+
+func initPenaltyConfig(cfg *config.Config) domain.PenaltyConfig {
+
+	durations := make([]time.Duration, len(cfg.PenaltyDurations))
+	for i, value := range cfg.PenaltyDurations {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			fatal("invalid penalty duration", fmt.Errorf("duration %q", value))
+		}
+		durations[i] = duration
+	}
+
+	return domain.PenaltyConfig{
+		Threshold: cfg.PenaltyThreshold,
+		Window:    time.Duration(cfg.PenaltyWindowSec) * time.Second,
+		Durations: durations,
+		Reset:     time.Duration(cfg.PenaltyResetSec) * time.Second,
+	}
+}
+
+func initKeyExtractor(cfg *config.Config, spec string) handler.KeyExtractor {
+
+	options := handler.KeyExtractorOptions{
+		JWT: handler.JWTKeyConfig{
+			HMACSecret: []byte(cfg.JWTHMACSecret),
+		},
+		UserContextKey: cfg.UserContextKey,
+		IPv6PrefixLen:  cfg.IPv6PrefixLen,
+		PrefixKeys:     cfg.ClientKeyPrefix,
+	}
+
+	if cfg.JWTRSAPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.JWTRSAPublicKeyFile)
+		if err != nil {
+			fatal("failed to read JWT public key", err)
+		}
+
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			fatal("failed to parse JWT public key", err)
+		}
+		options.JWT.RSAPublicKey = key
+	}
+
+	extractor, err := handler.ParseKeyExtractor(spec, options)
+	if err != nil {
+		fatal("invalid client key strategy", err)
+	}
+
+	return extractor
+}
+
+// initMemoryRepository returns the repository and a function that writes the
+// final snapshot on shutdown.
+func initMemoryRepository(ctx context.Context, cfg *config.Config) (repository.RateLimiterRepository, func() error) {
+	repo := memory.NewRateLimiterMemoryRepository(
 		cfg.DefaultMaxRequests,
 		int(cfg.DefaultCycleDuration),
 	)
+
+	if cfg.SnapshotPath == "" {
+		return repo, func() error { return nil }
+	}
+
+	snapshotter := repo.(repository.Snapshotter)
+	if err := memory.LoadSnapshotFile(snapshotter, cfg.SnapshotPath); err != nil {
+		fatal("failed to load snapshot", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(cfg.SnapshotIntervalSec) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := memory.SaveSnapshotFile(snapshotter, cfg.SnapshotPath); err != nil {
+				slog.Error("failed to save snapshot", "error", err)
+			}
+		}
+	}()
+
+	slog.Info("memory snapshots enabled", "path", cfg.SnapshotPath)
+
+	return repo, func() error {
+		return memory.SaveSnapshotFile(snapshotter, cfg.SnapshotPath)
+	}
 }
 
 func initRedisRepository(cfg *config.Config) repository.RateLimiterRepository {
 
 	opt, err := redis.ParseURL(cfg.RedisURL)
 	if err != nil {
-		log.Fatal("Failed to parse Redis URL:", err)
+		fatal("failed to parse Redis URL", err)
 	}
 
 	if cfg.RedisPassword != "" {
@@ -83,15 +572,16 @@ func initRedisRepository(cfg *config.Config) repository.RateLimiterRepository {
 	}
 
 	client := redis.NewClient(opt)
+	client.AddHook(redisRepo.TracingHook{})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
+		fatal("failed to connect to Redis", err)
 	}
 
-	log.Println("Successfully connected to Redis")
+	slog.Info("connected to Redis")
 
 	return redisRepo.NewRateLimiterRedisRepository(
 		client,
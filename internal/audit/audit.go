@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"rate-limiter-go/internal/domain"
+)
+
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor records who is acting on behalf of the request, for the audit
+// entries of the changes it makes.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// Logger writes one JSON object per line for every denied decision and every
+// configuration change. A nil Logger discards everything.
+type Logger struct {
+	logger *slog.Logger
+}
+
+func New(w io.Writer) *Logger {
+	return &Logger{logger: slog.New(slog.NewJSONHandler(w, nil))}
+}
+
+func (l *Logger) Denied(ctx context.Context, clientID string, decision domain.Decision) {
+	if l == nil {
+		return
+	}
+
+	reason := "rate_limited"
+	switch {
+	case decision.Banned:
+		reason = "banned"
+	case decision.Shed:
+		reason = "shed"
+	}
+
+	l.logger.InfoContext(ctx, "decision_denied",
+		"client_id", clientID,
+		"reason", reason,
+		"policy", decision.Policy,
+		"limit", decision.Limit,
+		"retry_after_seconds", decision.RetryAfterSeconds(),
+	)
+}
+
+func (l *Logger) AccessDenied(ctx context.Context, clientID string, ip net.IP, entry *domain.AccessEntry) {
+	if l == nil {
+		return
+	}
+
+	l.logger.InfoContext(ctx, "decision_denied",
+		"client_id", clientID,
+		"reason", "denylist",
+		"ip", ip.String(),
+		"entry", entry.ID,
+	)
+}
+
+// Change records a configuration change. old is nil for creations and new is
+// nil for deletions.
+func (l *Logger) Change(ctx context.Context, resource, id string, old, new any) {
+	if l == nil {
+		return
+	}
+
+	l.logger.InfoContext(ctx, "config_change",
+		"actor", Actor(ctx),
+		"resource", resource,
+		"id", id,
+		"old", old,
+		"new", new,
+	)
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNilLoggerDiscards(t *testing.T) {
+	var logger *Logger
+	logger.Change(context.Background(), "tier", "pro", nil, nil)
+}
+
+func TestActor(t *testing.T) {
+	ctx := context.Background()
+
+	if got := Actor(ctx); got != SystemActor {
+		t.Errorf("Expected %q without actor, got %q", SystemActor, got)
+	}
+	if got := Actor(WithActor(ctx, "203.0.113.7")); got != "203.0.113.7" {
+		t.Errorf("Expected actor from context, got %q", got)
+	}
+}
+
+func TestChangeIsOneJSONLine(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf).Change(WithActor(context.Background(), "admin"), "tier", "pro", nil, map[string]int{"max": 10})
+
+	line := buf.String()
+	if strings.Count(line, "\n") != 1 {
+		t.Fatalf("Expected a single line, got %q", line)
+	}
+	for _, want := range []string{`"msg":"config_change"`, `"actor":"admin"`, `"old":null`, `"new":{"max":10}`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected %s in %s", want, line)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	f, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for file, want := range expected {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if string(data) != want {
+			t.Errorf("Expected %q in %s, got %q", want, file, data)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Expected at most 2 backups")
+	}
+}
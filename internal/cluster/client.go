@@ -0,0 +1,347 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"rate-limiter-go/internal/domain"
+	"strconv"
+	"time"
+)
+
+const SecretHeader = "X-Cluster-Secret"
+
+// FailureMode decides how a check is answered when the owning peer cannot be
+// reached.
+type FailureMode string
+
+const (
+	FailLocal FailureMode = "local"
+	FailAllow FailureMode = "allow"
+	FailDeny  FailureMode = "deny"
+)
+
+func IsValidFailureMode(mode FailureMode) bool {
+	switch mode {
+	case FailLocal, FailAllow, FailDeny:
+		return true
+	}
+	return false
+}
+
+// peerErrors are the domain errors a peer reports by code, so the node that
+// forwarded the call can map them like local errors.
+var peerErrors = []struct {
+	code   string
+	status int
+	err    error
+}{
+	{"version_mismatch", http.StatusPreconditionFailed, domain.ErrVersionMismatch},
+	{"invalid_hierarchy", http.StatusBadRequest, domain.ErrInvalidHierarchy},
+	{"not_supported", http.StatusBadRequest, domain.ErrNotSupportedInCluster},
+	{"tier_not_found", http.StatusNotFound, domain.ErrTierNotFound},
+	{"tier_in_use", http.StatusConflict, domain.ErrTierInUse},
+	{"client_not_found", http.StatusNotFound, domain.ErrClientNotFound},
+	{"rule_not_found", http.StatusNotFound, domain.ErrRuleNotFound},
+	{"access_entry_not_found", http.StatusNotFound, domain.ErrAccessEntryNotFound},
+	{"penalty_not_found", http.StatusNotFound, domain.ErrPenaltyNotFound},
+}
+
+// ErrorCode returns the code and status a peer responds with for err, or an
+// empty code and 500 for errors that are not shared between nodes.
+func ErrorCode(err error) (string, int) {
+	for _, e := range peerErrors {
+		if errors.Is(err, e.err) {
+			return e.code, e.status
+		}
+	}
+	return "", http.StatusInternalServerError
+}
+
+type CheckResult struct {
+	Allowed      bool   `json:"allowed"`
+	Banned       bool   `json:"banned"`
+	Limit        int    `json:"limit"`
+	Remaining    int    `json:"remaining"`
+	Reset        int64  `json:"reset"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+	Policy       string `json:"policy"`
+	WindowMs     int64  `json:"window_ms"`
+	Shed         bool   `json:"shed"`
+}
+
+func NewCheckResult(decision domain.Decision) CheckResult {
+	return CheckResult{
+		Allowed:      decision.Allowed,
+		Banned:       decision.Banned,
+		Limit:        decision.Limit,
+		Remaining:    decision.Remaining,
+		Reset:        decision.Reset.Unix(),
+		RetryAfterMs: decision.RetryAfter.Milliseconds(),
+		Policy:       decision.Policy,
+		WindowMs:     decision.Window.Milliseconds(),
+		Shed:         decision.Shed,
+	}
+}
+
+func (r CheckResult) Decision() domain.Decision {
+	return domain.Decision{
+		Allowed:    r.Allowed,
+		Banned:     r.Banned,
+		Limit:      r.Limit,
+		Remaining:  r.Remaining,
+		Reset:      time.Unix(r.Reset, 0),
+		RetryAfter: time.Duration(r.RetryAfterMs) * time.Millisecond,
+		Policy:     r.Policy,
+		Window:     time.Duration(r.WindowMs) * time.Millisecond,
+		Shed:       r.Shed,
+	}
+}
+
+type BatchResult struct {
+	Allowed bool                   `json:"allowed"`
+	Results []domain.ConsumeResult `json:"results"`
+}
+
+type ClientResult struct {
+	RateLimit *domain.RateLimit `json:"rate_limit"`
+	Usage     *domain.Usage     `json:"usage"`
+}
+
+type Client struct {
+	httpClient *http.Client
+	secret     string
+}
+
+func NewClient(timeout time.Duration, secret string) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+		secret:     secret,
+	}
+}
+
+func (c *Client) Check(ctx context.Context, peer, clientID string) (*CheckResult, error) {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/check/%s", peer, url.PathEscape(clientID))
+
+	var result CheckResult
+	if err := c.do(ctx, http.MethodPost, endpoint, nil, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// CheckRoute consumes the client's counters of the route rules with the given
+// IDs on the owning peer, which looks the rules up in its own store.
+func (c *Client) CheckRoute(ctx context.Context, peer, clientID string, ruleIDs []string) (*CheckResult, error) {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/route-check/%s", peer, url.PathEscape(clientID))
+
+	var result CheckResult
+	if err := c.do(ctx, http.MethodPost, endpoint, nil, map[string][]string{"rules": ruleIDs}, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Refund gives back the request a check took from the client on the owning
+// peer, from the counters of the route rules with the given IDs if any.
+func (c *Client) Refund(ctx context.Context, peer, clientID string, ruleIDs []string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/refund/%s", peer, url.PathEscape(clientID))
+	return c.do(ctx, http.MethodPost, endpoint, nil, map[string][]string{"rules": ruleIDs}, nil)
+}
+
+func (c *Client) CheckBatch(ctx context.Context, peer string, requests []domain.ConsumeRequest) (*BatchResult, error) {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/batch", peer)
+
+	var result BatchResult
+	if err := c.do(ctx, http.MethodPost, endpoint, nil, requests, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Configure saves config on the owning peer, guarded by version unless it is
+// domain.AnyVersion, and returns the version after the update.
+func (c *Client) Configure(ctx context.Context, peer, clientID string, config domain.RateLimitConfig, version int) (int, error) {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/config/%s", peer, url.PathEscape(clientID))
+
+	body := map[string]interface{}{
+		"max_requests":   config.MaxRequests,
+		"cycle_duration": config.CycleDuration,
+		"parent_id":      config.ParentID,
+		"tier":           config.Tier,
+		"window":         config.Window,
+		"timezone":       config.TimeZone,
+	}
+
+	header := http.Header{}
+	if version != domain.AnyVersion {
+		header.Set("If-Match", strconv.Quote(strconv.Itoa(version)))
+	}
+
+	var result struct {
+		Version int `json:"version"`
+	}
+	if err := c.do(ctx, http.MethodPut, endpoint, header, body, &result); err != nil {
+		return 0, err
+	}
+
+	return result.Version, nil
+}
+
+func (c *Client) AssignTier(ctx context.Context, peer, clientID, tier string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/tier/%s", peer, url.PathEscape(clientID))
+	return c.do(ctx, http.MethodPut, endpoint, nil, map[string]string{"tier": tier}, nil)
+}
+
+func (c *Client) Usage(ctx context.Context, peer, clientID string) (*domain.Usage, error) {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/usage/%s", peer, url.PathEscape(clientID))
+
+	var usage domain.Usage
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, nil, &usage); err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+func (c *Client) GetClient(ctx context.Context, peer, clientID string) (*ClientResult, error) {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/clients/%s", peer, url.PathEscape(clientID))
+
+	var result ClientResult
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) ResetClient(ctx context.Context, peer, clientID string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/clients/%s/reset", peer, url.PathEscape(clientID))
+	return c.do(ctx, http.MethodPost, endpoint, nil, nil, nil)
+}
+
+func (c *Client) DeleteClient(ctx context.Context, peer, clientID string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/clients/%s", peer, url.PathEscape(clientID))
+	return c.do(ctx, http.MethodDelete, endpoint, nil, nil, nil)
+}
+
+func (c *Client) Penalty(ctx context.Context, peer, clientID string) (*domain.Penalty, error) {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/penalty/%s", peer, url.PathEscape(clientID))
+
+	var penalty domain.Penalty
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, nil, &penalty); err != nil {
+		return nil, err
+	}
+
+	return &penalty, nil
+}
+
+func (c *Client) Unban(ctx context.Context, peer, clientID string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/penalty/%s", peer, url.PathEscape(clientID))
+	return c.do(ctx, http.MethodDelete, endpoint, nil, nil, nil)
+}
+
+func (c *Client) SaveTier(ctx context.Context, peer string, tier *domain.Tier) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/tiers/%s", peer, url.PathEscape(tier.Name))
+	return c.do(ctx, http.MethodPut, endpoint, nil, tier, nil)
+}
+
+func (c *Client) DeleteTier(ctx context.Context, peer, name string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/tiers/%s", peer, url.PathEscape(name))
+	return c.do(ctx, http.MethodDelete, endpoint, nil, nil, nil)
+}
+
+func (c *Client) SaveRule(ctx context.Context, peer string, rule *domain.RouteRule) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/rules/%s", peer, url.PathEscape(rule.ID))
+	return c.do(ctx, http.MethodPut, endpoint, nil, rule, nil)
+}
+
+func (c *Client) DeleteRule(ctx context.Context, peer, id string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/rules/%s", peer, url.PathEscape(id))
+	return c.do(ctx, http.MethodDelete, endpoint, nil, nil, nil)
+}
+
+func (c *Client) SaveAccessEntry(ctx context.Context, peer string, entry *domain.AccessEntry) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/access/%s", peer, url.PathEscape(entry.ID))
+	return c.do(ctx, http.MethodPut, endpoint, nil, entry, nil)
+}
+
+func (c *Client) DeleteAccessEntry(ctx context.Context, peer, id string) error {
+	endpoint := fmt.Sprintf("%s/internal/v1/cluster/access/%s", peer, url.PathEscape(id))
+	return c.do(ctx, http.MethodDelete, endpoint, nil, nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, header http.Header, body, out interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return fmt.Errorf("failed to marshal: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, &payload)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		req.Header.Set(SecretHeader, c.secret)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to forward to peer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return peerError(resp, endpoint)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	var envelope struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to unmarshal: %w", err)
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+// peerError turns an error response into the domain error the peer reported,
+// or a generic error when the peer did not name one.
+func peerError(resp *http.Response, endpoint string) error {
+	var problem struct {
+		Data struct {
+			Code string `json:"code"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err == nil && problem.Data.Code != "" {
+		for _, e := range peerErrors {
+			if e.code == problem.Data.Code {
+				return e.err
+			}
+		}
+	}
+
+	return fmt.Errorf("peer %s responded with status %d", endpoint, resp.StatusCode)
+}
@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dgryski/go-rendezvous"
+)
+
+type Ring struct {
+	mu    sync.RWMutex
+	self  string
+	peers []string
+	rdv   *rendezvous.Rendezvous
+}
+
+// ValidateMembers checks that self is set and listed in peers, so every node
+// builds the same ring and agrees on the owner of each client.
+func ValidateMembers(self string, peers []string) error {
+	self = normalizeAddr(self)
+	if self == "" {
+		return errors.New("node address is empty")
+	}
+
+	for _, p := range peers {
+		if normalizeAddr(p) == self {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("node address %s is not one of the peers", self)
+}
+
+func NewRing(self string, peers []string) *Ring {
+	r := &Ring{self: normalizeAddr(self)}
+	r.SetPeers(peers)
+	return r
+}
+
+func (r *Ring) Self() string {
+	return r.self
+}
+
+func (r *Ring) SetPeers(peers []string) {
+	members := []string{r.self}
+	seen := map[string]bool{r.self: true}
+
+	for _, p := range peers {
+		p = normalizeAddr(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		members = append(members, p)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.peers = members
+	r.rdv = rendezvous.New(members, xxhash.Sum64String)
+}
+
+func (r *Ring) Peers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	peers := make([]string, len(r.peers))
+	copy(peers, r.peers)
+	return peers
+}
+
+func (r *Ring) Owner(clientID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.rdv.Lookup(clientID)
+}
+
+func (r *Ring) IsLocal(clientID string) bool {
+	return r.Owner(clientID) == r.self
+}
+
+func normalizeAddr(addr string) string {
+	return strings.TrimRight(strings.TrimSpace(addr), "/")
+}
@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRingIncludesSelf(t *testing.T) {
+	ring := NewRing("http://node-a", []string{"http://node-b/", "http://node-b"})
+
+	peers := ring.Peers()
+	if len(peers) != 2 {
+		t.Fatalf("Expected 2 members, got %v", peers)
+	}
+	if peers[0] != "http://node-a" {
+		t.Errorf("Expected self to be a member, got %v", peers)
+	}
+}
+
+func TestRingOwnerIsStable(t *testing.T) {
+	a := NewRing("http://node-a", []string{"http://node-b", "http://node-c"})
+	b := NewRing("http://node-b", []string{"http://node-a", "http://node-c"})
+
+	for i := 0; i < 100; i++ {
+		clientID := fmt.Sprintf("client-%d", i)
+		if a.Owner(clientID) != b.Owner(clientID) {
+			t.Fatalf("Nodes disagree on owner of %s", clientID)
+		}
+	}
+}
+
+func TestRingRebalanceOnlyMovesRemovedKeys(t *testing.T) {
+	ring := NewRing("http://node-a", []string{"http://node-b", "http://node-c"})
+
+	before := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		clientID := fmt.Sprintf("client-%d", i)
+		before[clientID] = ring.Owner(clientID)
+	}
+
+	ring.SetPeers([]string{"http://node-b"})
+
+	for clientID, owner := range before {
+		after := ring.Owner(clientID)
+		if after == "http://node-c" {
+			t.Fatalf("%s still owned by removed peer", clientID)
+		}
+		if owner != "http://node-c" && after != owner {
+			t.Errorf("%s moved from %s to %s although its owner stayed", clientID, owner, after)
+		}
+	}
+}
+
+func TestValidateMembers(t *testing.T) {
+	peers := []string{"http://node-a", "http://node-b/"}
+
+	if err := ValidateMembers("http://node-b", peers); err != nil {
+		t.Errorf("Expected member to be valid, got %v", err)
+	}
+	if err := ValidateMembers("", peers); err == nil {
+		t.Error("Expected empty node address to be rejected")
+	}
+	if err := ValidateMembers("http://node-c", peers); err == nil {
+		t.Error("Expected node outside the peers to be rejected")
+	}
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"net"
+	"path"
+	"strings"
+	"time"
+)
+
+type AccessList string
+
+const (
+	AccessAllow AccessList = "allow"
+	AccessDeny  AccessList = "deny"
+)
+
+// AccessKind says what an entry's pattern is matched against. IP entries
+// match the client address. Client entries match the extracted client ID and
+// are only as trustworthy as the key extractor: with a key taken from a
+// client-supplied header anyone can claim an allowlisted ID.
+type AccessKind string
+
+const (
+	AccessIP     AccessKind = "ip"
+	AccessClient AccessKind = "client"
+)
+
+type AccessVerdict int
+
+const (
+	AccessNone AccessVerdict = iota
+	AccessAllowed
+	AccessDenied
+)
+
+var ErrAccessEntryNotFound = errors.New("access entry not found")
+
+type AccessEntry struct {
+	ID        string
+	List      AccessList
+	Kind      AccessKind
+	Pattern   string
+	Reason    string
+	ExpiresAt time.Time
+}
+
+// AccessKindOf is the kind of a pattern given without one: patterns starting
+// with an IP address, such as CIDRs, are IP entries, anything else a client
+// entry.
+func AccessKindOf(pattern string) AccessKind {
+	addr, _, _ := strings.Cut(pattern, "/")
+	if net.ParseIP(addr) != nil {
+		return AccessIP
+	}
+	return AccessClient
+}
+
+// ResolvedKind is the entry's kind, taken from the pattern for entries stored
+// before kinds existed.
+func (e *AccessEntry) ResolvedKind() AccessKind {
+	if e.Kind == "" {
+		return AccessKindOf(e.Pattern)
+	}
+	return e.Kind
+}
+
+// Matches checks the pattern against the client: an IP entry's address or
+// CIDR against the client address, a client entry's glob (*, ?, [...]) or
+// plain value against the client ID.
+func (e *AccessEntry) Matches(clientID string, ip net.IP) bool {
+	if e.ResolvedKind() == AccessIP {
+		if ip == nil {
+			return false
+		}
+		if _, ipNet, err := net.ParseCIDR(e.Pattern); err == nil {
+			return ipNet.Contains(ip)
+		}
+		addr := net.ParseIP(e.Pattern)
+		return addr != nil && addr.Equal(ip)
+	}
+
+	if strings.ContainsAny(e.Pattern, "*?[") {
+		matched, _ := path.Match(e.Pattern, clientID)
+		return matched
+	}
+
+	return clientID == e.Pattern
+}
+
+func (e *AccessEntry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+func IsValidAccessPattern(kind AccessKind, pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	if kind == AccessIP {
+		if strings.Contains(pattern, "/") {
+			_, _, err := net.ParseCIDR(pattern)
+			return err == nil
+		}
+		return net.ParseIP(pattern) != nil
+	}
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+func IsValidAccessKind(kind AccessKind) bool {
+	return kind == AccessIP || kind == AccessClient
+}
+
+func IsValidAccessList(list AccessList) bool {
+	return list == AccessAllow || list == AccessDeny
+}
+
+// EvaluateAccess returns the verdict of the unexpired entries matching the
+// client. A deny entry wins over an allow entry.
+func EvaluateAccess(entries []*AccessEntry, clientID string, ip net.IP, now time.Time) (AccessVerdict, *AccessEntry) {
+	var allowed *AccessEntry
+
+	for _, entry := range entries {
+		if entry.Expired(now) || !entry.Matches(clientID, ip) {
+			continue
+		}
+		if entry.List == AccessDeny {
+			return AccessDenied, entry
+		}
+		if allowed == nil {
+			allowed = entry
+		}
+	}
+
+	if allowed != nil {
+		return AccessAllowed, allowed
+	}
+	return AccessNone, nil
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAccessEntry_Matches(t *testing.T) {
+	tests := []struct {
+		kind     AccessKind
+		pattern  string
+		clientID string
+		ip       string
+		expected bool
+	}{
+		{"", "health-checker", "health-checker", "203.0.113.1", true},
+		{"", "health-checker", "other", "203.0.113.1", false},
+		{"", "internal-*", "internal-billing", "203.0.113.1", true},
+		{"", "internal-*", "external-billing", "203.0.113.1", false},
+		{"", "10.0.0.0/8", "any", "10.1.2.3", true},
+		{"", "10.0.0.0/8", "any", "11.1.2.3", false},
+		{"", "2001:db8::/32", "any", "2001:db8::1", true},
+		{"", "203.0.113.9", "any", "203.0.113.9", true},
+		{"", "203.0.113.9", "203.0.113.9", "198.51.100.1", false},
+		{AccessIP, "10.0.0.0/8", "10.0.0.1", "", false},
+		{AccessClient, "203.0.113.9", "203.0.113.9", "198.51.100.1", true},
+	}
+
+	for _, tt := range tests {
+		entry := &AccessEntry{Kind: tt.kind, Pattern: tt.pattern}
+		if got := entry.Matches(tt.clientID, net.ParseIP(tt.ip)); got != tt.expected {
+			t.Errorf("%s against %s/%s: expected %v, got %v", tt.pattern, tt.clientID, tt.ip, tt.expected, got)
+		}
+	}
+}
+
+func TestEvaluateAccess(t *testing.T) {
+	now := time.Now()
+	entries := []*AccessEntry{
+		{ID: "internal", List: AccessAllow, Pattern: "10.0.0.0/8"},
+		{ID: "abuser", List: AccessDeny, Pattern: "abuser-*"},
+		{ID: "expired", List: AccessDeny, Pattern: "old-*", ExpiresAt: now.Add(-time.Minute)},
+	}
+
+	if verdict, _ := EvaluateAccess(entries, "svc", net.ParseIP("10.0.0.5"), now); verdict != AccessAllowed {
+		t.Errorf("Expected allowed, got %v", verdict)
+	}
+
+	verdict, entry := EvaluateAccess(entries, "abuser-1", net.ParseIP("10.0.0.5"), now)
+	if verdict != AccessDenied || entry.ID != "abuser" {
+		t.Errorf("Deny should win over allow, got %v", verdict)
+	}
+
+	if verdict, _ := EvaluateAccess(entries, "old-client", nil, now); verdict != AccessNone {
+		t.Errorf("Expired entries should be ignored, got %v", verdict)
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// AdaptiveConfig drives an AIMD controller: every Interval the limit factor
+// grows by Increase while latency and errors stay under target, and is
+// multiplied by Decrease otherwise, never dropping below MinFactor.
+type AdaptiveConfig struct {
+	LatencyTarget  time.Duration
+	ErrorThreshold float64
+	Interval       time.Duration
+	MinFactor      float64
+	Increase       float64
+	Decrease       float64
+}
+
+func (c AdaptiveConfig) Enabled() bool {
+	return c.LatencyTarget > 0 && c.Interval > 0
+}
+
+// AdaptiveLimit scales a configured limit, which stays the ceiling, by a
+// factor between MinFactor and 1.
+type AdaptiveLimit struct {
+	Factor      float64
+	Latency     time.Duration
+	ErrorRate   float64
+	WindowStart time.Time
+
+	requests     int
+	errors       int
+	totalLatency time.Duration
+}
+
+type AdaptiveStatus struct {
+	RuleID    string
+	Ceiling   int
+	Limit     int
+	Factor    float64
+	Latency   time.Duration
+	ErrorRate float64
+}
+
+func NewAdaptiveLimit(now time.Time) *AdaptiveLimit {
+	return &AdaptiveLimit{Factor: 1, WindowStart: now}
+}
+
+// Observe records one handled request and adjusts the factor once the current
+// interval is over.
+func (a *AdaptiveLimit) Observe(latency time.Duration, failed bool, now time.Time, config AdaptiveConfig) {
+	a.requests++
+	a.totalLatency += latency
+	if failed {
+		a.errors++
+	}
+
+	if now.Sub(a.WindowStart) < config.Interval {
+		return
+	}
+
+	a.Latency = a.totalLatency / time.Duration(a.requests)
+	a.ErrorRate = float64(a.errors) / float64(a.requests)
+
+	if a.Latency > config.LatencyTarget || a.ErrorRate > config.ErrorThreshold {
+		a.Factor = math.Max(config.MinFactor, a.Factor*config.Decrease)
+	} else {
+		a.Factor = math.Min(1, a.Factor+config.Increase)
+	}
+
+	a.requests, a.errors, a.totalLatency = 0, 0, 0
+	a.WindowStart = now
+}
+
+func (a *AdaptiveLimit) Apply(max int) int {
+	limit := int(math.Ceil(float64(max) * a.Factor))
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimit_AIMD(t *testing.T) {
+	config := AdaptiveConfig{
+		LatencyTarget:  100 * time.Millisecond,
+		ErrorThreshold: 0.1,
+		Interval:       time.Second,
+		MinFactor:      0.2,
+		Increase:       0.1,
+		Decrease:       0.5,
+	}
+
+	now := time.Now()
+	limit := NewAdaptiveLimit(now)
+
+	now = now.Add(time.Second)
+	limit.Observe(300*time.Millisecond, false, now, config)
+	if limit.Factor != 0.5 || limit.Apply(100) != 50 {
+		t.Fatalf("Slow responses should halve the limit, got factor %v", limit.Factor)
+	}
+
+	now = now.Add(time.Second)
+	limit.Observe(10*time.Millisecond, true, now, config)
+	if limit.Factor != 0.25 {
+		t.Fatalf("Errors should halve the limit, got factor %v", limit.Factor)
+	}
+
+	now = now.Add(time.Second)
+	limit.Observe(10*time.Millisecond, true, now, config)
+	if limit.Factor != 0.2 {
+		t.Fatalf("Factor should not drop below the minimum, got %v", limit.Factor)
+	}
+
+	for i := 0; i < 20; i++ {
+		now = now.Add(time.Second)
+		limit.Observe(10*time.Millisecond, false, now, config)
+	}
+	if limit.Factor != 1 || limit.Apply(100) != 100 {
+		t.Errorf("Healthy responses should recover up to the ceiling, got factor %v", limit.Factor)
+	}
+}
+
+func TestAdaptiveLimit_WaitsForInterval(t *testing.T) {
+	config := AdaptiveConfig{LatencyTarget: time.Millisecond, Interval: time.Minute, MinFactor: 0.1, Decrease: 0.5}
+
+	now := time.Now()
+	limit := NewAdaptiveLimit(now)
+	limit.Observe(time.Second, true, now.Add(time.Second), config)
+
+	if limit.Factor != 1 {
+		t.Errorf("Factor should only change once the interval is over, got %v", limit.Factor)
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrClientNotFound = errors.New("client not found")
+	ErrInvalidCursor  = errors.New("invalid cursor")
+)
+
+// counterSeparator joins a client ID with the rule or concurrency scope of a
+// derived counter. IsValidClientID keeps it out of client IDs.
+const counterSeparator = "|"
+
+// ClientFilter selects stored clients by ID prefix and tier. Empty fields
+// match everything.
+type ClientFilter struct {
+	Prefix string
+	Tier   string
+}
+
+// IsDerivedCounter reports whether id belongs to a rule or concurrency
+// counter rather than a client.
+func IsDerivedCounter(id string) bool {
+	return strings.Contains(id, counterSeparator)
+}
+
+// IsValidClientID reports whether id may name a client. IDs holding the
+// counter separator are rejected so they cannot address a derived counter.
+func IsValidClientID(id string) bool {
+	return id != "" && !IsDerivedCounter(id)
+}
+
+func (f ClientFilter) Matches(rateLimit *RateLimit) bool {
+	if IsDerivedCounter(rateLimit.ClientID) || !strings.HasPrefix(rateLimit.ClientID, f.Prefix) {
+		return false
+	}
+	return f.Tier == "" || rateLimit.Tier == f.Tier
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// ConcurrencyLease holds one in-flight slot of a client. Leases expire after
+// TTL so that slots held by a crashed replica are eventually freed.
+type ConcurrencyLease struct {
+	Key      string
+	ID       string
+	Limit    int
+	InFlight int
+	TTL      time.Duration
+}
+
+func (l *ConcurrencyLease) Remaining() int {
+	if remaining := l.Limit - l.InFlight; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func ConcurrencyKey(clientID, scope string) string {
+	if scope == "" {
+		return clientID + counterSeparator + "concurrency"
+	}
+	return clientID + counterSeparator + "concurrency:" + scope
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+type Decision struct {
+	Allowed    bool
+	Banned     bool
+	Shed       bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+	Policy     string
+	Window     time.Duration
+}
+
+func NewDecision(result ConsumeResult, allowed bool, now time.Time) Decision {
+	rl := result.RateLimit
+
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     rl.MaxRequests,
+		Remaining: rl.GetRemainingRequests(),
+		Reset:     rl.GetResetTime(),
+		Policy:    result.Policy,
+		Window:    rl.WindowLength(),
+	}
+
+	if !allowed {
+		decision.RetryAfter = decision.Reset.Sub(now)
+		if decision.RetryAfter < time.Second {
+			decision.RetryAfter = time.Second
+		}
+	}
+
+	return decision
+}
+
+// Decision is the outcome of a batch result, a ban when the client is
+// banned.
+func (r ConsumeResult) Decision(now time.Time) Decision {
+	if r.Penalty != nil {
+		return NewBanDecision(r.Penalty, now)
+	}
+	return NewDecision(r, r.Allowed, now)
+}
+
+// ResetAfter is the time left until the window resets, rounded up to whole
+// seconds as expected by the rate limit headers.
+func (d Decision) ResetAfter(now time.Time) int64 {
+	return ceilSeconds(d.Reset.Sub(now))
+}
+
+func (d Decision) RetryAfterSeconds() int64 {
+	return ceilSeconds(d.RetryAfter)
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const PenaltyPolicy = "penalty"
+
+var ErrPenaltyNotFound = errors.New("penalty not found")
+
+// PenaltyConfig bans a client once it is denied more than Threshold times
+// within Window. Each repeat offense uses the next entry of Durations, and
+// offenses are forgotten after Reset without a new ban.
+type PenaltyConfig struct {
+	Threshold int
+	Window    time.Duration
+	Durations []time.Duration
+	Reset     time.Duration
+}
+
+func (c PenaltyConfig) Enabled() bool {
+	return c.Threshold > 0 && c.Window > 0 && len(c.Durations) > 0
+}
+
+type Penalty struct {
+	ClientID    string
+	Violations  int
+	WindowStart time.Time
+	Offenses    int
+	LastOffense time.Time
+	BannedUntil time.Time
+}
+
+func (p *Penalty) Banned(now time.Time) bool {
+	return now.Before(p.BannedUntil)
+}
+
+// RecordViolation counts a denied request and reports whether it started a
+// new ban.
+func (p *Penalty) RecordViolation(now time.Time, config PenaltyConfig) bool {
+	if !p.LastOffense.IsZero() && config.Reset > 0 && now.Sub(p.LastOffense) >= config.Reset {
+		p.Offenses = 0
+	}
+
+	if p.WindowStart.IsZero() || now.Sub(p.WindowStart) >= config.Window {
+		p.WindowStart = now
+		p.Violations = 0
+	}
+
+	p.Violations++
+	if p.Violations <= config.Threshold {
+		return false
+	}
+
+	step := p.Offenses
+	if step >= len(config.Durations) {
+		step = len(config.Durations) - 1
+	}
+
+	p.BannedUntil = now.Add(config.Durations[step])
+	p.Offenses++
+	p.LastOffense = now
+	p.Violations = 0
+	p.WindowStart = time.Time{}
+
+	return true
+}
+
+// Retention is how long the record still matters: until the ban ends, the
+// violation window closes or the offense history is forgotten.
+func (p *Penalty) Retention(now time.Time, config PenaltyConfig) time.Duration {
+	retention := p.BannedUntil.Sub(now)
+
+	if !p.WindowStart.IsZero() {
+		if d := p.WindowStart.Add(config.Window).Sub(now); d > retention {
+			retention = d
+		}
+	}
+	if !p.LastOffense.IsZero() {
+		if d := p.LastOffense.Add(config.Reset).Sub(now); d > retention {
+			retention = d
+		}
+	}
+
+	return retention
+}
+
+func NewBanDecision(penalty *Penalty, now time.Time) Decision {
+	retryAfter := penalty.BannedUntil.Sub(now)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+
+	return Decision{
+		Allowed:    false,
+		Banned:     true,
+		Reset:      penalty.BannedUntil,
+		RetryAfter: retryAfter,
+		Policy:     PenaltyPolicy,
+	}
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPenalty_RecordViolationEscalates(t *testing.T) {
+	config := PenaltyConfig{
+		Threshold: 2,
+		Window:    time.Minute,
+		Durations: []time.Duration{time.Minute, 10 * time.Minute, time.Hour},
+		Reset:     24 * time.Hour,
+	}
+
+	now := time.Now()
+	penalty := &Penalty{ClientID: "noisy"}
+
+	expected := []time.Duration{time.Minute, 10 * time.Minute, time.Hour, time.Hour}
+	for offense, duration := range expected {
+		for i := 0; i < config.Threshold; i++ {
+			if penalty.RecordViolation(now, config) {
+				t.Fatalf("Offense %d: banned before exceeding the threshold", offense+1)
+			}
+		}
+
+		if !penalty.RecordViolation(now, config) {
+			t.Fatalf("Offense %d: expected a ban", offense+1)
+		}
+		if got := penalty.BannedUntil.Sub(now); got != duration {
+			t.Errorf("Offense %d: expected %v ban, got %v", offense+1, duration, got)
+		}
+
+		now = penalty.BannedUntil
+	}
+}
+
+func TestPenalty_ViolationsOutsideWindowAndReset(t *testing.T) {
+	config := PenaltyConfig{
+		Threshold: 1,
+		Window:    time.Minute,
+		Durations: []time.Duration{time.Minute, time.Hour},
+		Reset:     time.Hour,
+	}
+
+	now := time.Now()
+	penalty := &Penalty{ClientID: "noisy"}
+
+	penalty.RecordViolation(now, config)
+	if penalty.RecordViolation(now.Add(2*time.Minute), config) {
+		t.Error("Violations in separate windows should not ban")
+	}
+
+	penalty.RecordViolation(now.Add(2*time.Minute), config)
+	if !penalty.Banned(now.Add(2*time.Minute)) || penalty.Offenses != 1 {
+		t.Fatalf("Expected first ban, got %+v", penalty)
+	}
+
+	later := now.Add(3 * time.Hour)
+	penalty.RecordViolation(later, config)
+	penalty.RecordViolation(later, config)
+	if got := penalty.BannedUntil.Sub(later); got != time.Minute {
+		t.Errorf("Offenses should reset after quiet period, got %v ban", got)
+	}
+}
+
+func TestNewBanDecision(t *testing.T) {
+	now := time.Now()
+	decision := NewBanDecision(&Penalty{BannedUntil: now.Add(90 * time.Second)}, now)
+
+	if decision.Allowed || !decision.Banned || decision.Policy != PenaltyPolicy {
+		t.Errorf("Unexpected decision %+v", decision)
+	}
+	if decision.RetryAfterSeconds() != 90 {
+		t.Errorf("Expected retry after 90s, got %d", decision.RetryAfterSeconds())
+	}
+}
@@ -1,29 +1,198 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+const MaxHierarchyDepth = 8
+
+// AnyVersion skips the config version check of a conditional update.
+const AnyVersion = -1
+
+var (
+	ErrInvalidHierarchy      = errors.New("invalid rate limit hierarchy")
+	ErrVersionMismatch       = errors.New("config version mismatch")
+	ErrNotSupportedInCluster = errors.New("not supported in cluster mode")
+)
 
 type RateLimit struct {
 	ClientID      string
+	ParentID      string
+	Tier          string
+	Custom        bool
 	RequestCount  int
 	MaxRequests   int
 	CycleDuration int
 	CycleStart    time.Time
+	Window        string
+	TimeZone      string
+	PreviousCount int
+	PreviousStart time.Time
+	// Version counts changes to the configured part of the record; 0 means
+	// the client has never been configured.
+	Version int
 }
 
-func (r *RateLimit) IsAllowed() bool {
-	now := time.Now()
+type RateLimitConfig struct {
+	MaxRequests   int
+	CycleDuration int
+	ParentID      string
+	Tier          string
+	Window        string
+	TimeZone      string
+}
+
+type ConsumeRequest struct {
+	ClientID      string
+	Cost          int
+	MaxRequests   int
+	CycleDuration int
+	Window        string
+	TimeZone      string
+	Policy        string
+}
+
+type ConsumeResult struct {
+	ClientID  string
+	Allowed   bool
+	Policy    string
+	RateLimit *RateLimit
+	Penalty   *Penalty
+}
+
+// Config is the configured part of the record. Limits are only set when the
+// client overrides them.
+func (r *RateLimit) Config() RateLimitConfig {
+	config := RateLimitConfig{
+		ParentID: r.ParentID,
+		Tier:     r.Tier,
+	}
+	if r.Custom {
+		config.MaxRequests = r.MaxRequests
+		config.CycleDuration = r.CycleDuration
+		config.Window = r.Window
+		config.TimeZone = r.TimeZone
+	}
+	return config
+}
+
+// HasConfig reports whether the record holds configuration rather than just
+// counters. Limits inherited from a tier, parent or the defaults do not count.
+func (r *RateLimit) HasConfig() bool {
+	return r.Custom || r.ParentID != "" || r.Tier != ""
+}
+
+func (r *RateLimit) location() *time.Location {
+	loc, err := LoadTimeZone(r.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Roll moves the counter into the period containing now, remembering the
+// count of the period that just ended when it directly precedes the new one.
+func (r *RateLimit) Roll(now time.Time) {
+	if IsCalendarWindow(r.Window) {
+		start := PeriodStart(r.Window, now, r.location())
+		if r.CycleStart.Equal(start) {
+			return
+		}
+
+		previousStart := PeriodStart(r.Window, start.Add(-time.Nanosecond), r.location())
+		r.PreviousStart = previousStart
+		r.PreviousCount = 0
+		if r.CycleStart.Equal(previousStart) {
+			r.PreviousCount = r.RequestCount
+		}
+
+		r.CycleStart = start
+		r.RequestCount = 0
+		return
+	}
+
 	duration := time.Duration(r.CycleDuration) * time.Minute
 
 	if now.Sub(r.CycleStart) >= duration {
+		r.PreviousStart = r.CycleStart
+		r.PreviousCount = 0
+		if now.Sub(r.CycleStart) < 2*duration {
+			r.PreviousCount = r.RequestCount
+		}
+
 		r.CycleStart = now
 		r.RequestCount = 0
 	}
+}
 
-	return r.RequestCount < r.MaxRequests
+// Reset clears the counters of the current and previous period, starting the
+// current one afresh.
+func (r *RateLimit) Reset(now time.Time) {
+	r.Roll(now)
+	if !IsCalendarWindow(r.Window) {
+		r.CycleStart = now
+	}
+	r.RequestCount = 0
+	r.PreviousCount = 0
+	r.PreviousStart = time.Time{}
+}
+
+func (r *RateLimit) Usage(now time.Time) Usage {
+	rolled := *r
+	rolled.Roll(now)
+
+	usage := Usage{
+		ClientID:    r.ClientID,
+		MaxRequests: r.MaxRequests,
+		Window:      r.Window,
+		TimeZone:    r.TimeZone,
+		Current: Period{
+			Start: rolled.CycleStart,
+			End:   rolled.GetResetTime(),
+			Count: rolled.RequestCount,
+		},
+	}
+
+	if !rolled.PreviousStart.IsZero() {
+		usage.Previous = Period{
+			Start: rolled.PreviousStart,
+			End:   rolled.CycleStart,
+			Count: rolled.PreviousCount,
+		}
+		if IsCalendarWindow(r.Window) {
+			usage.Previous.End = PeriodEnd(r.Window, rolled.PreviousStart)
+		}
+	}
+
+	return usage
+}
+
+// Retention is how long an unconfigured record needs to be kept to cover the
+// current and the previous period.
+func (r *RateLimit) Retention() time.Duration {
+	if IsCalendarWindow(r.Window) {
+		return 2 * PeriodLength(r.Window)
+	}
+	return time.Duration(r.CycleDuration*2) * time.Minute
+}
+
+func (r *RateLimit) IsAllowed() bool {
+	return r.IsAllowedN(1)
+}
+
+func (r *RateLimit) IsAllowedN(cost int) bool {
+	r.Roll(time.Now())
+
+	return r.RequestCount+cost <= r.MaxRequests
 }
 
 func (r *RateLimit) Increment() {
-	r.RequestCount++
+	r.IncrementBy(1)
+}
+
+func (r *RateLimit) IncrementBy(cost int) {
+	r.RequestCount += cost
 }
 
 func (r *RateLimit) GetRemainingRequests() int {
@@ -34,7 +203,57 @@ func (r *RateLimit) GetRemainingRequests() int {
 	return remaining
 }
 
+func (r *RateLimit) WindowLength() time.Duration {
+	if IsCalendarWindow(r.Window) {
+		return PeriodEnd(r.Window, r.CycleStart).Sub(r.CycleStart)
+	}
+	return time.Duration(r.CycleDuration) * time.Minute
+}
+
 func (r *RateLimit) GetResetTime() time.Time {
+	if IsCalendarWindow(r.Window) {
+		return PeriodEnd(r.Window, r.CycleStart)
+	}
+
 	duration := time.Duration(r.CycleDuration) * time.Minute
 	return r.CycleStart.Add(duration)
 }
+
+// EvaluateBatch consumes every request against the matching rate limit, or none
+// of them if any request would exceed its limit. Requests sharing a client ID
+// must point at the same *RateLimit so their costs accumulate. A request that
+// carries its own MaxRequests overrides the stored limits, which is how
+// inherited limits are applied.
+func EvaluateBatch(limits []*RateLimit, requests []ConsumeRequest) ([]ConsumeResult, bool) {
+	results := make([]ConsumeResult, len(requests))
+	allowed := true
+
+	for i, req := range requests {
+		rl := limits[i]
+
+		if req.MaxRequests > 0 {
+			rl.MaxRequests = req.MaxRequests
+			rl.CycleDuration = req.CycleDuration
+			rl.Window = req.Window
+			rl.TimeZone = req.TimeZone
+		}
+
+		results[i] = ConsumeResult{ClientID: req.ClientID, Policy: req.Policy, RateLimit: rl}
+		if rl.IsAllowedN(req.Cost) {
+			rl.IncrementBy(req.Cost)
+			results[i].Allowed = true
+		} else {
+			allowed = false
+		}
+	}
+
+	if !allowed {
+		for i, req := range requests {
+			if results[i].Allowed {
+				limits[i].IncrementBy(-req.Cost)
+			}
+		}
+	}
+
+	return results, allowed
+}
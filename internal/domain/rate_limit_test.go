@@ -118,3 +118,90 @@ func TestRateLimit_GetResetTime(t *testing.T) {
 			expectedReset, resetTime)
 	}
 }
+
+func TestEvaluateBatch(t *testing.T) {
+	t.Run("consume all when every limit allows", func(t *testing.T) {
+		user := &RateLimit{ClientID: "user", MaxRequests: 10, CycleDuration: 1, CycleStart: time.Now()}
+		org := &RateLimit{ClientID: "org", MaxRequests: 10, CycleDuration: 1, CycleStart: time.Now()}
+
+		requests := []ConsumeRequest{{ClientID: "user", Cost: 2}, {ClientID: "org", Cost: 3}}
+		_, allowed := EvaluateBatch([]*RateLimit{user, org}, requests)
+
+		if !allowed {
+			t.Fatal("Expected batch to be allowed")
+		}
+		if user.RequestCount != 2 || org.RequestCount != 3 {
+			t.Errorf("Expected counts 2 and 3, got %d and %d", user.RequestCount, org.RequestCount)
+		}
+	})
+
+	t.Run("consume nothing when one limit blocks", func(t *testing.T) {
+		user := &RateLimit{ClientID: "user", MaxRequests: 10, CycleDuration: 1, CycleStart: time.Now()}
+		org := &RateLimit{ClientID: "org", RequestCount: 9, MaxRequests: 10, CycleDuration: 1, CycleStart: time.Now()}
+
+		requests := []ConsumeRequest{{ClientID: "user", Cost: 1}, {ClientID: "org", Cost: 2}}
+		results, allowed := EvaluateBatch([]*RateLimit{user, org}, requests)
+
+		if allowed {
+			t.Fatal("Expected batch to be blocked")
+		}
+		if results[1].Allowed {
+			t.Error("Expected org to be reported as blocked")
+		}
+		if user.RequestCount != 0 || org.RequestCount != 9 {
+			t.Errorf("Expected counts to be rolled back, got %d and %d", user.RequestCount, org.RequestCount)
+		}
+	})
+
+	t.Run("costs accumulate for the same client", func(t *testing.T) {
+		user := &RateLimit{ClientID: "user", MaxRequests: 3, CycleDuration: 1, CycleStart: time.Now()}
+
+		requests := []ConsumeRequest{{ClientID: "user", Cost: 2}, {ClientID: "user", Cost: 2}}
+		_, allowed := EvaluateBatch([]*RateLimit{user, user}, requests)
+
+		if allowed {
+			t.Error("Expected batch to be blocked")
+		}
+		if user.RequestCount != 0 {
+			t.Errorf("Expected count 0, got %d", user.RequestCount)
+		}
+	})
+}
+
+func TestNewDecision(t *testing.T) {
+	now := time.Now()
+	rateLimit := &RateLimit{
+		RequestCount:  10,
+		MaxRequests:   10,
+		CycleDuration: 1,
+		CycleStart:    now.Add(-30 * time.Second),
+	}
+
+	decision := NewDecision(ConsumeResult{Policy: "free", RateLimit: rateLimit}, false, now)
+
+	if decision.Limit != 10 || decision.Remaining != 0 || decision.Policy != "free" {
+		t.Errorf("Unexpected decision %+v", decision)
+	}
+	if decision.RetryAfterSeconds() != 30 {
+		t.Errorf("Expected retry after 30s, got %d", decision.RetryAfterSeconds())
+	}
+	if decision.Window != time.Minute {
+		t.Errorf("Expected window of one minute, got %v", decision.Window)
+	}
+}
+
+func TestReset(t *testing.T) {
+	now := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	rolling := &RateLimit{RequestCount: 5, MaxRequests: 5, CycleDuration: 1, CycleStart: now.Add(-30 * time.Second), PreviousCount: 4, PreviousStart: now.Add(-90 * time.Second)}
+	rolling.Reset(now)
+	if rolling.RequestCount != 0 || rolling.PreviousCount != 0 || !rolling.CycleStart.Equal(now) {
+		t.Errorf("Expected a fresh rolling window at now, got %+v", rolling)
+	}
+
+	monthly := &RateLimit{RequestCount: 5, MaxRequests: 5, Window: WindowMonth, CycleStart: PeriodStart(WindowMonth, now, time.UTC)}
+	monthly.Reset(now)
+	if monthly.RequestCount != 0 || !monthly.CycleStart.Equal(PeriodStart(WindowMonth, now, time.UTC)) {
+		t.Errorf("Expected the calendar period to be kept, got %+v", monthly)
+	}
+}
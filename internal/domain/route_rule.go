@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+type RuleMatchMode string
+
+const (
+	RuleMatchMostSpecific RuleMatchMode = "most_specific"
+	RuleMatchAll          RuleMatchMode = "all"
+)
+
+var ErrRuleNotFound = errors.New("route rule not found")
+
+// RouteRule limits requests to matching routes. A global rule counts the
+// requests of all clients together; PriorityShare is the percentage of its
+// budget available per priority level, so low priority traffic is shed
+// first when the budget gets tight.
+type RouteRule struct {
+	ID            string
+	Method        string
+	Pattern       string
+	MaxRequests   int
+	CycleDuration int
+	Global        bool
+	PriorityShare int
+}
+
+// Matches reports whether the rule applies to the request. An empty method or
+// "*" matches any method; in the pattern "*" matches a single path segment and
+// a trailing "**" matches the rest of the path.
+func (r *RouteRule) Matches(method, path string) bool {
+	if r.Method != "" && r.Method != "*" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+
+	patternSegments := splitPath(r.Pattern)
+	pathSegments := splitPath(path)
+
+	for i, segment := range patternSegments {
+		if segment == "**" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
+}
+
+// Specificity orders rules so that literal segments win over wildcards, longer
+// patterns over shorter ones and a specific method over any method.
+func (r *RouteRule) Specificity() int {
+	score := 0
+	for _, segment := range splitPath(r.Pattern) {
+		switch segment {
+		case "**":
+		case "*":
+			score += 10
+		default:
+			score += 100
+		}
+	}
+
+	if r.Method != "" && r.Method != "*" {
+		score++
+	}
+
+	return score
+}
+
+func (r *RouteRule) CounterKey(clientID string) string {
+	return clientID + counterSeparator + "rule:" + r.ID
+}
+
+func (r *RouteRule) GlobalCounterKey() string {
+	return "global|rule:" + r.ID
+}
+
+// EffectiveMax is the part of a global rule's budget that traffic of the
+// given priority may use: PriorityShare percent per level, starting at
+// priority 0.
+func (r *RouteRule) EffectiveMax(priority int) int {
+	if r.PriorityShare <= 0 || r.PriorityShare >= 100 || priority < 0 {
+		return r.MaxRequests
+	}
+
+	percent := r.PriorityShare * (priority + 1)
+	if percent >= 100 {
+		return r.MaxRequests
+	}
+
+	if max := r.MaxRequests * percent / 100; max > 0 {
+		return max
+	}
+	return 1
+}
+
+// MatchRules returns the rules matching the request, most specific first. In
+// most specific mode one global and one per-client rule are kept.
+func MatchRules(rules []*RouteRule, method, path string, mode RuleMatchMode) []*RouteRule {
+	var matched []*RouteRule
+	for _, rule := range rules {
+		if rule.Matches(method, path) {
+			matched = append(matched, rule)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Specificity() > matched[j].Specificity()
+	})
+
+	if mode == RuleMatchAll {
+		return matched
+	}
+
+	var selected []*RouteRule
+	var global, client bool
+	for _, rule := range matched {
+		if rule.Global && !global || !rule.Global && !client {
+			selected = append(selected, rule)
+			global = global || rule.Global
+			client = client || !rule.Global
+		}
+	}
+
+	return selected
+}
+
+func SplitGlobalRules(rules []*RouteRule) (global, client []*RouteRule) {
+	for _, rule := range rules {
+		if rule.Global {
+			global = append(global, rule)
+		} else {
+			client = append(client, rule)
+		}
+	}
+	return global, client
+}
+
+func IsValidRulePattern(pattern string) bool {
+	if !strings.HasPrefix(pattern, "/") {
+		return false
+	}
+
+	segments := splitPath(pattern)
+	for i, segment := range segments {
+		if segment == "**" && i != len(segments)-1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,84 @@
+package domain
+
+import "testing"
+
+func TestRouteRule_Matches(t *testing.T) {
+	tests := []struct {
+		method   string
+		pattern  string
+		reqMeth  string
+		reqPath  string
+		expected bool
+	}{
+		{"POST", "/orders/*", "POST", "/orders/42", true},
+		{"POST", "/orders/*", "GET", "/orders/42", false},
+		{"POST", "/orders/*", "POST", "/orders/42/items", false},
+		{"POST", "/orders/*", "POST", "/orders", false},
+		{"", "/orders/**", "DELETE", "/orders/42/items", true},
+		{"*", "/search", "GET", "/search/", true},
+		{"GET", "/search", "GET", "/searching", false},
+	}
+
+	for _, tt := range tests {
+		rule := &RouteRule{Method: tt.method, Pattern: tt.pattern}
+		if got := rule.Matches(tt.reqMeth, tt.reqPath); got != tt.expected {
+			t.Errorf("%s %s against %s %s: expected %v, got %v",
+				tt.method, tt.pattern, tt.reqMeth, tt.reqPath, tt.expected, got)
+		}
+	}
+}
+
+func TestMatchRules(t *testing.T) {
+	all := &RouteRule{ID: "all", Pattern: "/api/**"}
+	orders := &RouteRule{ID: "orders", Pattern: "/api/orders/*"}
+	createOrder := &RouteRule{ID: "create-order", Method: "POST", Pattern: "/api/orders/*"}
+	rules := []*RouteRule{all, orders, createOrder}
+
+	matched := MatchRules(rules, "POST", "/api/orders/1", RuleMatchMostSpecific)
+	if len(matched) != 1 || matched[0].ID != "create-order" {
+		t.Errorf("Expected most specific rule create-order, got %v", matched)
+	}
+
+	matched = MatchRules(rules, "GET", "/api/orders/1", RuleMatchAll)
+	if len(matched) != 2 || matched[0].ID != "orders" || matched[1].ID != "all" {
+		t.Errorf("Expected orders and all, got %v", matched)
+	}
+
+	if matched := MatchRules(rules, "GET", "/health", RuleMatchAll); len(matched) != 0 {
+		t.Errorf("Expected no match, got %v", matched)
+	}
+}
+
+func TestMatchRules_KeepsGlobalAndClientRule(t *testing.T) {
+	global := &RouteRule{ID: "api-ceiling", Pattern: "/api/**", Global: true}
+	orders := &RouteRule{ID: "orders", Pattern: "/api/orders/*"}
+	globalOrders := &RouteRule{ID: "orders-ceiling", Pattern: "/api/orders/*", Global: true}
+
+	matched := MatchRules([]*RouteRule{global, orders, globalOrders}, "GET", "/api/orders/1", RuleMatchMostSpecific)
+	if len(matched) != 2 || matched[0].ID != "orders" || matched[1].ID != "orders-ceiling" {
+		t.Errorf("Expected orders and orders-ceiling, got %v", matched)
+	}
+
+	globalRules, clientRules := SplitGlobalRules(matched)
+	if len(globalRules) != 1 || len(clientRules) != 1 {
+		t.Errorf("Expected one global and one client rule, got %v and %v", globalRules, clientRules)
+	}
+}
+
+func TestRouteRule_EffectiveMax(t *testing.T) {
+	rule := &RouteRule{MaxRequests: 1000, PriorityShare: 40}
+
+	tests := map[int]int{0: 400, 1: 800, 2: 1000, 5: 1000}
+	for priority, expected := range tests {
+		if got := rule.EffectiveMax(priority); got != expected {
+			t.Errorf("Priority %d: expected %d, got %d", priority, expected, got)
+		}
+	}
+
+	if got := (&RouteRule{MaxRequests: 2, PriorityShare: 10}).EffectiveMax(0); got != 1 {
+		t.Errorf("Expected at least one request, got %d", got)
+	}
+	if got := (&RouteRule{MaxRequests: 50}).EffectiveMax(0); got != 50 {
+		t.Errorf("Expected full budget without priority share, got %d", got)
+	}
+}
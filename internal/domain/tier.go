@@ -0,0 +1,19 @@
+package domain
+
+import "errors"
+
+const DefaultTier = "default"
+
+var (
+	ErrTierNotFound = errors.New("tier not found")
+	ErrTierInUse    = errors.New("tier is assigned to clients")
+)
+
+type Tier struct {
+	Name          string
+	MaxRequests   int
+	CycleDuration int
+	Window        string
+	TimeZone      string
+	Priority      int
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	WindowRolling = ""
+	WindowSecond  = "second"
+	WindowMinute  = "minute"
+	WindowHour    = "hour"
+	WindowDay     = "day"
+	WindowMonth   = "month"
+)
+
+type Period struct {
+	Start time.Time
+	End   time.Time
+	Count int
+}
+
+type Usage struct {
+	ClientID    string
+	MaxRequests int
+	Window      string
+	TimeZone    string
+	Current     Period
+	Previous    Period
+}
+
+func IsValidWindow(window string) bool {
+	switch window {
+	case WindowRolling, WindowSecond, WindowMinute, WindowHour, WindowDay, WindowMonth:
+		return true
+	}
+	return false
+}
+
+func IsCalendarWindow(window string) bool {
+	return window != WindowRolling && IsValidWindow(window)
+}
+
+// timeZones caches loaded locations by name, since time.LoadLocation reads
+// the zone database on every call.
+var timeZones sync.Map
+
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := timeZones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	timeZones.Store(name, loc)
+	return loc, nil
+}
+
+// PeriodStart returns the start of the calendar period containing t, aligned
+// in loc (top of the minute/hour, midnight, first of month).
+func PeriodStart(window string, t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+
+	switch window {
+	case WindowSecond:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+	case WindowMinute:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+	case WindowHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case WindowDay:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	case WindowMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	}
+	return t
+}
+
+func PeriodEnd(window string, start time.Time) time.Time {
+	switch window {
+	case WindowSecond:
+		return start.Add(time.Second)
+	case WindowMinute:
+		return start.Add(time.Minute)
+	case WindowHour:
+		return start.Add(time.Hour)
+	case WindowDay:
+		return start.AddDate(0, 0, 1)
+	case WindowMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start
+}
+
+func PeriodLength(window string) time.Duration {
+	switch window {
+	case WindowSecond:
+		return time.Second
+	case WindowMinute:
+		return time.Minute
+	case WindowHour:
+		return time.Hour
+	case WindowDay:
+		return 24 * time.Hour
+	case WindowMonth:
+		return 31 * 24 * time.Hour
+	}
+	return 0
+}
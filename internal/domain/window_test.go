@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	now := time.Date(2024, 3, 31, 20, 45, 30, 0, time.UTC)
+
+	tests := []struct {
+		window   string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{WindowMinute, time.UTC, time.Date(2024, 3, 31, 20, 45, 0, 0, time.UTC)},
+		{WindowHour, time.UTC, time.Date(2024, 3, 31, 20, 0, 0, 0, time.UTC)},
+		{WindowDay, time.UTC, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{WindowMonth, time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{WindowDay, jakarta, time.Date(2024, 4, 1, 0, 0, 0, 0, jakarta)},
+		{WindowMonth, jakarta, time.Date(2024, 4, 1, 0, 0, 0, 0, jakarta)},
+	}
+
+	for _, tt := range tests {
+		got := PeriodStart(tt.window, now, tt.loc)
+		if !got.Equal(tt.expected) {
+			t.Errorf("%s in %s: expected %v, got %v", tt.window, tt.loc, tt.expected, got)
+		}
+	}
+}
+
+func TestRateLimit_CalendarWindow(t *testing.T) {
+	t.Run("reset at the start of the next period", func(t *testing.T) {
+		lastMonth := PeriodStart(WindowMonth, time.Now().AddDate(0, -1, 0), time.UTC)
+		rateLimit := &RateLimit{
+			RequestCount: 10,
+			MaxRequests:  10,
+			Window:       WindowMonth,
+			CycleStart:   lastMonth,
+		}
+
+		if !rateLimit.IsAllowed() {
+			t.Fatal("Expected to allow in the new month")
+		}
+		if rateLimit.PreviousCount != 10 {
+			t.Errorf("Expected previous count 10, got %d", rateLimit.PreviousCount)
+		}
+		if !rateLimit.PreviousStart.Equal(lastMonth) {
+			t.Errorf("Expected previous start %v, got %v", lastMonth, rateLimit.PreviousStart)
+		}
+	})
+
+	t.Run("reset time is the end of the period", func(t *testing.T) {
+		start := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		rateLimit := &RateLimit{Window: WindowMonth, CycleStart: start}
+
+		expected := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		if !rateLimit.GetResetTime().Equal(expected) {
+			t.Errorf("Expected reset %v, got %v", expected, rateLimit.GetResetTime())
+		}
+	})
+
+	t.Run("usage drops stale previous period", func(t *testing.T) {
+		rateLimit := &RateLimit{
+			RequestCount: 7,
+			MaxRequests:  10,
+			Window:       WindowDay,
+			CycleStart:   PeriodStart(WindowDay, time.Now().AddDate(0, 0, -3), time.UTC),
+		}
+
+		usage := rateLimit.Usage(time.Now())
+		if usage.Current.Count != 0 || usage.Previous.Count != 0 {
+			t.Errorf("Expected empty usage, got %d and %d", usage.Current.Count, usage.Previous.Count)
+		}
+		if rateLimit.RequestCount != 7 {
+			t.Error("Usage should not modify the rate limit")
+		}
+	})
+}
+
+func TestLoadTimeZone(t *testing.T) {
+	first, err := LoadTimeZone("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	second, _ := LoadTimeZone("Asia/Jakarta")
+	if first != second {
+		t.Error("Expected the cached location to be reused")
+	}
+
+	if _, err := LoadTimeZone("Mars/Base"); err == nil {
+		t.Error("Expected an error for an unknown time zone")
+	}
+}
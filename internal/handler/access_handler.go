@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AccessHandler struct {
+	useCase usecase.RateLimiterUseCase
+}
+
+func NewAccessHandler(useCase usecase.RateLimiterUseCase) *AccessHandler {
+	return &AccessHandler{
+		useCase: useCase,
+	}
+}
+
+// accessRequest takes the kind from the pattern when it is omitted: IPs and
+// CIDRs match the client address, anything else the client ID.
+type accessRequest struct {
+	List       string     `json:"list"`
+	Kind       string     `json:"kind"`
+	Pattern    string     `json:"pattern"`
+	Reason     string     `json:"reason"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	TTLSeconds int        `json:"ttl_seconds"`
+}
+
+func (r accessRequest) validate(now time.Time) []response.FieldError {
+	var errs []response.FieldError
+	invalid := func(field, message string) {
+		errs = append(errs, response.FieldError{Field: field, Message: message})
+	}
+
+	if !domain.IsValidAccessList(domain.AccessList(r.List)) {
+		invalid("list", "Must be allow or deny")
+	}
+	kind := r.kind()
+	if !domain.IsValidAccessKind(kind) {
+		invalid("kind", "Must be ip or client")
+	} else if !domain.IsValidAccessPattern(kind, r.Pattern) {
+		invalid("pattern", "Must be an IP or CIDR for ip entries, a client ID or glob for client entries")
+	}
+	if r.TTLSeconds < 0 {
+		invalid("ttl_seconds", "Must not be negative")
+	} else if r.ExpiresAt != nil && r.TTLSeconds > 0 {
+		invalid("ttl_seconds", "Not allowed together with expires_at")
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		invalid("expires_at", "Must be in the future")
+	}
+
+	return errs
+}
+
+func (r accessRequest) kind() domain.AccessKind {
+	if r.Kind == "" {
+		return domain.AccessKindOf(r.Pattern)
+	}
+	return domain.AccessKind(r.Kind)
+}
+
+func (r accessRequest) toEntry(id string, now time.Time) *domain.AccessEntry {
+	entry := &domain.AccessEntry{
+		ID:      id,
+		List:    domain.AccessList(r.List),
+		Kind:    r.kind(),
+		Pattern: r.Pattern,
+		Reason:  r.Reason,
+	}
+
+	switch {
+	case r.ExpiresAt != nil:
+		entry.ExpiresAt = *r.ExpiresAt
+	case r.TTLSeconds > 0:
+		entry.ExpiresAt = now.Add(time.Duration(r.TTLSeconds) * time.Second)
+	}
+
+	return entry
+}
+
+func accessResponse(entry *domain.AccessEntry) map[string]interface{} {
+	resp := map[string]interface{}{
+		"id":         entry.ID,
+		"list":       entry.List,
+		"kind":       entry.ResolvedKind(),
+		"pattern":    entry.Pattern,
+		"reason":     entry.Reason,
+		"expires_at": nil,
+	}
+	if !entry.ExpiresAt.IsZero() {
+		resp["expires_at"] = entry.ExpiresAt.Unix()
+	}
+	return resp
+}
+
+func (h *AccessHandler) ListAccessEntries(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	entries, err := h.useCase.ListAccessEntries(ctx)
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to list access entries")
+	}
+
+	list := c.QueryParam("list")
+
+	items := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		if list == "" || string(entry.List) == list {
+			items = append(items, accessResponse(entry))
+		}
+	}
+
+	return response.Success(c, items)
+}
+
+func (h *AccessHandler) GetAccessEntry(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	entry, err := h.useCase.GetAccessEntry(ctx, c.Param("id"))
+	if errors.Is(err, domain.ErrAccessEntryNotFound) {
+		return response.Error(c, http.StatusNotFound, "Access entry not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to get access entry")
+	}
+
+	return response.Success(c, accessResponse(entry))
+}
+
+func (h *AccessHandler) SaveAccessEntry(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var req accessRequest
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	now := time.Now()
+	if !ruleIDPattern.MatchString(c.Param("id")) {
+		return response.Error(c, http.StatusBadRequest, "Invalid access entry ID")
+	}
+	if errs := req.validate(now); len(errs) > 0 {
+		return response.ValidationError(c, "Invalid access entry values", errs)
+	}
+
+	entry := req.toEntry(c.Param("id"), now)
+	if err := h.useCase.SaveAccessEntry(ctx, entry); err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to save access entry")
+	}
+
+	return response.Success(c, accessResponse(entry))
+}
+
+func (h *AccessHandler) DeleteAccessEntry(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	err := h.useCase.DeleteAccessEntry(ctx, c.Param("id"))
+	if errors.Is(err, domain.ErrAccessEntryNotFound) {
+		return response.Error(c, http.StatusNotFound, "Access entry not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to delete access entry")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Delete access entry successfully",
+	})
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"rate-limiter-go/internal/audit"
+	"rate-limiter-go/pkg/response"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	SignatureKeyHeader       = "X-Signature-Key"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureHeader          = "X-Signature"
+)
+
+// maxSignedBodyBytes caps the body buffered to verify a signature.
+const maxSignedBodyBytes = 1 << 20
+
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+)
+
+// AdminCredential grants a role to a bearer token, an HMAC signing key or a
+// client certificate common name. Name identifies the caller in the audit log.
+type AdminCredential struct {
+	Name   string
+	Role   Role
+	Secret string
+}
+
+// AdminAuthConfig lists the accepted credentials. Without any, every request
+// is rejected unless Disabled is set.
+type AdminAuthConfig struct {
+	Tokens       []AdminCredential
+	HMACKeys     []AdminCredential
+	ClientCerts  []AdminCredential
+	MaxClockSkew time.Duration
+	Disabled     bool
+}
+
+func (c AdminAuthConfig) Enabled() bool {
+	return len(c.Tokens) > 0 || len(c.HMACKeys) > 0 || len(c.ClientCerts) > 0
+}
+
+// ParseAdminCredentials parses "name:role:secret" entries, or "name:role"
+// when withSecret is false.
+func ParseAdminCredentials(specs []string, withSecret bool) ([]AdminCredential, error) {
+	parts := 2
+	if withSecret {
+		parts = 3
+	}
+
+	credentials := make([]AdminCredential, 0, len(specs))
+	for _, spec := range specs {
+		fields := strings.SplitN(spec, ":", parts)
+		if len(fields) != parts || fields[0] == "" || withSecret && fields[2] == "" {
+			return nil, fmt.Errorf("invalid admin credential %q", spec)
+		}
+
+		role := Role(fields[1])
+		if role != RoleViewer && role != RoleOperator {
+			return nil, fmt.Errorf("invalid role %q for %s", fields[1], fields[0])
+		}
+
+		credential := AdminCredential{Name: fields[0], Role: role}
+		if withSecret {
+			credential.Secret = fields[2]
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, nil
+}
+
+// AdminAuthMiddleware requires a bearer token, an HMAC signature or a verified
+// client certificate. Viewers may only read; writes need an operator. With no
+// credentials configured every request is rejected, or let through when
+// authentication is explicitly disabled.
+func AdminAuthMiddleware(config AdminAuthConfig) echo.MiddlewareFunc {
+	if config.MaxClockSkew <= 0 {
+		config.MaxClockSkew = 5 * time.Minute
+	}
+
+	replays := newReplayCache()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			if !config.Enabled() {
+				if config.Disabled {
+					return next(c)
+				}
+				return response.Error(c, http.StatusUnauthorized, "Admin authentication not configured")
+			}
+
+			credential, reason := authenticateAdmin(c, config, replays)
+			if credential == nil {
+				c.Response().Header().Set("WWW-Authenticate", "Bearer")
+				return response.Error(c, http.StatusUnauthorized, reason)
+			}
+
+			if credential.Role != RoleOperator && !readOnly(c.Request().Method) {
+				return response.Error(c, http.StatusForbidden, "Operator role required")
+			}
+
+			ctx := audit.WithActor(c.Request().Context(), credential.Name)
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// authenticateAdmin returns the caller's credential, or nil and the reason it
+// was rejected.
+func authenticateAdmin(c echo.Context, config AdminAuthConfig, replays *replayCache) (*AdminCredential, string) {
+	req := c.Request()
+
+	if token, ok := strings.CutPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer "); ok && len(config.Tokens) > 0 {
+		for i := range config.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(config.Tokens[i].Secret)) == 1 {
+				return &config.Tokens[i], ""
+			}
+		}
+		return nil, "Invalid token"
+	}
+
+	if name := req.Header.Get(SignatureKeyHeader); name != "" && len(config.HMACKeys) > 0 {
+		return verifySignature(c, config, replays, name)
+	}
+
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(config.ClientCerts) > 0 {
+		cn := req.TLS.VerifiedChains[0][0].Subject.CommonName
+		for i := range config.ClientCerts {
+			if config.ClientCerts[i].Name == cn {
+				return &config.ClientCerts[i], ""
+			}
+		}
+		return nil, "Client certificate not authorized"
+	}
+
+	return nil, "Authentication required"
+}
+
+// verifySignature checks X-Signature against Sign under the named key and
+// rejects timestamps outside the allowed clock skew as well as signatures
+// that were already used.
+func verifySignature(c echo.Context, config AdminAuthConfig, replays *replayCache, name string) (*AdminCredential, string) {
+	req := c.Request()
+
+	var credential *AdminCredential
+	for i := range config.HMACKeys {
+		if config.HMACKeys[i].Name == name {
+			credential = &config.HMACKeys[i]
+		}
+	}
+	if credential == nil {
+		return nil, "Unknown signing key"
+	}
+
+	timestamp := req.Header.Get(SignatureTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, "Invalid signature timestamp"
+	}
+	signedAt := time.Unix(unix, 0)
+	if skew := time.Since(signedAt); skew > config.MaxClockSkew || skew < -config.MaxClockSkew {
+		return nil, "Signature expired"
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, maxSignedBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, "Request body too large"
+		}
+		return nil, "Unable to read request body"
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	signature := req.Header.Get(SignatureHeader)
+	expected := Sign(credential.Secret, req.Method, req.URL.RequestURI(), timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, "Invalid signature"
+	}
+
+	if !replays.use(signature, signedAt.Add(config.MaxClockSkew)) {
+		return nil, "Signature already used"
+	}
+
+	return credential, ""
+}
+
+// replayCache remembers verified signatures until their timestamp falls out
+// of the clock skew window, after which they are rejected as expired anyway.
+type replayCache struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{
+		seen: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+// use records signature until expires and reports whether it was unused.
+// Expired signatures are swept at most once a minute.
+func (r *replayCache) use(signature string, expires time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.After(r.nextSweep) {
+		for seen, until := range r.seen {
+			if !until.After(now) {
+				delete(r.seen, seen)
+			}
+		}
+		r.nextSweep = now.Add(time.Minute)
+	}
+
+	if until, ok := r.seen[signature]; ok && until.After(now) {
+		return false
+	}
+
+	r.seen[signature] = expires
+	return true
+}
+
+// Sign returns the hex HMAC-SHA256 over the method, request URI, timestamp
+// and body hash, each on its own line.
+func Sign(secret, method, requestURI, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", method, requestURI, timestamp, hex.EncodeToString(bodyHash[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func readOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
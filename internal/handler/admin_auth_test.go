@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/audit"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var testAdminAuth = AdminAuthConfig{
+	Tokens: []AdminCredential{
+		{Name: "alice", Role: RoleOperator, Secret: "operator-token"},
+		{Name: "grafana", Role: RoleViewer, Secret: "viewer-token"},
+	},
+	HMACKeys: []AdminCredential{
+		{Name: "ci", Role: RoleOperator, Secret: "signing-secret"},
+	},
+	ClientCerts: []AdminCredential{
+		{Name: "ops-team", Role: RoleOperator},
+	},
+}
+
+func serveAdmin(config AdminAuthConfig, req *http.Request) (*httptest.ResponseRecorder, string) {
+	var actor string
+
+	e := echo.New()
+	e.Any("/admin", func(c echo.Context) error {
+		actor = audit.Actor(c.Request().Context())
+		return c.NoContent(http.StatusNoContent)
+	}, AdminAuthMiddleware(config))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec, actor
+}
+
+func signedRequest(method, body, key, secret string, at time.Time) *http.Request {
+	req := httptest.NewRequest(method, "/admin", strings.NewReader(body))
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	req.Header.Set(SignatureKeyHeader, key)
+	req.Header.Set(SignatureTimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(secret, method, "/admin", timestamp, []byte(body)))
+	return req
+}
+
+func certRequest(cn string) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, "/admin", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}},
+	}
+	return req
+}
+
+func TestAdminAuth_Allowed(t *testing.T) {
+	bearer := func(method, token string) *http.Request {
+		req := httptest.NewRequest(method, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	tests := []struct {
+		name  string
+		req   *http.Request
+		actor string
+	}{
+		{"operator token writes", bearer(http.MethodPut, "operator-token"), "alice"},
+		{"viewer token reads", bearer(http.MethodGet, "viewer-token"), "grafana"},
+		{"signed request", signedRequest(http.MethodPut, `{"max_requests":5}`, "ci", "signing-secret", time.Now()), "ci"},
+		{"client certificate", certRequest("ops-team"), "ops-team"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, actor := serveAdmin(testAdminAuth, tt.req)
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("Expected request to pass, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if actor != tt.actor {
+				t.Errorf("Expected actor %q, got %q", tt.actor, actor)
+			}
+		})
+	}
+}
+
+func TestAdminAuth_Denied(t *testing.T) {
+	bearer := func(method, token string) *http.Request {
+		req := httptest.NewRequest(method, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	tampered := signedRequest(http.MethodPut, `{"max_requests":5}`, "ci", "signing-secret", time.Now())
+	tampered.Body = httptest.NewRequest(http.MethodPut, "/admin", strings.NewReader(`{"max_requests":5000}`)).Body
+
+	badTimestamp := signedRequest(http.MethodPut, "", "ci", "signing-secret", time.Now())
+	badTimestamp.Header.Set(SignatureTimestampHeader, "yesterday")
+
+	unverified := httptest.NewRequest(http.MethodPut, "/admin", nil)
+	unverified.TLS = &tls.ConnectionState{}
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		code    int
+		message string
+	}{
+		{"no credentials", httptest.NewRequest(http.MethodGet, "/admin", nil), http.StatusUnauthorized, "Authentication required"},
+		{"unknown token", bearer(http.MethodGet, "guessed"), http.StatusUnauthorized, "Invalid token"},
+		{"viewer writes", bearer(http.MethodPut, "viewer-token"), http.StatusForbidden, "Operator role required"},
+		{"viewer deletes", bearer(http.MethodDelete, "viewer-token"), http.StatusForbidden, "Operator role required"},
+		{"unknown signing key", signedRequest(http.MethodPut, "", "intruder", "signing-secret", time.Now()), http.StatusUnauthorized, "Unknown signing key"},
+		{"wrong signing secret", signedRequest(http.MethodPut, "", "ci", "wrong-secret", time.Now()), http.StatusUnauthorized, "Invalid signature"},
+		{"tampered body", tampered, http.StatusUnauthorized, "Invalid signature"},
+		{"expired signature", signedRequest(http.MethodPut, "", "ci", "signing-secret", time.Now().Add(-time.Hour)), http.StatusUnauthorized, "Signature expired"},
+		{"future signature", signedRequest(http.MethodPut, "", "ci", "signing-secret", time.Now().Add(time.Hour)), http.StatusUnauthorized, "Signature expired"},
+		{"invalid timestamp", badTimestamp, http.StatusUnauthorized, "Invalid signature timestamp"},
+		{"unknown certificate", certRequest("someone-else"), http.StatusUnauthorized, "Client certificate not authorized"},
+		{"unverified certificate", unverified, http.StatusUnauthorized, "Authentication required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := serveAdmin(testAdminAuth, tt.req)
+			if rec.Code != tt.code {
+				t.Fatalf("Expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("Expected %q in %s", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminAuth_WithoutCredentials(t *testing.T) {
+	rec, _ := serveAdmin(AdminAuthConfig{}, httptest.NewRequest(http.MethodPut, "/admin", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without configured credentials, got %d", rec.Code)
+	}
+
+	rec, _ = serveAdmin(AdminAuthConfig{Disabled: true}, httptest.NewRequest(http.MethodPut, "/admin", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected request to pass with authentication disabled, got %d", rec.Code)
+	}
+}
+
+func TestAdminAuth_SignatureReplay(t *testing.T) {
+	e := echo.New()
+	e.Any("/admin", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, AdminAuthMiddleware(testAdminAuth))
+
+	serve := func(req *http.Request) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	now := time.Now()
+	if code := serve(signedRequest(http.MethodPut, `{"max_requests":5}`, "ci", "signing-secret", now)); code != http.StatusNoContent {
+		t.Fatalf("Expected the first request to pass, got %d", code)
+	}
+	if code := serve(signedRequest(http.MethodPut, `{"max_requests":5}`, "ci", "signing-secret", now)); code != http.StatusUnauthorized {
+		t.Errorf("Expected a replayed signature to be rejected, got %d", code)
+	}
+	if code := serve(signedRequest(http.MethodPut, `{"max_requests":6}`, "ci", "signing-secret", now)); code != http.StatusNoContent {
+		t.Errorf("Expected a different request to pass, got %d", code)
+	}
+}
+
+func TestAdminAuth_SignedBodyLimit(t *testing.T) {
+	body := strings.Repeat("a", maxSignedBodyBytes+1)
+
+	rec, _ := serveAdmin(testAdminAuth, signedRequest(http.MethodPut, body, "ci", "signing-secret", time.Now()))
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "Request body too large") {
+		t.Errorf("Expected an oversized body to be rejected, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReplayCache_ForgetsExpiredSignatures(t *testing.T) {
+	now := time.Now()
+	cache := newReplayCache()
+	cache.now = func() time.Time { return now }
+
+	if !cache.use("sig", now.Add(time.Minute)) || cache.use("sig", now.Add(time.Minute)) {
+		t.Fatal("Expected the signature to be accepted once")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !cache.use("sig", now.Add(time.Minute)) {
+		t.Error("Expected an expired signature to be forgotten")
+	}
+	if len(cache.seen) != 1 {
+		t.Errorf("Expected expired entries to be swept, got %d", len(cache.seen))
+	}
+}
+
+func TestParseAdminCredentials(t *testing.T) {
+	credentials, err := ParseAdminCredentials([]string{"alice:operator:s3cr:et"}, true)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if credentials[0].Name != "alice" || credentials[0].Role != RoleOperator || credentials[0].Secret != "s3cr:et" {
+		t.Errorf("Unexpected credential: %+v", credentials[0])
+	}
+
+	if _, err := ParseAdminCredentials([]string{"ops-team:viewer"}, false); err != nil {
+		t.Errorf("Expected name:role to parse, got %v", err)
+	}
+
+	for _, spec := range []string{"alice:operator", "alice:admin:secret", ":viewer:secret", "alice:viewer:"} {
+		if _, err := ParseAdminCredentials([]string{spec}, true); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"rate-limiter-go/internal/audit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuditActorMiddleware attributes the configuration changes made by a request
+// to the caller's address in the audit log.
+func AuditActorMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			ctx := audit.WithActor(c.Request().Context(), c.RealIP())
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
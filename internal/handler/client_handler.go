@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+	maxBulkSize     = 1000
+)
+
+type bulkConfigureItem struct {
+	ClientID string `json:"client_id"`
+	configureRequest
+}
+
+type ClientHandler struct {
+	useCase usecase.RateLimiterUseCase
+}
+
+func NewClientHandler(useCase usecase.RateLimiterUseCase) *ClientHandler {
+	return &ClientHandler{
+		useCase: useCase,
+	}
+}
+
+func clientResponse(rateLimit *domain.RateLimit) map[string]interface{} {
+	resp := map[string]interface{}{
+		"client_id":      rateLimit.ClientID,
+		"parent_id":      rateLimit.ParentID,
+		"tier":           rateLimit.Tier,
+		"custom":         rateLimit.Custom,
+		"version":        rateLimit.Version,
+		"max_requests":   nil,
+		"cycle_duration": nil,
+		"window":         nil,
+		"timezone":       nil,
+	}
+	if rateLimit.Custom {
+		window := rateLimit.Window
+		if window == domain.WindowRolling {
+			window = "rolling"
+		}
+
+		resp["max_requests"] = rateLimit.MaxRequests
+		resp["cycle_duration"] = rateLimit.CycleDuration
+		resp["window"] = window
+		resp["timezone"] = rateLimit.TimeZone
+	}
+	return resp
+}
+
+func (h *ClientHandler) ListClients(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	limit := defaultPageSize
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxPageSize {
+			return response.Error(c, http.StatusBadRequest, fmt.Sprintf("Limit must be between 1 and %d", maxPageSize))
+		}
+		limit = parsed
+	}
+
+	filter := domain.ClientFilter{
+		Prefix: c.QueryParam("prefix"),
+		Tier:   c.QueryParam("tier"),
+	}
+
+	clients, next, err := h.useCase.ListClients(ctx, filter, c.QueryParam("cursor"), limit)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		return response.Error(c, http.StatusBadRequest, "Invalid cursor")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to list clients")
+	}
+
+	items := make([]map[string]interface{}, len(clients))
+	for i, client := range clients {
+		items[i] = clientResponse(client)
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"clients":     items,
+		"next_cursor": next,
+	})
+}
+
+func (h *ClientHandler) GetClient(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	rateLimit, usage, err := h.useCase.GetClient(ctx, clientID)
+	if errors.Is(err, domain.ErrClientNotFound) {
+		return response.Error(c, http.StatusNotFound, "Client not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to get client")
+	}
+
+	penalty, err := h.useCase.GetPenalty(ctx, clientID)
+	if err != nil && !errors.Is(err, domain.ErrPenaltyNotFound) {
+		return response.Error(c, http.StatusInternalServerError, "Failed to get penalty")
+	}
+
+	resp := clientResponse(rateLimit)
+	resp["usage"] = usageResponse(usage)
+	resp["penalty"] = penaltyResponse(penalty, time.Now())
+
+	c.Response().Header().Set("ETag", etag(rateLimit.Version))
+	return response.Success(c, resp)
+}
+
+func (h *ClientHandler) ResetClient(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	err := h.useCase.ResetRateLimit(ctx, clientID)
+	if errors.Is(err, domain.ErrClientNotFound) {
+		return response.Error(c, http.StatusNotFound, "Client not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to reset client")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Reset client successfully",
+	})
+}
+
+func (h *ClientHandler) DeleteClient(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	err := h.useCase.DeleteRateLimit(ctx, clientID)
+	if errors.Is(err, domain.ErrClientNotFound) {
+		return response.Error(c, http.StatusNotFound, "Client not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to delete client")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Delete client successfully",
+	})
+}
+
+// BulkConfigure validates every entry before saving any, then applies them
+// one by one and reports the outcome of each.
+func (h *ClientHandler) BulkConfigure(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var req []bulkConfigureItem
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if len(req) == 0 || len(req) > maxBulkSize {
+		return response.Error(c, http.StatusBadRequest, fmt.Sprintf("Bulk must contain between 1 and %d configurations", maxBulkSize))
+	}
+
+	var errs []response.FieldError
+	seen := make(map[string]bool)
+	for i, item := range req {
+		prefix := fmt.Sprintf("[%d].", i)
+		switch {
+		case item.ClientID == "":
+			errs = append(errs, response.FieldError{Field: prefix + "client_id", Message: "Required"})
+		case !domain.IsValidClientID(item.ClientID):
+			errs = append(errs, response.FieldError{Field: prefix + "client_id", Message: "Must not contain \"|\""})
+		case seen[item.ClientID]:
+			errs = append(errs, response.FieldError{Field: prefix + "client_id", Message: "Duplicate client"})
+		}
+		seen[item.ClientID] = true
+
+		if item.configureRequest == (configureRequest{}) {
+			errs = append(errs, response.FieldError{Field: prefix + "max_requests", Message: "One of max_requests, parent_id or tier is required"})
+		}
+		for _, err := range item.validate(item.ClientID) {
+			errs = append(errs, response.FieldError{Field: prefix + err.Field, Message: err.Message})
+		}
+	}
+	if len(errs) > 0 {
+		return response.ValidationError(c, "Invalid configuration values", errs)
+	}
+
+	saved := 0
+	results := make([]map[string]interface{}, len(req))
+	for i, item := range req {
+		result := map[string]interface{}{
+			"client_id": item.ClientID,
+			"saved":     false,
+			"error":     nil,
+		}
+
+		err := h.useCase.ConfigureRateLimit(ctx, item.ClientID, item.toConfig())
+		switch {
+		case errors.Is(err, domain.ErrInvalidHierarchy):
+			result["error"] = "Invalid parent hierarchy"
+		case errors.Is(err, domain.ErrTierNotFound):
+			result["error"] = "Tier not found"
+		case err != nil:
+			result["error"] = "Failed to configure rate limit"
+		default:
+			result["saved"] = true
+			saved++
+		}
+		results[i] = result
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"saved":   saved,
+		"results": results,
+	})
+}
@@ -0,0 +1,385 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"rate-limiter-go/internal/cluster"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ClusterHandler struct {
+	local  usecase.RateLimiterUseCase
+	secret string
+}
+
+func NewClusterHandler(local usecase.RateLimiterUseCase, secret string) *ClusterHandler {
+	return &ClusterHandler{
+		local:  local,
+		secret: secret,
+	}
+}
+
+func (h *ClusterHandler) Register(e *echo.Echo) {
+	internal := e.Group("/internal/v1/cluster")
+	internal.Use(h.authenticate)
+	internal.POST("/check/:clientID", h.Check)
+	internal.POST("/route-check/:clientID", h.CheckRoute)
+	internal.POST("/refund/:clientID", h.Refund)
+	internal.POST("/batch", h.CheckBatch)
+	internal.PUT("/config/:clientID", h.Configure)
+	internal.PUT("/tier/:clientID", h.AssignTier)
+	internal.GET("/usage/:clientID", h.GetUsage)
+	internal.GET("/clients/:clientID", h.GetClient)
+	internal.POST("/clients/:clientID/reset", h.ResetClient)
+	internal.DELETE("/clients/:clientID", h.DeleteClient)
+	internal.GET("/penalty/:clientID", h.GetPenalty)
+	internal.DELETE("/penalty/:clientID", h.Unban)
+	internal.PUT("/tiers/:name", h.SaveTier)
+	internal.DELETE("/tiers/:name", h.DeleteTier)
+	internal.PUT("/rules/:id", h.SaveRule)
+	internal.DELETE("/rules/:id", h.DeleteRule)
+	internal.PUT("/access/:id", h.SaveAccessEntry)
+	internal.DELETE("/access/:id", h.DeleteAccessEntry)
+}
+
+// authenticate rejects every request while no secret is configured.
+func (h *ClusterHandler) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		got := c.Request().Header.Get(cluster.SecretHeader)
+		if h.secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(h.secret)) != 1 {
+			return response.Error(c, http.StatusForbidden, "Invalid cluster secret")
+		}
+
+		return next(c)
+	}
+}
+
+func (h *ClusterHandler) Check(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := clusterClientID(c)
+	if clientID == "" {
+		return response.Error(c, http.StatusBadRequest, "Client ID required")
+	}
+
+	decision := h.local.CheckRateLimit(ctx, clientID)
+
+	return response.Success(c, cluster.NewCheckResult(decision))
+}
+
+func (h *ClusterHandler) CheckRoute(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := clusterClientID(c)
+	if clientID == "" {
+		return response.Error(c, http.StatusBadRequest, "Client ID required")
+	}
+
+	var req struct {
+		Rules []string `json:"rules"`
+	}
+
+	if err := c.Bind(&req); err != nil || len(req.Rules) == 0 {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	rules := make([]*domain.RouteRule, len(req.Rules))
+	for i, id := range req.Rules {
+		rule, err := h.local.GetRule(ctx, id)
+		if err != nil {
+			return peerError(c, err)
+		}
+		rules[i] = rule
+	}
+
+	decision := h.local.CheckRouteRateLimit(ctx, clientID, rules)
+
+	return response.Success(c, cluster.NewCheckResult(decision))
+}
+
+func (h *ClusterHandler) Refund(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := clusterClientID(c)
+	if clientID == "" {
+		return response.Error(c, http.StatusBadRequest, "Client ID required")
+	}
+
+	var req struct {
+		Rules []string `json:"rules"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	rules := make([]*domain.RouteRule, len(req.Rules))
+	for i, id := range req.Rules {
+		rule, err := h.local.GetRule(ctx, id)
+		if err != nil {
+			return peerError(c, err)
+		}
+		rules[i] = rule
+	}
+
+	h.local.RefundRateLimit(ctx, clientID, rules)
+
+	return response.Success(c, map[string]string{"message": "Rate limit refunded successfully"})
+}
+
+func (h *ClusterHandler) CheckBatch(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var requests []domain.ConsumeRequest
+
+	if err := c.Bind(&requests); err != nil || len(requests) == 0 {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	results, allowed, err := h.local.CheckRateLimitBatch(ctx, requests)
+	if err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, cluster.BatchResult{Allowed: allowed, Results: results})
+}
+
+func (h *ClusterHandler) Configure(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := clusterClientID(c)
+	if clientID == "" {
+		return response.Error(c, http.StatusBadRequest, "Client ID required")
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return response.Error(c, http.StatusPreconditionFailed, "Config version mismatch")
+	}
+
+	var req configureRequest
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	version, err := h.local.ConfigureRateLimitIfMatch(ctx, clientID, req.toConfig(), version)
+	if err != nil {
+		return peerError(c, err)
+	}
+
+	c.Response().Header().Set("ETag", etag(version))
+	return response.Success(c, map[string]interface{}{
+		"message": "Save setting successfully",
+		"version": version,
+	})
+}
+
+func (h *ClusterHandler) AssignTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var req struct {
+		Tier string `json:"tier"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.local.AssignTier(ctx, clusterClientID(c), req.Tier); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Tier assigned successfully"})
+}
+
+func (h *ClusterHandler) GetUsage(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	usage, err := h.local.GetUsage(ctx, clusterClientID(c))
+	if err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, usage)
+}
+
+func (h *ClusterHandler) GetClient(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	rateLimit, usage, err := h.local.GetClient(ctx, clusterClientID(c))
+	if err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, cluster.ClientResult{RateLimit: rateLimit, Usage: usage})
+}
+
+func (h *ClusterHandler) ResetClient(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	if err := h.local.ResetRateLimit(ctx, clusterClientID(c)); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Client reset successfully"})
+}
+
+func (h *ClusterHandler) DeleteClient(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	if err := h.local.DeleteRateLimit(ctx, clusterClientID(c)); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Client deleted successfully"})
+}
+
+func (h *ClusterHandler) GetPenalty(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	penalty, err := h.local.GetPenalty(ctx, clusterClientID(c))
+	if err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, penalty)
+}
+
+func (h *ClusterHandler) Unban(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	if err := h.local.Unban(ctx, clusterClientID(c)); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Client unbanned successfully"})
+}
+
+func (h *ClusterHandler) SaveTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var tier domain.Tier
+
+	if err := c.Bind(&tier); err != nil || tier.Name != c.Param("name") {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.local.SaveTier(ctx, &tier); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Tier saved successfully"})
+}
+
+func (h *ClusterHandler) DeleteTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	if err := h.local.DeleteTier(ctx, c.Param("name")); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Tier deleted successfully"})
+}
+
+func (h *ClusterHandler) SaveRule(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var rule domain.RouteRule
+
+	if err := c.Bind(&rule); err != nil || rule.ID != c.Param("id") {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.local.SaveRule(ctx, &rule); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Rule saved successfully"})
+}
+
+func (h *ClusterHandler) DeleteRule(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	if err := h.local.DeleteRule(ctx, c.Param("id")); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Rule deleted successfully"})
+}
+
+func (h *ClusterHandler) SaveAccessEntry(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var entry domain.AccessEntry
+
+	if err := c.Bind(&entry); err != nil || entry.ID != c.Param("id") {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.local.SaveAccessEntry(ctx, &entry); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Access entry saved successfully"})
+}
+
+func (h *ClusterHandler) DeleteAccessEntry(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	if err := h.local.DeleteAccessEntry(ctx, c.Param("id")); err != nil {
+		return peerError(c, err)
+	}
+
+	return response.Success(c, map[string]string{"message": "Access entry deleted successfully"})
+}
+
+// clusterClientID undoes the escaping of the forwarding peer. Echo leaves
+// path parameters escaped when the path contains an encoded slash.
+func clusterClientID(c echo.Context) string {
+	clientID := c.Param("clientID")
+	if c.Request().URL.RawPath == "" {
+		return clientID
+	}
+
+	unescaped, err := url.PathUnescape(clientID)
+	if err != nil {
+		return clientID
+	}
+	return unescaped
+}
+
+// peerError reports err with the code the forwarding peer maps back to the
+// same domain error.
+func peerError(c echo.Context, err error) error {
+	code, status := cluster.ErrorCode(err)
+	if code == "" {
+		return response.Error(c, status, "Cluster operation failed")
+	}
+
+	return response.ErrorWithData(c, status, err.Error(), map[string]string{"code": code})
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"rate-limiter-go/internal/health"
+	"rate-limiter-go/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+func (h *HealthHandler) Register(e *echo.Echo) {
+	e.GET("/healthz", h.Liveness)
+	e.GET("/readyz", h.Readiness)
+}
+
+// Liveness only reports that the process is serving requests; backend
+// problems are left to Readiness so a broken Redis does not restart pods.
+func (h *HealthHandler) Liveness(c echo.Context) error {
+
+	return response.Success(c, map[string]interface{}{
+		"status": "alive",
+		"build":  health.Build(),
+	})
+}
+
+func (h *HealthHandler) Readiness(c echo.Context) error {
+
+	status, ready := h.checker.Ready(c.Request().Context())
+	if !ready {
+		return response.ErrorWithData(c, http.StatusServiceUnavailable, "Service not ready", status)
+	}
+
+	return response.Success(c, status)
+}
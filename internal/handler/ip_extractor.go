@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NewIPExtractor trusts X-Forwarded-For only when the request arrives through
+// one of the trusted proxies. The header is walked right to left and the first
+// address that is not a trusted proxy is the client, so values prepended by
+// the client itself are never used. Without trusted proxies the connection
+// address is used as is.
+func NewIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, ipNet := range trustedProxies {
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/pkg/netutil"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestIPExtractor(t *testing.T) {
+	trusted, err := netutil.ParseCIDRs([]string{"10.0.0.0/8", "2001:db8:ffff::/48"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		trusted    bool
+		remoteAddr string
+		xff        []string
+		realIP     string
+		expected   string
+	}{
+		{
+			name:       "no proxies configured ignores forged xff",
+			remoteAddr: "203.0.113.5:4000",
+			xff:        []string{"1.2.3.4"},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "no proxies configured ignores forged x-real-ip",
+			remoteAddr: "203.0.113.5:4000",
+			realIP:     "1.2.3.4",
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "direct untrusted client cannot forge xff",
+			trusted:    true,
+			remoteAddr: "203.0.113.5:4000",
+			xff:        []string{"1.2.3.4"},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "trusted proxy forwards client address",
+			trusted:    true,
+			remoteAddr: "10.0.0.2:4000",
+			xff:        []string{"198.51.100.7"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "client prepended values are skipped right to left",
+			trusted:    true,
+			remoteAddr: "10.0.0.2:4000",
+			xff:        []string{"1.2.3.4, 198.51.100.7"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "chain of trusted proxies",
+			trusted:    true,
+			remoteAddr: "10.0.0.2:4000",
+			xff:        []string{"1.2.3.4, 198.51.100.7, 10.1.1.1"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "multiple xff headers are joined",
+			trusted:    true,
+			remoteAddr: "10.0.0.2:4000",
+			xff:        []string{"1.2.3.4", "198.51.100.7"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "x-real-ip is not trusted",
+			trusted:    true,
+			remoteAddr: "10.0.0.2:4000",
+			realIP:     "1.2.3.4",
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "private range is not trusted implicitly",
+			trusted:    true,
+			remoteAddr: "192.168.1.10:4000",
+			xff:        []string{"1.2.3.4"},
+			expected:   "192.168.1.10",
+		},
+		{
+			name:       "loopback is not trusted implicitly",
+			trusted:    true,
+			remoteAddr: "127.0.0.1:4000",
+			xff:        []string{"1.2.3.4"},
+			expected:   "127.0.0.1",
+		},
+		{
+			name:       "garbage in xff falls back to connection address",
+			trusted:    true,
+			remoteAddr: "10.0.0.2:4000",
+			xff:        []string{"not-an-ip"},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "ipv6 trusted proxy",
+			trusted:    true,
+			remoteAddr: "[2001:db8:ffff::1]:4000",
+			xff:        []string{"2001:db8:1::42"},
+			expected:   "2001:db8:1::42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			if tt.trusted {
+				e.IPExtractor = NewIPExtractor(trusted)
+			} else {
+				e.IPExtractor = NewIPExtractor(nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, xff := range tt.xff {
+				req.Header.Add(echo.HeaderXForwardedFor, xff)
+			}
+			if tt.realIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, tt.realIP)
+			}
+
+			c := e.NewContext(req, httptest.NewRecorder())
+			if got := c.RealIP(); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+var ErrClientKeyNotFound = errors.New("client key not found")
+
+type KeyExtractor func(c echo.Context) (string, error)
+
+type JWTKeyConfig struct {
+	Claim        string
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+}
+
+type KeyExtractorOptions struct {
+	JWT            JWTKeyConfig
+	UserContextKey string
+	IPv6PrefixLen  int
+	PrefixKeys     bool
+}
+
+func HeaderKey(name string) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		if v := c.Request().Header.Get(name); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("%w: header %s", ErrClientKeyNotFound, name)
+	}
+}
+
+func QueryParamKey(name string) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		if v := c.QueryParam(name); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("%w: query parameter %s", ErrClientKeyNotFound, name)
+	}
+}
+
+func PathParamKey(name string) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		if v := c.Param(name); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("%w: path parameter %s", ErrClientKeyNotFound, name)
+	}
+}
+
+// ContextKey uses a value stored in the echo context by an earlier
+// authentication middleware, such as the authenticated user.
+func ContextKey(key string) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		switch v := c.Get(key).(type) {
+		case string:
+			if v != "" {
+				return v, nil
+			}
+		case fmt.Stringer:
+			if s := v.String(); s != "" {
+				return s, nil
+			}
+		}
+		return "", fmt.Errorf("%w: context value %s", ErrClientKeyNotFound, key)
+	}
+}
+
+// IPKey identifies clients by address, aggregating IPv6 addresses to their
+// prefix since a single host usually controls a whole /64. The prefix is
+// written as "2001:db8::~64", since a "/" cannot be used in client paths.
+func IPKey(ipv6PrefixLen int) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		ip := net.ParseIP(c.RealIP())
+		if ip == nil {
+			return "", fmt.Errorf("%w: invalid client ip", ErrClientKeyNotFound)
+		}
+
+		if ip.To4() == nil && ipv6PrefixLen > 0 && ipv6PrefixLen < 128 {
+			mask := net.CIDRMask(ipv6PrefixLen, 128)
+			return fmt.Sprintf("%s~%d", ip.Mask(mask), ipv6PrefixLen), nil
+		}
+
+		return ip.String(), nil
+	}
+}
+
+func RouteKey() KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		return c.Request().Method + " " + c.Path(), nil
+	}
+}
+
+func BearerJWTKey(config JWTKeyConfig) KeyExtractor {
+	var methods []string
+	if len(config.HMACSecret) > 0 {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if config.RSAPublicKey != nil {
+		methods = append(methods, "RS256", "RS384", "RS512")
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods(methods))
+
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			return config.HMACSecret, nil
+		case *jwt.SigningMethodRSA:
+			return config.RSAPublicKey, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+
+	return func(c echo.Context) (string, error) {
+		auth := c.Request().Header.Get(echo.HeaderAuthorization)
+		raw, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || raw == "" {
+			return "", fmt.Errorf("%w: bearer token", ErrClientKeyNotFound)
+		}
+
+		claims := jwt.MapClaims{}
+		if _, err := parser.ParseWithClaims(raw, claims, keyFunc); err != nil {
+			return "", fmt.Errorf("%w: invalid bearer token: %v", ErrClientKeyNotFound, err)
+		}
+
+		value, ok := claims[config.Claim]
+		if !ok {
+			return "", fmt.Errorf("%w: claim %s", ErrClientKeyNotFound, config.Claim)
+		}
+
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				return v, nil
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v), nil
+		}
+		return "", fmt.Errorf("%w: claim %s", ErrClientKeyNotFound, config.Claim)
+	}
+}
+
+// Prefixed namespaces the keys of extract with the strategy that produced
+// them, e.g. "ip:203.0.113.7", so an API key equal to someone's address
+// never shares their counter.
+func Prefixed(prefix string, extract KeyExtractor) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		key, err := extract(c)
+		if err != nil {
+			return "", err
+		}
+		return prefix + ":" + key, nil
+	}
+}
+
+// FirstOf returns the key of the first extractor that succeeds.
+func FirstOf(extractors ...KeyExtractor) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		err := ErrClientKeyNotFound
+		for _, extract := range extractors {
+			var key string
+			if key, err = extract(c); err == nil {
+				return key, nil
+			}
+		}
+		return "", err
+	}
+}
+
+// compoundEscaper escapes the separator of compound keys, so a key holding
+// "+" cannot pose as two parts.
+var compoundEscaper = strings.NewReplacer("%", "%25", "+", "%2B")
+
+// Compound joins the keys of every extractor, e.g. user+route, and fails if
+// any of them fails.
+func Compound(extractors ...KeyExtractor) KeyExtractor {
+	return func(c echo.Context) (string, error) {
+		parts := make([]string, len(extractors))
+		for i, extract := range extractors {
+			key, err := extract(c)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = compoundEscaper.Replace(key)
+		}
+		return strings.Join(parts, "+"), nil
+	}
+}
+
+// DefaultKeyExtractor is the "header:X-Client-ID|ip" strategy.
+func DefaultKeyExtractor() KeyExtractor {
+	return FirstOf(HeaderKey("X-Client-ID"), IPKey(64))
+}
+
+// ParseKeyExtractor builds an extractor from a spec such as
+// "jwt:sub+route|apikey:X-API-Key|ip": "|" separates fallbacks and "+"
+// joins the parts of a compound key. With PrefixKeys every part prefixes its
+// key with its kind and source, e.g. "header:X-Client-ID:abc" or
+// "ip:203.0.113.7".
+func ParseKeyExtractor(spec string, options KeyExtractorOptions) (KeyExtractor, error) {
+	var alternatives []KeyExtractor
+
+	for _, alternative := range strings.Split(spec, "|") {
+		var parts []KeyExtractor
+
+		for _, part := range strings.Split(alternative, "+") {
+			prefix, extract, err := parseKeyPart(strings.TrimSpace(part), options)
+			if err != nil {
+				return nil, err
+			}
+			if options.PrefixKeys {
+				extract = Prefixed(prefix, extract)
+			}
+			parts = append(parts, extract)
+		}
+
+		if len(parts) == 1 {
+			alternatives = append(alternatives, parts[0])
+		} else {
+			alternatives = append(alternatives, Compound(parts...))
+		}
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return FirstOf(alternatives...), nil
+}
+
+func parseKeyPart(part string, options KeyExtractorOptions) (string, KeyExtractor, error) {
+	kind, arg, _ := strings.Cut(part, ":")
+
+	switch kind {
+	case "header", "apikey":
+		if arg != "" {
+			return "header:" + arg, HeaderKey(arg), nil
+		}
+	case "query":
+		if arg != "" {
+			return "query:" + arg, QueryParamKey(arg), nil
+		}
+	case "param":
+		if arg != "" {
+			return "param:" + arg, PathParamKey(arg), nil
+		}
+	case "user":
+		key := options.UserContextKey
+		if arg != "" {
+			key = arg
+		}
+		if key == "" {
+			key = "user"
+		}
+		return "user", ContextKey(key), nil
+	case "ip":
+		prefix := options.IPv6PrefixLen
+		if arg != "" {
+			if _, err := fmt.Sscanf(arg, "%d", &prefix); err != nil {
+				return "", nil, fmt.Errorf("invalid ipv6 prefix in key part %q", part)
+			}
+		}
+		return "ip", IPKey(prefix), nil
+	case "route":
+		return "route", RouteKey(), nil
+	case "jwt":
+		config := options.JWT
+		if arg != "" {
+			config.Claim = arg
+		}
+		if config.Claim == "" {
+			config.Claim = "sub"
+		}
+		if len(config.HMACSecret) == 0 && config.RSAPublicKey == nil {
+			return "", nil, fmt.Errorf("key part %q requires a JWT verification key", part)
+		}
+		return "jwt:" + config.Claim, BearerJWTKey(config), nil
+	}
+
+	return "", nil, fmt.Errorf("invalid key part %q", part)
+}
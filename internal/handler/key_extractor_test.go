@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func newKeyContext(req *http.Request, path string) echo.Context {
+	e := echo.New()
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetPath(path)
+	return c
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal("Failed to sign token:", err)
+	}
+	return token
+}
+
+func TestKeyExtractors(t *testing.T) {
+	secret := []byte("hmac-secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("Failed to generate RSA key:", err)
+	}
+
+	options := KeyExtractorOptions{
+		JWT:            JWTKeyConfig{HMACSecret: secret, RSAPublicKey: &rsaKey.PublicKey},
+		UserContextKey: "user",
+		IPv6PrefixLen:  64,
+	}
+
+	hmacToken := signToken(t, jwt.SigningMethodHS256, secret, jwt.MapClaims{"sub": "alice"})
+	rsaToken := signToken(t, jwt.SigningMethodRS256, rsaKey, jwt.MapClaims{"sub": "bob", "org": "acme"})
+	forgedToken := signToken(t, jwt.SigningMethodHS256, []byte("other-secret"), jwt.MapClaims{"sub": "alice"})
+	expiredToken := signToken(t, jwt.SigningMethodHS256, secret, jwt.MapClaims{
+		"sub": "alice",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+
+	tests := []struct {
+		name     string
+		spec     string
+		prefix   bool
+		setup    func(req *http.Request)
+		user     interface{}
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "api key header",
+			spec:     "apikey:X-API-Key",
+			setup:    func(req *http.Request) { req.Header.Set("X-API-Key", "key-1") },
+			expected: "key-1",
+		},
+		{
+			name:    "missing api key",
+			spec:    "apikey:X-API-Key",
+			wantErr: true,
+		},
+		{
+			name:     "query parameter",
+			spec:     "query:api_key",
+			expected: "from-query",
+		},
+		{
+			name:     "hmac jwt subject",
+			spec:     "jwt:sub",
+			setup:    func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+hmacToken) },
+			expected: "alice",
+		},
+		{
+			name:     "rsa jwt custom claim",
+			spec:     "jwt:org",
+			setup:    func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+rsaToken) },
+			expected: "acme",
+		},
+		{
+			name:    "forged jwt",
+			spec:    "jwt:sub",
+			setup:   func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+forgedToken) },
+			wantErr: true,
+		},
+		{
+			name:    "expired jwt",
+			spec:    "jwt:sub",
+			setup:   func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+expiredToken) },
+			wantErr: true,
+		},
+		{
+			name:     "authenticated user",
+			spec:     "user",
+			user:     "carol",
+			expected: "carol",
+		},
+		{
+			name:     "ipv4 address",
+			spec:     "ip",
+			setup:    func(req *http.Request) { req.RemoteAddr = "203.0.113.7:5000" },
+			expected: "203.0.113.7",
+		},
+		{
+			name:     "ipv6 aggregated to /64",
+			spec:     "ip",
+			setup:    func(req *http.Request) { req.RemoteAddr = "[2001:db8:1:2:aaaa:bbbb:cccc:dddd]:5000" },
+			expected: "2001:db8:1:2::~64",
+		},
+		{
+			name:     "compound user and route",
+			spec:     "user+route",
+			user:     "carol",
+			expected: "carol+GET /orders/:id",
+		},
+		{
+			name:     "prefixed header equal to an address",
+			spec:     "header:X-Client-ID|ip",
+			prefix:   true,
+			setup:    func(req *http.Request) { req.Header.Set("X-Client-ID", "203.0.113.7") },
+			expected: "header:X-Client-ID:203.0.113.7",
+		},
+		{
+			name:     "compound escapes separator",
+			spec:     "user+route",
+			prefix:   true,
+			user:     "carol+route:GET /admin",
+			expected: "user:carol%2Broute:GET /admin+route:GET /orders/:id",
+		},
+		{
+			name:     "prefixed fallback to ip",
+			spec:     "header:X-Client-ID|ip",
+			prefix:   true,
+			setup:    func(req *http.Request) { req.RemoteAddr = "203.0.113.7:5000" },
+			expected: "ip:203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options.PrefixKeys = tt.prefix
+			extract, err := ParseKeyExtractor(tt.spec, options)
+			if err != nil {
+				t.Fatalf("ParseKeyExtractor failed: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/42?api_key=from-query", nil)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+
+			c := newKeyContext(req, "/orders/:id")
+			if tt.user != nil {
+				c.Set("user", tt.user)
+			}
+
+			key, err := extract(c)
+			if tt.wantErr {
+				if !errors.Is(err, ErrClientKeyNotFound) {
+					t.Errorf("Expected ErrClientKeyNotFound, got key %q err %v", key, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if key != tt.expected {
+				t.Errorf("Expected key %q, got %q", tt.expected, key)
+			}
+		})
+	}
+}
+
+func TestDefaultKeyExtractorIsUnprefixed(t *testing.T) {
+	extract := DefaultKeyExtractor()
+
+	req := httptest.NewRequest(http.MethodGet, "/data", nil)
+	req.Header.Set("X-Client-ID", "0101")
+	if key, _ := extract(newKeyContext(req, "/data")); key != "0101" {
+		t.Errorf("Expected raw header key, got %q", key)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/data", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	if key, _ := extract(newKeyContext(req, "/data")); key != "203.0.113.7" {
+		t.Errorf("Expected raw address key, got %q", key)
+	}
+}
+
+func TestParseKeyExtractorErrors(t *testing.T) {
+	for _, spec := range []string{"", "header", "unknown:x", "jwt:sub", "ip:abc"} {
+		if _, err := ParseKeyExtractor(spec, KeyExtractorOptions{}); err == nil {
+			t.Errorf("Expected error for spec %q", spec)
+		}
+	}
+}
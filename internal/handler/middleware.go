@@ -1,36 +1,327 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/tracing"
 	"rate-limiter-go/internal/usecase"
 	"rate-limiter-go/pkg/response"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+type HeaderMode string
+
+const (
+	HeadersLegacy HeaderMode = "legacy"
+	HeadersIETF   HeaderMode = "ietf"
+	HeadersBoth   HeaderMode = "both"
+	HeadersNone   HeaderMode = "none"
+)
+
+type LimiterMode string
+
+const (
+	LimitRate        LimiterMode = "rate"
+	LimitConcurrency LimiterMode = "concurrency"
+	LimitBoth        LimiterMode = "both"
+)
+
+// ConcurrencyConfig caps the number of in-flight requests per client. Scope
+// separates the slots of middleware instances sharing a backend, such as
+// several services on one Redis.
+type ConcurrencyConfig struct {
+	Limit    int
+	LeaseTTL time.Duration
+	Scope    string
+}
+
+type RateLimiterConfig struct {
+	Headers      HeaderMode
+	KeyExtractor KeyExtractor
+	RuleMatch    domain.RuleMatchMode
+	Mode         LimiterMode
+	Concurrency  ConcurrencyConfig
+}
+
+var DefaultRateLimiterConfig = RateLimiterConfig{
+	Headers:      HeadersLegacy,
+	KeyExtractor: DefaultKeyExtractor(),
+	RuleMatch:    domain.RuleMatchMostSpecific,
+	Mode:         LimitRate,
+	Concurrency: ConcurrencyConfig{
+		LeaseTTL: 30 * time.Second,
+	},
+}
+
+func IsValidLimiterMode(mode LimiterMode) bool {
+	switch mode {
+	case LimitRate, LimitConcurrency, LimitBoth:
+		return true
+	}
+	return false
+}
+
 func RateLimiterMiddleware(useCase usecase.RateLimiterUseCase) echo.MiddlewareFunc {
+	return RateLimiterMiddlewareWithConfig(useCase, DefaultRateLimiterConfig)
+}
+
+func RateLimiterMiddlewareWithConfig(useCase usecase.RateLimiterUseCase, config RateLimiterConfig) echo.MiddlewareFunc {
+	switch config.Headers {
+	case HeadersLegacy, HeadersIETF, HeadersBoth, HeadersNone:
+	default:
+		config.Headers = DefaultRateLimiterConfig.Headers
+	}
+	if config.KeyExtractor == nil {
+		config.KeyExtractor = DefaultRateLimiterConfig.KeyExtractor
+	}
+	if config.RuleMatch != domain.RuleMatchAll {
+		config.RuleMatch = domain.RuleMatchMostSpecific
+	}
+	if config.Mode != LimitConcurrency && config.Mode != LimitBoth || config.Concurrency.Limit <= 0 {
+		config.Mode = LimitRate
+	}
+	if config.Concurrency.LeaseTTL <= 0 {
+		config.Concurrency.LeaseTTL = DefaultRateLimiterConfig.Concurrency.LeaseTTL
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 
-			ctx := c.Request().Context()
+			ctx := otel.GetTextMapPropagator().Extract(c.Request().Context(), propagation.HeaderCarrier(c.Request().Header))
+			ctx, span := tracing.Tracer().Start(ctx, "RateLimiterMiddleware", trace.WithAttributes(
+				attribute.String("http.request.method", c.Request().Method),
+				attribute.String("url.path", c.Request().URL.Path),
+			))
+			defer span.End()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			clientID, err := config.KeyExtractor(c)
+			if err != nil {
+				return response.Error(c, http.StatusUnauthorized, "Unable to identify client")
+			}
+			if !domain.IsValidClientID(clientID) {
+				return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+			}
+
+			span.SetAttributes(attribute.String("ratelimit.client_id", clientID))
 
-			clientID := c.Request().Header.Get("X-Client-ID")
-			if clientID == "" {
-				clientID = c.RealIP()
+			verdict, _ := useCase.CheckAccess(ctx, clientID, net.ParseIP(c.RealIP()))
+			span.SetAttributes(attribute.String("ratelimit.access", accessLabel(verdict)))
+			switch verdict {
+			case domain.AccessDenied:
+				return response.Error(c, http.StatusForbidden, "Access denied")
+			case domain.AccessAllowed:
+				return next(c)
 			}
 
-			allowed, remaining, resetTime := useCase.CheckRateLimit(ctx, clientID)
+			var rules []*domain.RouteRule
+
+			if config.Mode != LimitConcurrency {
+				var decision domain.Decision
+				decision, rules = checkRequest(c, useCase, config, clientID)
+				span.SetAttributes(tracing.DecisionAttributes(decision)...)
+
+				if decision.Shed {
+					c.Response().Header().Set("Retry-After", strconv.FormatInt(decision.RetryAfterSeconds(), 10))
+					return response.Error(c, http.StatusServiceUnavailable, "Service overloaded")
+				}
+
+				if decision.Banned {
+					c.Response().Header().Set("Retry-After", strconv.FormatInt(decision.RetryAfterSeconds(), 10))
+					return response.Error(c, http.StatusTooManyRequests, "Client temporarily banned")
+				}
+
+				setRateLimitHeaders(c.Response().Header(), config.Headers, decision)
+
+				if !decision.Allowed {
+					c.Response().Header().Set("Retry-After", strconv.FormatInt(decision.RetryAfterSeconds(), 10))
+					return response.Error(c, http.StatusTooManyRequests, "Rate limit exceeded")
+				}
+			}
 
-			c.Response().Header().Set("X-RateLimit-Limit", "100")
-			c.Response().Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
-			c.Response().Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetTime, 10))
+			handler := next
+			if len(rules) > 0 {
+				handler = observeRoute(next, useCase, rules)
+			}
 
-			if !allowed {
-				return response.Error(c, http.StatusTooManyRequests, "Rate limit exceeded")
+			if config.Mode == LimitRate {
+				return handler(c)
 			}
 
-			return next(c)
+			return withConcurrencySlot(c, handler, useCase, config, clientID, rules)
+		}
+	}
+}
+
+// checkRequest applies the global rules matching the request first, then the
+// per-client route rules, falling back to the client's own limit when no
+// per-client rule matches. The global budget is refunded when the client
+// itself is refused.
+func checkRequest(c echo.Context, useCase usecase.RateLimiterUseCase, config RateLimiterConfig, clientID string) (domain.Decision, []*domain.RouteRule) {
+	ctx := c.Request().Context()
+
+	rules, err := useCase.MatchRouteRules(ctx, c.Request().Method, c.Request().URL.Path, config.RuleMatch)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to match route rules", "error", err)
+	}
+
+	global, client := domain.SplitGlobalRules(rules)
+
+	if len(global) > 0 {
+		if decision := useCase.CheckGlobalRateLimit(ctx, clientID, global); !decision.Allowed {
+			return decision, rules
+		}
+	}
+
+	var decision domain.Decision
+	if len(client) > 0 {
+		decision = useCase.CheckRouteRateLimit(ctx, clientID, client)
+	} else {
+		decision = useCase.CheckRateLimit(ctx, clientID)
+	}
+
+	if len(global) > 0 && !decision.Allowed {
+		useCase.RefundGlobalRateLimit(ctx, clientID, global)
+	}
+
+	return decision, rules
+}
+
+// refundRequest gives back what checkRequest charged for an admitted request.
+func refundRequest(ctx context.Context, useCase usecase.RateLimiterUseCase, clientID string, rules []*domain.RouteRule) {
+	global, client := domain.SplitGlobalRules(rules)
+
+	if len(global) > 0 {
+		useCase.RefundGlobalRateLimit(ctx, clientID, global)
+	}
+	useCase.RefundRateLimit(ctx, clientID, client)
+}
+
+// observeRoute reports the latency and outcome of next to the adaptive limits
+// of the matched rules.
+func observeRoute(next echo.HandlerFunc, useCase usecase.RateLimiterUseCase, rules []*domain.RouteRule) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+		useCase.ObserveRoute(rules, time.Since(start), responseFailed(c, err))
+		return err
+	}
+}
+
+func responseFailed(c echo.Context, err error) bool {
+	if err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return httpErr.Code >= http.StatusInternalServerError
+		}
+		return true
+	}
+	return c.Response().Status >= http.StatusInternalServerError
+}
+
+// withConcurrencySlot runs next while holding one of the client's in-flight
+// slots. The lease is renewed while the request runs and released afterwards,
+// even if the client has gone away. In LimitBoth mode a refused request gets
+// back the rate limit it was charged.
+func withConcurrencySlot(c echo.Context, next echo.HandlerFunc, useCase usecase.RateLimiterUseCase, config RateLimiterConfig, clientID string, rules []*domain.RouteRule) error {
+	ctx := context.WithoutCancel(c.Request().Context())
+
+	lease, acquired := useCase.AcquireConcurrency(ctx, clientID, config.Concurrency.Scope, config.Concurrency.Limit, config.Concurrency.LeaseTTL)
+
+	if config.Headers != HeadersNone {
+		c.Response().Header().Set("X-Concurrency-Limit", strconv.Itoa(lease.Limit))
+		c.Response().Header().Set("X-Concurrency-Remaining", strconv.Itoa(lease.Remaining()))
+	}
+
+	if !acquired {
+		if config.Mode == LimitBoth {
+			refundRequest(ctx, useCase, clientID, rules)
+		}
+		c.Response().Header().Set("Retry-After", "1")
+		return response.Error(c, http.StatusTooManyRequests, "Too many concurrent requests")
+	}
+
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		useCase.ReleaseConcurrency(ctx, lease)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(config.Concurrency.LeaseTTL / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				useCase.RenewConcurrency(ctx, lease)
+			}
+		}
+	}()
+
+	return next(c)
+}
+
+func accessLabel(verdict domain.AccessVerdict) string {
+	switch verdict {
+	case domain.AccessAllowed:
+		return "allowed"
+	case domain.AccessDenied:
+		return "denied"
+	}
+	return "none"
+}
+
+// setRateLimitHeaders describes the limit behind decision. Decisions made
+// without resolving a limit, such as when a cluster peer or the backend is
+// unreachable, have none to describe and get no headers.
+func setRateLimitHeaders(header http.Header, mode HeaderMode, decision domain.Decision) {
+	if decision.Limit <= 0 {
+		return
+	}
+
+	if mode == HeadersLegacy || mode == HeadersBoth {
+		header.Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(decision.Reset.Unix(), 10))
+	}
+
+	if mode == HeadersIETF || mode == HeadersBoth {
+		policy := sfString(decision.Policy)
+		header.Set("RateLimit-Policy", fmt.Sprintf("%s;q=%d;w=%d", policy, decision.Limit, int64(decision.Window/time.Second)))
+		header.Set("RateLimit", fmt.Sprintf("%s;r=%d;t=%d", policy, decision.Remaining, decision.ResetAfter(time.Now())))
+	}
+}
+
+// sfString encodes s as a structured field string (RFC 8941), dropping
+// characters outside printable ASCII.
+func sfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			continue
+		}
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
 		}
+		b.WriteRune(r)
 	}
+	b.WriteByte('"')
+	return b.String()
 }
@@ -1,13 +1,127 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"rate-limiter-go/internal/domain"
 	"rate-limiter-go/internal/usecase"
 	"rate-limiter-go/pkg/response"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
+const maxBatchSize = 100
+
+type configureRequest struct {
+	MaxRequests   int    `json:"max_requests"`
+	CycleDuration int    `json:"cycle_duration"`
+	ParentID      string `json:"parent_id"`
+	Tier          string `json:"tier"`
+	Window        string `json:"window"`
+	TimeZone      string `json:"timezone"`
+}
+
+// patchRequest holds the fields of a partial update; nil fields are kept.
+type patchRequest struct {
+	MaxRequests   *int    `json:"max_requests"`
+	CycleDuration *int    `json:"cycle_duration"`
+	ParentID      *string `json:"parent_id"`
+	Tier          *string `json:"tier"`
+	Window        *string `json:"window"`
+	TimeZone      *string `json:"timezone"`
+}
+
+func configureRequestOf(config domain.RateLimitConfig) configureRequest {
+	return configureRequest{
+		MaxRequests:   config.MaxRequests,
+		CycleDuration: config.CycleDuration,
+		ParentID:      config.ParentID,
+		Tier:          config.Tier,
+		Window:        config.Window,
+		TimeZone:      config.TimeZone,
+	}
+}
+
+// apply merges the patch into req. Setting max_requests to 0 drops the
+// client's own limits, so the fields that go with them are cleared too.
+func (p patchRequest) apply(req configureRequest) configureRequest {
+	if p.MaxRequests != nil {
+		req.MaxRequests = *p.MaxRequests
+		if req.MaxRequests == 0 {
+			req.CycleDuration, req.Window, req.TimeZone = 0, domain.WindowRolling, ""
+		}
+	}
+	if p.CycleDuration != nil {
+		req.CycleDuration = *p.CycleDuration
+	}
+	if p.ParentID != nil {
+		req.ParentID = *p.ParentID
+	}
+	if p.Tier != nil {
+		req.Tier = *p.Tier
+	}
+	if p.Window != nil {
+		req.Window = *p.Window
+	}
+	if p.TimeZone != nil {
+		req.TimeZone = *p.TimeZone
+	}
+	return req
+}
+
+func (r configureRequest) validate(clientID string) []response.FieldError {
+	var errs []response.FieldError
+	invalid := func(field, message string) {
+		errs = append(errs, response.FieldError{Field: field, Message: message})
+	}
+
+	if r.ParentID != "" && r.ParentID == clientID {
+		invalid("parent_id", "Must not be the client itself")
+	} else if r.ParentID != "" && !domain.IsValidClientID(r.ParentID) {
+		invalid("parent_id", "Must not contain \"|\"")
+	}
+
+	switch {
+	case r.MaxRequests < 0:
+		invalid("max_requests", "Must not be negative")
+	case r.CycleDuration < 0:
+		invalid("cycle_duration", "Must not be negative")
+	case r.MaxRequests == 0 && r.CycleDuration != 0:
+		invalid("cycle_duration", "Requires max_requests")
+	case r.MaxRequests > 0 && r.CycleDuration == 0 && !domain.IsCalendarWindow(r.Window):
+		invalid("cycle_duration", "Required for a rolling window")
+	}
+
+	if !domain.IsValidWindow(r.Window) {
+		invalid("window", "Must be one of second, minute, hour, day or month")
+	} else if r.MaxRequests == 0 && r.Window != domain.WindowRolling {
+		invalid("window", "Requires max_requests")
+	}
+
+	if _, err := domain.LoadTimeZone(r.TimeZone); err != nil {
+		invalid("timezone", "Unknown time zone")
+	} else if r.MaxRequests == 0 && r.TimeZone != "" {
+		invalid("timezone", "Requires max_requests")
+	}
+
+	return errs
+}
+
+func (r configureRequest) toConfig() domain.RateLimitConfig {
+	return domain.RateLimitConfig{
+		MaxRequests:   r.MaxRequests,
+		CycleDuration: r.CycleDuration,
+		ParentID:      r.ParentID,
+		Tier:          r.Tier,
+		Window:        r.Window,
+		TimeZone:      r.TimeZone,
+	}
+}
+
 type RateLimiterHandler struct {
 	useCase usecase.RateLimiterUseCase
 }
@@ -23,17 +137,17 @@ func (h *RateLimiterHandler) CheckRateLimit(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	clientID := c.Param("clientID")
-	if clientID == "" {
-		return response.Error(c, http.StatusBadRequest, "Client ID required")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
 	}
 
-	allowed, remaining, resetTime := h.useCase.CheckRateLimit(ctx, clientID)
+	if verdict, _ := h.useCase.CheckAccess(ctx, clientID, nil); verdict == domain.AccessDenied {
+		return response.Error(c, http.StatusForbidden, "Access denied")
+	}
 
-	return response.Success(c, map[string]interface{}{
-		"allowed":   allowed,
-		"remaining": remaining,
-		"reset":     resetTime,
-	})
+	decision := h.useCase.CheckRateLimit(ctx, clientID)
+
+	return response.Success(c, decisionResponse(decision))
 }
 
 func (h *RateLimiterHandler) ConfigureRateLimit(c echo.Context) error {
@@ -41,29 +155,293 @@ func (h *RateLimiterHandler) ConfigureRateLimit(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	clientID := c.Param("clientID")
-	if clientID == "" {
-		return response.Error(c, http.StatusBadRequest, "Client ID required")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
 	}
 
-	var req struct {
-		MaxRequests   int `json:"max_requests"`
-		CycleDuration int `json:"cycle_duration"`
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return response.Error(c, http.StatusPreconditionFailed, "Config version mismatch")
 	}
 
+	var req configureRequest
+
 	if err := c.Bind(&req); err != nil {
 		return response.Error(c, http.StatusBadRequest, "Invalid request body")
 	}
 
-	if req.MaxRequests <= 0 || req.CycleDuration <= 0 {
-		return response.Error(c, http.StatusBadRequest, "Invalid configuration values")
+	if req == (configureRequest{}) {
+		return response.ValidationError(c, "Invalid configuration values", []response.FieldError{
+			{Field: "max_requests", Message: "One of max_requests, parent_id or tier is required"},
+		})
+	}
+	if errs := req.validate(clientID); len(errs) > 0 {
+		return response.ValidationError(c, "Invalid configuration values", errs)
 	}
 
-	err := h.useCase.ConfigureRateLimit(ctx, clientID, req.MaxRequests, req.CycleDuration)
+	version, err := h.useCase.ConfigureRateLimitIfMatch(ctx, clientID, req.toConfig(), version)
 	if err != nil {
-		return response.Error(c, http.StatusInternalServerError, "Failed to configure rate limit")
+		return configureError(c, err)
 	}
 
+	c.Response().Header().Set("ETag", etag(version))
 	return response.Success(c, map[string]string{
 		"message": "Save setting successfully",
 	})
 }
+
+// PatchRateLimit changes only the supplied fields. Without If-Match the
+// update is still guarded by the version it was merged onto.
+func (h *RateLimiterHandler) PatchRateLimit(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return response.Error(c, http.StatusPreconditionFailed, "Config version mismatch")
+	}
+
+	var patch patchRequest
+
+	if err := c.Bind(&patch); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	var current configureRequest
+	rateLimit, _, err := h.useCase.GetClient(ctx, clientID)
+	switch {
+	case errors.Is(err, domain.ErrClientNotFound):
+		rateLimit = &domain.RateLimit{}
+	case err != nil:
+		return response.Error(c, http.StatusInternalServerError, "Failed to get client")
+	default:
+		current = configureRequestOf(rateLimit.Config())
+	}
+
+	if version == domain.AnyVersion {
+		version = rateLimit.Version
+	}
+
+	req := patch.apply(current)
+	if errs := req.validate(clientID); len(errs) > 0 {
+		return response.ValidationError(c, "Invalid configuration values", errs)
+	}
+
+	version, err = h.useCase.ConfigureRateLimitIfMatch(ctx, clientID, req.toConfig(), version)
+	if err != nil {
+		return configureError(c, err)
+	}
+
+	c.Response().Header().Set("ETag", etag(version))
+	return response.Success(c, map[string]string{
+		"message": "Save setting successfully",
+	})
+}
+
+func (h *RateLimiterHandler) CheckRateLimitBatch(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var req struct {
+		Requests []struct {
+			ClientID string `json:"client_id"`
+			Cost     *int   `json:"cost"`
+		} `json:"requests"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	if len(req.Requests) == 0 || len(req.Requests) > maxBatchSize {
+		return response.Error(c, http.StatusBadRequest, fmt.Sprintf("Batch must contain between 1 and %d requests", maxBatchSize))
+	}
+
+	requests := make([]domain.ConsumeRequest, len(req.Requests))
+	for i, item := range req.Requests {
+		cost := 1
+		if item.Cost != nil {
+			cost = *item.Cost
+		}
+
+		if !domain.IsValidClientID(item.ClientID) || cost <= 0 {
+			return response.Error(c, http.StatusBadRequest, "Invalid batch request values")
+		}
+
+		requests[i] = domain.ConsumeRequest{ClientID: item.ClientID, Cost: cost}
+	}
+
+	for _, req := range requests {
+		if verdict, _ := h.useCase.CheckAccess(ctx, req.ClientID, nil); verdict == domain.AccessDenied {
+			return response.Error(c, http.StatusForbidden, "Access denied")
+		}
+	}
+
+	results, allowed, err := h.useCase.CheckRateLimitBatch(ctx, requests)
+	if errors.Is(err, domain.ErrNotSupportedInCluster) {
+		return response.Error(c, http.StatusBadRequest, "Batch clients must be owned by the same cluster node")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to check rate limit")
+	}
+
+	now := time.Now()
+
+	items := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		items[i] = decisionResponse(result.Decision(now))
+		items[i]["client_id"] = result.ClientID
+	}
+
+	return response.Success(c, map[string]interface{}{
+		"allowed": allowed,
+		"results": items,
+	})
+}
+
+func (h *RateLimiterHandler) GetUsage(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	usage, err := h.useCase.GetUsage(ctx, clientID)
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to get usage")
+	}
+
+	penalty, err := h.useCase.GetPenalty(ctx, clientID)
+	if err != nil && !errors.Is(err, domain.ErrPenaltyNotFound) {
+		return response.Error(c, http.StatusInternalServerError, "Failed to get penalty")
+	}
+
+	resp := usageResponse(usage)
+	resp["penalty"] = penaltyResponse(penalty, time.Now())
+
+	return response.Success(c, resp)
+}
+
+func (h *RateLimiterHandler) Unban(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	err := h.useCase.Unban(ctx, clientID)
+	if errors.Is(err, domain.ErrPenaltyNotFound) {
+		return response.Error(c, http.StatusNotFound, "Penalty not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to unban client")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Unban client successfully",
+	})
+}
+
+func configureError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return response.Error(c, http.StatusPreconditionFailed, "Config version mismatch")
+	case errors.Is(err, domain.ErrInvalidHierarchy):
+		return response.ValidationError(c, "Invalid configuration values", []response.FieldError{
+			{Field: "parent_id", Message: "Invalid parent hierarchy"},
+		})
+	case errors.Is(err, domain.ErrTierNotFound):
+		return response.ValidationError(c, "Invalid configuration values", []response.FieldError{
+			{Field: "tier", Message: "Tier not found"},
+		})
+	}
+	return response.Error(c, http.StatusInternalServerError, "Failed to configure rate limit")
+}
+
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatchVersion reads the config version a request is conditional on. A
+// missing header or "*" matches any version; weak or malformed tags match
+// none.
+func ifMatchVersion(c echo.Context) (int, bool) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return domain.AnyVersion, true
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version < 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+func decisionResponse(decision domain.Decision) map[string]interface{} {
+	return map[string]interface{}{
+		"allowed":     decision.Allowed,
+		"banned":      decision.Banned,
+		"limit":       decision.Limit,
+		"remaining":   decision.Remaining,
+		"reset":       decision.Reset.Unix(),
+		"retry_after": decision.RetryAfterSeconds(),
+		"policy":      decision.Policy,
+	}
+}
+
+func penaltyResponse(penalty *domain.Penalty, now time.Time) interface{} {
+	if penalty == nil {
+		return nil
+	}
+
+	resp := map[string]interface{}{
+		"banned":       penalty.Banned(now),
+		"banned_until": nil,
+		"offenses":     penalty.Offenses,
+		"violations":   penalty.Violations,
+	}
+	if !penalty.BannedUntil.IsZero() {
+		resp["banned_until"] = penalty.BannedUntil.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func usageResponse(usage *domain.Usage) map[string]interface{} {
+	period := func(p domain.Period) interface{} {
+		if p.Start.IsZero() {
+			return nil
+		}
+		return map[string]interface{}{
+			"start": p.Start.Format(time.RFC3339),
+			"end":   p.End.Format(time.RFC3339),
+			"count": p.Count,
+		}
+	}
+
+	window := usage.Window
+	if window == domain.WindowRolling {
+		window = "rolling"
+	}
+
+	return map[string]interface{}{
+		"client_id":    usage.ClientID,
+		"max_requests": usage.MaxRequests,
+		"window":       window,
+		"timezone":     usage.TimeZone,
+		"current":      period(usage.Current),
+		"previous":     period(usage.Previous),
+	}
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var ruleIDPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,62}$`)
+
+type RuleHandler struct {
+	useCase usecase.RateLimiterUseCase
+}
+
+func NewRuleHandler(useCase usecase.RateLimiterUseCase) *RuleHandler {
+	return &RuleHandler{
+		useCase: useCase,
+	}
+}
+
+type RuleRequest struct {
+	ID            string `json:"id"`
+	Method        string `json:"method"`
+	Path          string `json:"path"`
+	MaxRequests   int    `json:"max_requests"`
+	CycleDuration int    `json:"cycle_duration"`
+	Global        bool   `json:"global"`
+	PriorityShare int    `json:"priority_share"`
+}
+
+func (r RuleRequest) Valid() bool {
+	return len(r.Validate()) == 0
+}
+
+func (r RuleRequest) Validate() []response.FieldError {
+	var errs []response.FieldError
+	invalid := func(field, message string) {
+		errs = append(errs, response.FieldError{Field: field, Message: message})
+	}
+
+	if !ruleIDPattern.MatchString(r.ID) {
+		invalid("id", "Must be 1-63 letters, digits, '_', '.' or '-'")
+	}
+	if !domain.IsValidRulePattern(r.Path) {
+		invalid("path", "Invalid path pattern")
+	}
+	if r.MaxRequests <= 0 {
+		invalid("max_requests", "Must be positive")
+	}
+	if r.CycleDuration <= 0 {
+		invalid("cycle_duration", "Must be positive")
+	}
+	if r.PriorityShare < 0 || r.PriorityShare > 100 {
+		invalid("priority_share", "Must be between 0 and 100")
+	} else if !r.Global && r.PriorityShare != 0 {
+		invalid("priority_share", "Only allowed on global rules")
+	}
+
+	return errs
+}
+
+func (r RuleRequest) ToRule() *domain.RouteRule {
+	return &domain.RouteRule{
+		ID:            r.ID,
+		Method:        strings.ToUpper(r.Method),
+		Pattern:       r.Path,
+		MaxRequests:   r.MaxRequests,
+		CycleDuration: r.CycleDuration,
+		Global:        r.Global,
+		PriorityShare: r.PriorityShare,
+	}
+}
+
+func ruleResponse(rule *domain.RouteRule) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             rule.ID,
+		"method":         rule.Method,
+		"path":           rule.Pattern,
+		"max_requests":   rule.MaxRequests,
+		"cycle_duration": rule.CycleDuration,
+		"global":         rule.Global,
+		"priority_share": rule.PriorityShare,
+	}
+}
+
+func (h *RuleHandler) ListRules(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	rules, err := h.useCase.ListRules(ctx)
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to list rules")
+	}
+
+	items := make([]map[string]interface{}, len(rules))
+	for i, rule := range rules {
+		items[i] = ruleResponse(rule)
+	}
+
+	return response.Success(c, items)
+}
+
+func (h *RuleHandler) GetRule(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	rule, err := h.useCase.GetRule(ctx, c.Param("id"))
+	if errors.Is(err, domain.ErrRuleNotFound) {
+		return response.Error(c, http.StatusNotFound, "Rule not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to get rule")
+	}
+
+	return response.Success(c, ruleResponse(rule))
+}
+
+func (h *RuleHandler) SaveRule(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	var req RuleRequest
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	req.ID = c.Param("id")
+	if errs := req.Validate(); len(errs) > 0 {
+		return response.ValidationError(c, "Invalid rule values", errs)
+	}
+
+	rule := req.ToRule()
+	err := h.useCase.SaveRule(ctx, rule)
+	if errors.Is(err, domain.ErrNotSupportedInCluster) {
+		return response.ValidationError(c, "Invalid rule values", []response.FieldError{
+			{Field: "global", Message: "Global rules are not supported in cluster mode"},
+		})
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to save rule")
+	}
+
+	return response.Success(c, ruleResponse(rule))
+}
+
+func (h *RuleHandler) DeleteRule(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	err := h.useCase.DeleteRule(ctx, c.Param("id"))
+	if errors.Is(err, domain.ErrRuleNotFound) {
+		return response.Error(c, http.StatusNotFound, "Rule not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to delete rule")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Delete rule successfully",
+	})
+}
+
+func (h *RuleHandler) ListAdaptiveLimits(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	statuses, err := h.useCase.ListAdaptiveLimits(ctx)
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to list adaptive limits")
+	}
+
+	items := make([]map[string]interface{}, len(statuses))
+	for i, status := range statuses {
+		items[i] = map[string]interface{}{
+			"rule_id":    status.RuleID,
+			"ceiling":    status.Ceiling,
+			"limit":      status.Limit,
+			"factor":     status.Factor,
+			"latency_ms": status.Latency.Milliseconds(),
+			"error_rate": status.ErrorRate,
+		}
+	}
+
+	return response.Success(c, items)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
+	"regexp"
+
+	"github.com/labstack/echo/v4"
+)
+
+var tierNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type TierHandler struct {
+	useCase usecase.RateLimiterUseCase
+}
+
+func NewTierHandler(useCase usecase.RateLimiterUseCase) *TierHandler {
+	return &TierHandler{
+		useCase: useCase,
+	}
+}
+
+func tierResponse(tier *domain.Tier) map[string]interface{} {
+	return map[string]interface{}{
+		"name":           tier.Name,
+		"max_requests":   tier.MaxRequests,
+		"cycle_duration": tier.CycleDuration,
+		"window":         tier.Window,
+		"timezone":       tier.TimeZone,
+		"priority":       tier.Priority,
+	}
+}
+
+func (h *TierHandler) ListTiers(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	tiers, err := h.useCase.ListTiers(ctx)
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to list tiers")
+	}
+
+	items := make([]map[string]interface{}, len(tiers))
+	for i, tier := range tiers {
+		items[i] = tierResponse(tier)
+	}
+
+	return response.Success(c, items)
+}
+
+func (h *TierHandler) GetTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	tier, err := h.useCase.GetTier(ctx, c.Param("name"))
+	if errors.Is(err, domain.ErrTierNotFound) {
+		return response.Error(c, http.StatusNotFound, "Tier not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to get tier")
+	}
+
+	return response.Success(c, tierResponse(tier))
+}
+
+func (h *TierHandler) SaveTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	name := c.Param("name")
+	if !tierNamePattern.MatchString(name) {
+		return response.Error(c, http.StatusBadRequest, "Invalid tier name")
+	}
+
+	var req struct {
+		MaxRequests   int    `json:"max_requests"`
+		CycleDuration int    `json:"cycle_duration"`
+		Window        string `json:"window"`
+		TimeZone      string `json:"timezone"`
+		Priority      int    `json:"priority"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	var errs []response.FieldError
+	invalid := func(field, message string) {
+		errs = append(errs, response.FieldError{Field: field, Message: message})
+	}
+
+	if req.MaxRequests <= 0 {
+		invalid("max_requests", "Must be positive")
+	}
+	if req.CycleDuration < 0 {
+		invalid("cycle_duration", "Must not be negative")
+	} else if req.CycleDuration == 0 && !domain.IsCalendarWindow(req.Window) {
+		invalid("cycle_duration", "Required for a rolling window")
+	}
+	if !domain.IsValidWindow(req.Window) {
+		invalid("window", "Must be one of second, minute, hour, day or month")
+	}
+	if _, err := domain.LoadTimeZone(req.TimeZone); err != nil {
+		invalid("timezone", "Unknown time zone")
+	}
+	if req.Priority < 0 {
+		invalid("priority", "Must not be negative")
+	}
+	if len(errs) > 0 {
+		return response.ValidationError(c, "Invalid configuration values", errs)
+	}
+
+	tier := &domain.Tier{
+		Name:          name,
+		MaxRequests:   req.MaxRequests,
+		CycleDuration: req.CycleDuration,
+		Window:        req.Window,
+		TimeZone:      req.TimeZone,
+		Priority:      req.Priority,
+	}
+
+	if err := h.useCase.SaveTier(ctx, tier); err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to save tier")
+	}
+
+	return response.Success(c, tierResponse(tier))
+}
+
+func (h *TierHandler) DeleteTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	err := h.useCase.DeleteTier(ctx, c.Param("name"))
+	if errors.Is(err, domain.ErrTierNotFound) {
+		return response.Error(c, http.StatusNotFound, "Tier not found")
+	}
+	if errors.Is(err, domain.ErrTierInUse) {
+		return response.Error(c, http.StatusConflict, "Tier is assigned to clients")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to delete tier")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Delete tier successfully",
+	})
+}
+
+func (h *TierHandler) AssignTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	var req struct {
+		Tier string `json:"tier"`
+	}
+
+	if err := c.Bind(&req); err != nil || req.Tier == "" {
+		return response.Error(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	err := h.useCase.AssignTier(ctx, clientID, req.Tier)
+	if errors.Is(err, domain.ErrTierNotFound) {
+		return response.Error(c, http.StatusNotFound, "Tier not found")
+	}
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to assign tier")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Assign tier successfully",
+	})
+}
+
+func (h *TierHandler) UnassignTier(c echo.Context) error {
+
+	ctx := c.Request().Context()
+
+	clientID := c.Param("clientID")
+	if !domain.IsValidClientID(clientID) {
+		return response.Error(c, http.StatusBadRequest, "Invalid client ID")
+	}
+
+	if err := h.useCase.AssignTier(ctx, clientID, ""); err != nil {
+		return response.Error(c, http.StatusInternalServerError, "Failed to unassign tier")
+	}
+
+	return response.Success(c, map[string]string{
+		"message": "Unassign tier successfully",
+	})
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"rate-limiter-go/internal/repository"
+	"runtime/debug"
+	"time"
+)
+
+// Version is set at build time with -ldflags "-X rate-limiter-go/internal/health.Version=...".
+var Version = "dev"
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+type Status struct {
+	Status        string       `json:"status"`
+	Backend       string       `json:"backend"`
+	LatencyMs     float64      `json:"latency_ms"`
+	Circuit       CircuitState `json:"circuit"`
+	ConfigVersion string       `json:"config_version,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	Build         BuildInfo    `json:"build"`
+}
+
+// Checker decides whether the instance can serve traffic: the backend must
+// answer a ping within timeout and its circuit must not be open.
+type Checker struct {
+	backend       string
+	pinger        repository.Pinger
+	breaker       *Breaker
+	timeout       time.Duration
+	configVersion func() string
+}
+
+func NewChecker(backend string, pinger repository.Pinger, breaker *Breaker, timeout time.Duration, configVersion func() string) *Checker {
+	if configVersion == nil {
+		configVersion = func() string { return "" }
+	}
+
+	return &Checker{
+		backend:       backend,
+		pinger:        pinger,
+		breaker:       breaker,
+		timeout:       timeout,
+		configVersion: configVersion,
+	}
+}
+
+func (c *Checker) Ready(ctx context.Context) (Status, bool) {
+	status := Status{
+		Backend:       c.backend,
+		ConfigVersion: c.configVersion(),
+		Build:         Build(),
+	}
+
+	// While the circuit is open the backend is not probed at all.
+	if c.breaker.State() == CircuitOpen {
+		status.Status = "not_ready"
+		status.Circuit = CircuitOpen
+		status.Error = "circuit open"
+		return status, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.pinger.Ping(ctx)
+	status.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+	c.breaker.Record(err)
+	status.Circuit = c.breaker.State()
+
+	if err != nil {
+		status.Status = "not_ready"
+		status.Error = err.Error()
+		return status, false
+	}
+
+	status.Status = "ready"
+	return status, true
+}
+
+func Build() BuildInfo {
+	build := BuildInfo{Version: Version}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+
+	build.GoVersion = info.GoVersion
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			build.Revision = setting.Value
+		}
+	}
+
+	return build
+}
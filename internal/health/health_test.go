@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubPinger struct {
+	err   error
+	calls int
+}
+
+func (p *stubPinger) Ping(ctx context.Context) error {
+	p.calls++
+	return p.err
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	failure := errors.New("connection refused")
+
+	breaker.Record(failure)
+	if got := breaker.State(); got != CircuitClosed {
+		t.Fatalf("Expected closed below threshold, got %s", got)
+	}
+
+	breaker.Record(failure)
+	if got := breaker.State(); got != CircuitOpen {
+		t.Fatalf("Expected open at threshold, got %s", got)
+	}
+
+	now = now.Add(time.Minute)
+	if got := breaker.State(); got != CircuitHalfOpen {
+		t.Fatalf("Expected half open after cooldown, got %s", got)
+	}
+
+	breaker.Record(failure)
+	if got := breaker.State(); got != CircuitOpen {
+		t.Fatalf("Expected a failed probe to reopen, got %s", got)
+	}
+
+	now = now.Add(time.Minute)
+	breaker.Record(nil)
+	if got := breaker.State(); got != CircuitClosed {
+		t.Fatalf("Expected a successful probe to close, got %s", got)
+	}
+}
+
+func TestCheckerReady(t *testing.T) {
+	pinger := &stubPinger{}
+	checker := NewChecker("redis", pinger, NewBreaker(1, time.Minute), time.Second, func() string { return "abc123" })
+
+	status, ready := checker.Ready(context.Background())
+	if !ready || status.Status != "ready" || status.Circuit != CircuitClosed {
+		t.Fatalf("Expected ready, got %+v", status)
+	}
+	if status.Backend != "redis" || status.ConfigVersion != "abc123" || status.Build.Version == "" {
+		t.Errorf("Expected backend, config version and build info, got %+v", status)
+	}
+
+	pinger.err = errors.New("connection refused")
+
+	status, ready = checker.Ready(context.Background())
+	if ready || status.Error != "connection refused" || status.Circuit != CircuitOpen {
+		t.Fatalf("Expected not ready with open circuit, got %+v", status)
+	}
+
+	_, ready = checker.Ready(context.Background())
+	if ready || pinger.calls != 2 {
+		t.Errorf("Expected open circuit to skip the ping, got %d pings", pinger.calls)
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	pinger := pingerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker := NewChecker("redis", pinger, NewBreaker(5, time.Minute), 10*time.Millisecond, nil)
+
+	status, ready := checker.Ready(context.Background())
+	if ready || status.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected timeout to make the instance not ready, got %+v", status)
+	}
+}
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry holds every metric of the service. Client IDs are never used as
+// label values so that cardinality stays bounded by the number of tiers and
+// rules.
+var Registry = prometheus.NewRegistry()
+
+var (
+	Decisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limiter_decisions_total",
+		Help: "Rate limit decisions by result, route rule and tier.",
+	}, []string{"result", "rule", "tier"})
+
+	RepositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rate_limiter_repository_duration_seconds",
+		Help:    "Latency of repository operations per backend.",
+		Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"backend", "operation"})
+
+	RepositoryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limiter_repository_errors_total",
+		Help: "Failed repository operations per backend.",
+	}, []string{"backend", "operation"})
+
+	AdaptiveLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rate_limiter_adaptive_limit",
+		Help: "Current effective limit of adaptive route rules.",
+	}, []string{"rule"})
+
+	AdaptiveFactor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rate_limiter_adaptive_factor",
+		Help: "Current factor applied to the configured limit of adaptive route rules.",
+	}, []string{"rule"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Decisions,
+		RepositoryDuration,
+		RepositoryErrors,
+		AdaptiveLimit,
+		AdaptiveFactor,
+	)
+}
+
+// RegisterStoreSize exposes the number of entries per store of the memory
+// backend, read on every scrape.
+func RegisterStoreSize(sizes func() map[string]int) {
+	Registry.MustRegister(&storeSizeCollector{sizes: sizes})
+}
+
+var storeSizeDesc = prometheus.NewDesc(
+	"rate_limiter_memory_store_entries",
+	"Number of entries held by the memory backend per store.",
+	[]string{"store"}, nil,
+)
+
+type storeSizeCollector struct {
+	sizes func() map[string]int
+}
+
+func (c *storeSizeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- storeSizeDesc
+}
+
+func (c *storeSizeCollector) Collect(ch chan<- prometheus.Metric) {
+	for store, size := range c.sizes() {
+		ch <- prometheus.MustNewConstMetric(storeSizeDesc, prometheus.GaugeValue, float64(size), store)
+	}
+}
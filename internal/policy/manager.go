@@ -0,0 +1,260 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"rate-limiter-go/internal/audit"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/usecase"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Manager loads the policy file and applies it through the use case. A policy
+// that fails to parse or validate is rejected as a whole and the previous one
+// stays in effect; one the use case rejects midway is rolled back to it.
+type Manager struct {
+	path    string
+	useCase usecase.RateLimiterUseCase
+
+	mu      sync.Mutex
+	current *Policy
+	modTime time.Time
+}
+
+func NewManager(path string, useCase usecase.RateLimiterUseCase) *Manager {
+	return &Manager{
+		path:    path,
+		useCase: useCase,
+	}
+}
+
+func (m *Manager) Current() *Policy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+func (m *Manager) Version() string {
+	if current := m.Current(); current != nil {
+		return current.Version
+	}
+	return ""
+}
+
+// Reload reads the policy file and applies it if its content changed.
+func (m *Manager) Reload(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, err := os.Stat(m.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat policy file: %w", err)
+	}
+
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	next, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	if m.current != nil && m.current.Version == next.Version {
+		m.modTime = info.ModTime()
+		return nil
+	}
+
+	previous := m.current
+	if previous == nil {
+		previous = &Policy{}
+	}
+
+	ctx = audit.WithActor(ctx, "policy:"+m.path)
+	if err := m.apply(ctx, previous, next); err != nil {
+		if rollbackErr := m.apply(ctx, next, previous); rollbackErr != nil {
+			return fmt.Errorf("failed to apply policy %s, previous policy only partly restored: %w", next.Version, errors.Join(err, rollbackErr))
+		}
+		return fmt.Errorf("failed to apply policy %s, previous policy restored: %w", next.Version, err)
+	}
+
+	m.current = next
+	m.modTime = info.ModTime()
+	slog.InfoContext(ctx, "policy applied", "version", next.Version, "path", m.path)
+
+	return nil
+}
+
+// Watch polls the policy file and reloads it whenever it changes, until ctx
+// is cancelled.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(m.path)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to stat policy file", "path", m.path, "error", err)
+			continue
+		}
+
+		m.mu.Lock()
+		changed := !info.ModTime().Equal(m.modTime)
+		m.mu.Unlock()
+
+		if changed {
+			if err := m.Reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "policy reload rejected, keeping previous policy", "path", m.path, "error", err)
+			}
+		}
+	}
+}
+
+// apply upserts everything the policy defines, then removes what the previous
+// policy defined and this one no longer does. Tiers go first and are removed
+// last so clients never reference a missing tier, and parents are configured
+// before their children.
+func (m *Manager) apply(ctx context.Context, previous, next *Policy) error {
+	for _, name := range sortedKeys(next.Tiers) {
+		if err := m.useCase.SaveTier(ctx, toTier(name, next.Tiers[name])); err != nil {
+			return err
+		}
+	}
+
+	if next.Defaults != nil {
+		if err := m.useCase.SaveTier(ctx, toTier(domain.DefaultTier, *next.Defaults)); err != nil {
+			return err
+		}
+	}
+
+	for _, spec := range next.Rules {
+		if err := m.useCase.SaveRule(ctx, toRule(spec)); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range next.parentsFirst() {
+		if err := m.useCase.ConfigureRateLimit(ctx, id, toConfig(next.Clients[id])); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range sortedKeys(previous.Clients) {
+		if _, exists := next.Clients[id]; !exists {
+			if err := m.release(ctx, id, previous.Clients[id]); err != nil {
+				return err
+			}
+		}
+	}
+
+	rules := make(map[string]bool)
+	for _, spec := range next.Rules {
+		rules[spec.ID] = true
+	}
+	for _, spec := range previous.Rules {
+		if !rules[spec.ID] {
+			if err := m.useCase.DeleteRule(ctx, spec.ID); err != nil && !errors.Is(err, domain.ErrRuleNotFound) {
+				return err
+			}
+		}
+	}
+
+	if previous.Defaults != nil && next.Defaults == nil {
+		if err := m.useCase.DeleteTier(ctx, domain.DefaultTier); err != nil && !errors.Is(err, domain.ErrTierNotFound) {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(previous.Tiers) {
+		if _, exists := next.Tiers[name]; !exists {
+			err := m.useCase.DeleteTier(ctx, name)
+			if errors.Is(err, domain.ErrTierInUse) {
+				slog.WarnContext(ctx, "policy tier still assigned to clients, keeping it", "tier", name)
+				continue
+			}
+			if err != nil && !errors.Is(err, domain.ErrTierNotFound) {
+				return err
+			}
+		}
+	}
+
+	m.useCase.SetPolicyAccessEntries(next.AccessEntries())
+
+	return nil
+}
+
+// release unsets what the previous policy set on a client it no longer
+// lists, keeping anything assigned through the admin API.
+func (m *Manager) release(ctx context.Context, id string, spec ClientSpec) error {
+	rateLimit, _, err := m.useCase.GetClient(ctx, id)
+	if errors.Is(err, domain.ErrClientNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	config := rateLimit.Config()
+	if spec.Parent != "" && config.ParentID == spec.Parent {
+		config.ParentID = ""
+	}
+	if spec.Tier != "" && config.Tier == spec.Tier {
+		config.Tier = ""
+	}
+	if spec.MaxRequests > 0 && config.MaxRequests == spec.MaxRequests && config.CycleDuration == spec.CycleDuration &&
+		config.Window == spec.Window && config.TimeZone == spec.TimeZone {
+		config.MaxRequests = 0
+		config.CycleDuration = 0
+		config.Window = ""
+		config.TimeZone = ""
+	}
+
+	return m.useCase.ConfigureRateLimit(ctx, id, config)
+}
+
+func toTier(name string, spec LimitSpec) *domain.Tier {
+	return &domain.Tier{
+		Name:          name,
+		MaxRequests:   spec.MaxRequests,
+		CycleDuration: spec.CycleDuration,
+		Window:        spec.Window,
+		TimeZone:      spec.TimeZone,
+		Priority:      spec.Priority,
+	}
+}
+
+func toRule(spec RuleSpec) *domain.RouteRule {
+	return &domain.RouteRule{
+		ID:            spec.ID,
+		Method:        strings.ToUpper(spec.Method),
+		Pattern:       spec.Path,
+		MaxRequests:   spec.MaxRequests,
+		CycleDuration: spec.CycleDuration,
+		Global:        spec.Global,
+		PriorityShare: spec.PriorityShare,
+	}
+}
+
+func toConfig(spec ClientSpec) domain.RateLimitConfig {
+	return domain.RateLimitConfig{
+		MaxRequests:   spec.MaxRequests,
+		CycleDuration: spec.CycleDuration,
+		ParentID:      spec.Parent,
+		Tier:          spec.Tier,
+		Window:        spec.Window,
+		TimeZone:      spec.TimeZone,
+	}
+}
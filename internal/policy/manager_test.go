@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/repository/memory"
+	"rate-limiter-go/internal/usecase"
+	"testing"
+	"time"
+)
+
+func writePolicy(t *testing.T, path, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+}
+
+func TestManager_Reload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	uc := usecase.NewRateLimiterUseCase(memory.NewRateLimiterMemoryRepository(100, 1))
+	manager := NewManager(path, uc)
+
+	writePolicy(t, path, validPolicy)
+	if err := manager.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if _, err := uc.GetTier(ctx, "enterprise"); err != nil {
+		t.Errorf("Expected enterprise tier, got %v", err)
+	}
+	if usage, _ := uc.GetUsage(ctx, "acme"); usage.MaxRequests != 1000 {
+		t.Errorf("Expected acme on pro tier, got %d", usage.MaxRequests)
+	}
+	if verdict, _ := uc.CheckAccess(ctx, "abuser-42", net.ParseIP("203.0.113.1")); verdict != domain.AccessDenied {
+		t.Errorf("Expected denylisted client, got %v", verdict)
+	}
+
+	version := manager.Version()
+
+	writePolicy(t, path, "tiers:\n  pro:\n    max_requests: 0\n")
+	if err := manager.Reload(ctx); err == nil {
+		t.Fatal("Expected invalid policy to be rejected")
+	}
+	if manager.Version() != version {
+		t.Error("Rejected policy should keep the previous version")
+	}
+	if tier, _ := uc.GetTier(ctx, "pro"); tier.MaxRequests != 1000 {
+		t.Errorf("Rejected policy should not be applied, got %d", tier.MaxRequests)
+	}
+
+	writePolicy(t, path, "tiers:\n  pro:\n    max_requests: 500\n    cycle_duration: 60\n")
+	if err := manager.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if _, err := uc.GetTier(ctx, "enterprise"); err == nil {
+		t.Error("Expected removed tier to be deleted")
+	}
+	if _, err := uc.GetRule(ctx, "orders-write"); err == nil {
+		t.Error("Expected removed rule to be deleted")
+	}
+	if usage, _ := uc.GetUsage(ctx, "acme"); usage.MaxRequests != 100 {
+		t.Errorf("Expected removed client override to be reset, got %d", usage.MaxRequests)
+	}
+	if verdict, _ := uc.CheckAccess(ctx, "abuser-42", nil); verdict != domain.AccessNone {
+		t.Errorf("Expected denylist to be cleared, got %v", verdict)
+	}
+}
+
+func TestManager_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	uc := usecase.NewRateLimiterUseCase(memory.NewRateLimiterMemoryRepository(100, 1))
+	manager := NewManager(path, uc)
+
+	writePolicy(t, path, "tiers:\n  pro:\n    max_requests: 10\n    cycle_duration: 1\n")
+	if err := manager.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	go manager.Watch(ctx, 10*time.Millisecond)
+
+	writePolicy(t, path, "tiers:\n  pro:\n    max_requests: 20\n    cycle_duration: 1\n")
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if tier, _ := uc.GetTier(ctx, "pro"); tier.MaxRequests == 20 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Error("Expected policy change to be picked up")
+}
+
+func TestManager_ReleaseKeepsAdminChanges(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	uc := usecase.NewRateLimiterUseCase(memory.NewRateLimiterMemoryRepository(100, 1))
+	manager := NewManager(path, uc)
+
+	writePolicy(t, path, "tiers:\n  pro:\n    max_requests: 10\n    cycle_duration: 1\nclients:\n  acme:\n    max_requests: 5\n    cycle_duration: 1\n")
+	if err := manager.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if err := uc.AssignTier(ctx, "acme", "pro"); err != nil {
+		t.Fatalf("AssignTier failed: %v", err)
+	}
+
+	writePolicy(t, path, "tiers:\n  pro:\n    max_requests: 10\n    cycle_duration: 1\n")
+	if err := manager.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	rateLimit, _, err := uc.GetClient(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	if rateLimit.Custom || rateLimit.Tier != "pro" {
+		t.Errorf("Expected policy limits dropped and admin tier kept, got custom %v tier %q", rateLimit.Custom, rateLimit.Tier)
+	}
+}
+
+func TestManager_ConfiguresParentsFirst(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	uc := usecase.NewRateLimiterUseCase(memory.NewRateLimiterMemoryRepository(100, 1))
+	manager := NewManager(path, uc)
+
+	uc.ConfigureRateLimit(ctx, "a", domain.RateLimitConfig{MaxRequests: 1, CycleDuration: 1})
+	uc.ConfigureRateLimit(ctx, "b", domain.RateLimitConfig{ParentID: "a"})
+
+	// Configuring a under b is only valid once b no longer has a as parent.
+	writePolicy(t, path, "clients:\n  a:\n    parent: b\n  b:\n    max_requests: 1\n    cycle_duration: 1\n")
+	if err := manager.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if rateLimit, _, _ := uc.GetClient(ctx, "a"); rateLimit.ParentID != "b" {
+		t.Errorf("Expected a under b, got parent %q", rateLimit.ParentID)
+	}
+}
+
+func TestManager_RetriesFailedApply(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	uc := usecase.NewRateLimiterUseCaseWithConfig(memory.NewRateLimiterMemoryRepository(100, 1), usecase.Config{Cluster: true})
+	manager := NewManager(path, uc)
+
+	writePolicy(t, path, "tiers:\n  gold:\n    max_requests: 5\n    cycle_duration: 1\nclients:\n  org:\n    max_requests: 1\n    cycle_duration: 1\n  user:\n    parent: org\n")
+	if err := manager.Reload(ctx); err == nil {
+		t.Fatal("Expected parents to be rejected in cluster mode")
+	}
+
+	if _, err := uc.GetTier(ctx, "gold"); err == nil {
+		t.Error("Expected tier of the failed policy to be rolled back")
+	}
+	if usage, _ := uc.GetUsage(ctx, "org"); usage.MaxRequests != 100 {
+		t.Errorf("Expected client of the failed policy to be rolled back, got %d", usage.MaxRequests)
+	}
+
+	if manager.Version() != "" || !manager.modTime.IsZero() {
+		t.Error("Failed apply should leave the file to be retried by Watch")
+	}
+}
@@ -0,0 +1,318 @@
+package policy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"rate-limiter-go/internal/domain"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is the declarative configuration loaded from the policy file. YAML
+// and JSON documents are both accepted.
+type Policy struct {
+	Defaults  *LimitSpec            `yaml:"defaults"`
+	Tiers     map[string]LimitSpec  `yaml:"tiers"`
+	Rules     []RuleSpec            `yaml:"rules"`
+	Allowlist []string              `yaml:"allowlist"`
+	Denylist  []string              `yaml:"denylist"`
+	Clients   map[string]ClientSpec `yaml:"clients"`
+
+	Version string `yaml:"-"`
+}
+
+type LimitSpec struct {
+	MaxRequests   int    `yaml:"max_requests"`
+	CycleDuration int    `yaml:"cycle_duration"`
+	Window        string `yaml:"window"`
+	TimeZone      string `yaml:"timezone"`
+	Priority      int    `yaml:"priority"`
+}
+
+type RuleSpec struct {
+	ID            string `yaml:"id"`
+	Method        string `yaml:"method"`
+	Path          string `yaml:"path"`
+	MaxRequests   int    `yaml:"max_requests"`
+	CycleDuration int    `yaml:"cycle_duration"`
+	Global        bool   `yaml:"global"`
+	PriorityShare int    `yaml:"priority_share"`
+}
+
+type ClientSpec struct {
+	LimitSpec `yaml:",inline"`
+	Parent    string `yaml:"parent"`
+	Tier      string `yaml:"tier"`
+}
+
+// FieldError points at the offending value in the policy file.
+type FieldError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+	return "invalid policy: " + strings.Join(messages, "; ")
+}
+
+// Parse decodes and validates a policy document. Unknown fields are rejected.
+func Parse(data []byte) (*Policy, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	policy := &Policy{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	if err := policy.validate(&root); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	policy.Version = hex.EncodeToString(sum[:6])
+
+	return policy, nil
+}
+
+// AccessEntries converts the allow and deny lists into domain entries.
+func (p *Policy) AccessEntries() []*domain.AccessEntry {
+	var entries []*domain.AccessEntry
+
+	for _, pattern := range p.Denylist {
+		entries = append(entries, &domain.AccessEntry{ID: "policy:deny:" + pattern, List: domain.AccessDeny, Kind: domain.AccessKindOf(pattern), Pattern: pattern, Reason: "policy"})
+	}
+	for _, pattern := range p.Allowlist {
+		entries = append(entries, &domain.AccessEntry{ID: "policy:allow:" + pattern, List: domain.AccessAllow, Kind: domain.AccessKindOf(pattern), Pattern: pattern, Reason: "policy"})
+	}
+
+	return entries
+}
+
+func (p *Policy) validate(root *yaml.Node) error {
+	v := &validator{root: root}
+
+	if p.Defaults != nil {
+		v.limit(p.Defaults, true, "defaults")
+	}
+
+	for _, name := range sortedKeys(p.Tiers) {
+		spec := p.Tiers[name]
+		if name == domain.DefaultTier && p.Defaults != nil {
+			v.fail("default tier conflicts with defaults", "tiers", name)
+		}
+		v.limit(&spec, true, "tiers", name)
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range p.Rules {
+		index := strconv.Itoa(i)
+		switch {
+		case rule.ID == "":
+			v.fail("id is required", "rules", index)
+		case seen[rule.ID]:
+			v.fail("duplicate rule id "+rule.ID, "rules", index, "id")
+		}
+		seen[rule.ID] = true
+
+		if !domain.IsValidRulePattern(rule.Path) {
+			v.fail("invalid path pattern", "rules", index, "path")
+		}
+		if rule.MaxRequests <= 0 {
+			v.fail("must be greater than zero", "rules", index, "max_requests")
+		}
+		if rule.CycleDuration <= 0 {
+			v.fail("must be greater than zero", "rules", index, "cycle_duration")
+		}
+		if rule.PriorityShare < 0 || rule.PriorityShare > 100 || (!rule.Global && rule.PriorityShare != 0) {
+			v.fail("must be a percentage on a global rule", "rules", index, "priority_share")
+		}
+	}
+
+	for _, list := range []string{"allowlist", "denylist"} {
+		patterns := p.Allowlist
+		if list == "denylist" {
+			patterns = p.Denylist
+		}
+		for i, pattern := range patterns {
+			if !domain.IsValidAccessPattern(domain.AccessKindOf(pattern), pattern) {
+				v.fail("invalid pattern", list, strconv.Itoa(i))
+			}
+		}
+	}
+
+	for _, id := range sortedKeys(p.Clients) {
+		client := p.Clients[id]
+		if !domain.IsValidClientID(id) {
+			v.fail("client id must not contain \"|\"", "clients", id)
+		}
+		if client.Parent == id {
+			v.fail("client cannot be its own parent", "clients", id, "parent")
+		} else if _, exists := p.Clients[client.Parent]; client.Parent != "" && !exists {
+			v.fail("unknown parent "+client.Parent, "clients", id, "parent")
+		} else if message := p.hierarchyError(id); message != "" {
+			v.fail(message, "clients", id, "parent")
+		}
+		if _, exists := p.Tiers[client.Tier]; client.Tier != "" && client.Tier != domain.DefaultTier && !exists {
+			v.fail("unknown tier "+client.Tier, "clients", id, "tier")
+		}
+		if client.Priority != 0 {
+			v.fail("priority is set on tiers", "clients", id, "priority")
+		}
+		v.limit(&client.LimitSpec, false, "clients", id)
+	}
+
+	if len(v.errors) > 0 {
+		return &ValidationError{Fields: v.errors}
+	}
+	return nil
+}
+
+// hierarchyError walks the parents of a client within the policy and
+// describes a cycle or a hierarchy deeper than the use case accepts.
+func (p *Policy) hierarchyError(id string) string {
+	for parent, depth := p.Clients[id].Parent, 1; parent != ""; depth++ {
+		if parent == id {
+			return "parent cycle through " + id
+		}
+		if depth >= domain.MaxHierarchyDepth {
+			return fmt.Sprintf("hierarchy deeper than %d levels", domain.MaxHierarchyDepth)
+		}
+		parent = p.Clients[parent].Parent
+	}
+	return ""
+}
+
+// parentsFirst orders the clients so every parent is configured before its
+// children, which keeps each step of an apply a valid hierarchy.
+func (p *Policy) parentsFirst() []string {
+	var order []string
+	visited := make(map[string]bool)
+
+	var visit func(id string)
+	visit = func(id string) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		if _, exists := p.Clients[p.Clients[id].Parent]; exists {
+			visit(p.Clients[id].Parent)
+		}
+		order = append(order, id)
+	}
+
+	for _, id := range sortedKeys(p.Clients) {
+		visit(id)
+	}
+
+	return order
+}
+
+type validator struct {
+	root   *yaml.Node
+	errors []FieldError
+}
+
+func (v *validator) limit(spec *LimitSpec, required bool, path ...string) {
+	if !domain.IsValidWindow(spec.Window) {
+		v.fail("unknown window "+spec.Window, append(path, "window")...)
+	}
+	if _, err := domain.LoadTimeZone(spec.TimeZone); err != nil {
+		v.fail("unknown timezone "+spec.TimeZone, append(path, "timezone")...)
+	}
+	if spec.MaxRequests < 0 || (required && spec.MaxRequests == 0) {
+		v.fail("must be greater than zero", append(path, "max_requests")...)
+	}
+	if spec.CycleDuration < 0 {
+		v.fail("must not be negative", append(path, "cycle_duration")...)
+	}
+	if spec.Priority < 0 {
+		v.fail("must not be negative", append(path, "priority")...)
+	}
+
+	switch {
+	case spec.MaxRequests == 0 && (spec.CycleDuration != 0 || spec.Window != "" || spec.TimeZone != ""):
+		v.fail("limits require max_requests", path...)
+	case spec.MaxRequests > 0 && spec.CycleDuration == 0 && !domain.IsCalendarWindow(spec.Window):
+		v.fail("cycle_duration is required for rolling windows", append(path, "cycle_duration")...)
+	}
+}
+
+func (v *validator) fail(message string, path ...string) {
+	node := locate(v.root, path)
+	v.errors = append(v.errors, FieldError{
+		Path:    strings.Join(path, "."),
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: message,
+	})
+}
+
+// locate returns the deepest node along path, so a missing field is reported
+// at its parent.
+func locate(node *yaml.Node, path []string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, key := range path {
+		next := child(node, key)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return node
+}
+
+func child(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+)
+
+const validPolicy = `
+defaults:
+  max_requests: 50
+  cycle_duration: 1
+tiers:
+  pro:
+    max_requests: 1000
+    cycle_duration: 60
+  enterprise:
+    max_requests: 100000
+    window: month
+    timezone: Asia/Jakarta
+rules:
+  - id: orders-write
+    method: POST
+    path: /api/v1/protected/orders/**
+    max_requests: 5
+    cycle_duration: 1
+allowlist:
+  - 10.0.0.0/8
+  - health-*
+denylist:
+  - abuser-42
+clients:
+  acme:
+    tier: pro
+  acme-mobile:
+    parent: acme
+    max_requests: 20
+    cycle_duration: 1
+`
+
+func TestParse(t *testing.T) {
+	policy, err := Parse([]byte(validPolicy))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if policy.Defaults.MaxRequests != 50 || len(policy.Tiers) != 2 || len(policy.Rules) != 1 {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+	if policy.Clients["acme-mobile"].Parent != "acme" || policy.Clients["acme-mobile"].MaxRequests != 20 {
+		t.Errorf("Client override not decoded, got %+v", policy.Clients["acme-mobile"])
+	}
+	if len(policy.AccessEntries()) != 3 {
+		t.Errorf("Expected 3 access entries, got %d", len(policy.AccessEntries()))
+	}
+	if policy.Version == "" {
+		t.Error("Expected a version")
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	policy, err := Parse([]byte(`{"tiers": {"pro": {"max_requests": 10, "cycle_duration": 1}}}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if policy.Tiers["pro"].MaxRequests != 10 {
+		t.Errorf("Expected pro tier, got %+v", policy.Tiers)
+	}
+}
+
+func TestParseErrorLocations(t *testing.T) {
+	data := `tiers:
+  pro:
+    max_requests: 10
+    window: fortnight
+rules:
+  - id: orders
+    path: /orders/**
+    max_requests: 0
+    cycle_duration: 1
+clients:
+  acme:
+    tier: gold
+`
+
+	_, err := Parse([]byte(data))
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	expected := map[string][2]int{
+		"tiers.pro.window":         {4, 13},
+		"rules.0.max_requests":     {8, 19},
+		"clients.acme.tier":        {12, 11},
+		"tiers.pro.cycle_duration": {3, 5},
+	}
+
+	for _, field := range validation.Fields {
+		location, ok := expected[field.Path]
+		if !ok {
+			t.Errorf("Unexpected error %v", field)
+			continue
+		}
+		if field.Line != location[0] || field.Column != location[1] {
+			t.Errorf("%s: expected line %d column %d, got %d:%d", field.Path, location[0], location[1], field.Line, field.Column)
+		}
+		delete(expected, field.Path)
+	}
+
+	for path := range expected {
+		t.Errorf("Missing error for %s", path)
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	_, err := Parse([]byte("tiers:\n  pro:\n    max_request: 10\n"))
+	if err == nil {
+		t.Fatal("Expected unknown field to be rejected")
+	}
+}
+
+func TestParseHierarchy(t *testing.T) {
+	tests := map[string]string{
+		"cycle":          "clients:\n  a:\n    parent: b\n  b:\n    parent: a\n",
+		"unknown parent": "clients:\n  a:\n    parent: missing\n",
+		"counter key":    "clients:\n  a|rule:x:\n    max_requests: 1\n    cycle_duration: 1\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var validation *ValidationError
+			if _, err := Parse([]byte(data)); !errors.As(err, &validation) {
+				t.Errorf("Expected ValidationError, got %v", err)
+			}
+		})
+	}
+}
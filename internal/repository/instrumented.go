@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/metrics"
+	"time"
+)
+
+// instrumentedRepository records the latency and errors of every operation
+// of the wrapped repository, and reports each outcome to the observers.
+// Observers only see failures of the backend itself; an operation rejected
+// because of its input, such as an invalid cursor, counts as answered.
+type instrumentedRepository struct {
+	repo      RateLimiterRepository
+	backend   string
+	observers []func(error)
+}
+
+func NewInstrumentedRepository(repo RateLimiterRepository, backend string, observers ...func(error)) RateLimiterRepository {
+	return &instrumentedRepository{
+		repo:      repo,
+		backend:   backend,
+		observers: observers,
+	}
+}
+
+func (r *instrumentedRepository) observe(operation string, start time.Time, err error) {
+	r.record(operation, start, err)
+
+	if !isBackendError(err) {
+		err = nil
+	}
+	for _, observer := range r.observers {
+		observer(err)
+	}
+}
+
+// record only updates the metrics, for operations whose outcome says nothing
+// about the health of the backend.
+func (r *instrumentedRepository) record(operation string, start time.Time, err error) {
+	metrics.RepositoryDuration.WithLabelValues(r.backend, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.RepositoryErrors.WithLabelValues(r.backend, operation).Inc()
+	}
+}
+
+func isBackendError(err error) bool {
+	return err != nil && !errors.Is(err, domain.ErrInvalidCursor) && !errors.Is(err, context.Canceled)
+}
+
+func (r *instrumentedRepository) Get(ctx context.Context, clientID string) (*domain.RateLimit, bool, error) {
+	start := time.Now()
+	rateLimit, exists, err := r.repo.Get(ctx, clientID)
+	r.observe("get", start, err)
+	return rateLimit, exists, err
+}
+
+func (r *instrumentedRepository) Save(ctx context.Context, rateLimit *domain.RateLimit) error {
+	start := time.Now()
+	err := r.repo.Save(ctx, rateLimit)
+	r.observe("save", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) Delete(ctx context.Context, clientID string) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, clientID)
+	r.observe("delete", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) CreateDefault(ctx context.Context, clientID string) *domain.RateLimit {
+	start := time.Now()
+	rateLimit := r.repo.CreateDefault(ctx, clientID)
+	r.record("create_default", start, nil)
+	return rateLimit
+}
+
+func (r *instrumentedRepository) ConsumeBatch(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeResult, bool, error) {
+	start := time.Now()
+	results, allowed, err := r.repo.ConsumeBatch(ctx, requests)
+	r.observe("consume_batch", start, err)
+	return results, allowed, err
+}
+
+func (r *instrumentedRepository) ListRateLimits(ctx context.Context, filter domain.ClientFilter, cursor string, limit int) ([]*domain.RateLimit, string, error) {
+	start := time.Now()
+	rateLimits, next, err := r.repo.ListRateLimits(ctx, filter, cursor, limit)
+	r.observe("list_rate_limits", start, err)
+	return rateLimits, next, err
+}
+
+func (r *instrumentedRepository) HasTierClients(ctx context.Context, tier string) (bool, error) {
+	start := time.Now()
+	found, err := r.repo.HasTierClients(ctx, tier)
+	r.observe("has_tier_clients", start, err)
+	return found, err
+}
+
+func (r *instrumentedRepository) GetTier(ctx context.Context, name string) (*domain.Tier, bool, error) {
+	start := time.Now()
+	tier, exists, err := r.repo.GetTier(ctx, name)
+	r.observe("get_tier", start, err)
+	return tier, exists, err
+}
+
+func (r *instrumentedRepository) SaveTier(ctx context.Context, tier *domain.Tier) error {
+	start := time.Now()
+	err := r.repo.SaveTier(ctx, tier)
+	r.observe("save_tier", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) DeleteTier(ctx context.Context, name string) error {
+	start := time.Now()
+	err := r.repo.DeleteTier(ctx, name)
+	r.observe("delete_tier", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) ListTiers(ctx context.Context) ([]*domain.Tier, error) {
+	start := time.Now()
+	tiers, err := r.repo.ListTiers(ctx)
+	r.observe("list_tiers", start, err)
+	return tiers, err
+}
+
+func (r *instrumentedRepository) GetRule(ctx context.Context, id string) (*domain.RouteRule, bool, error) {
+	start := time.Now()
+	rule, exists, err := r.repo.GetRule(ctx, id)
+	r.observe("get_rule", start, err)
+	return rule, exists, err
+}
+
+func (r *instrumentedRepository) SaveRule(ctx context.Context, rule *domain.RouteRule) error {
+	start := time.Now()
+	err := r.repo.SaveRule(ctx, rule)
+	r.observe("save_rule", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) DeleteRule(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.repo.DeleteRule(ctx, id)
+	r.observe("delete_rule", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) ListRules(ctx context.Context) ([]*domain.RouteRule, error) {
+	start := time.Now()
+	rules, err := r.repo.ListRules(ctx)
+	r.observe("list_rules", start, err)
+	return rules, err
+}
+
+func (r *instrumentedRepository) GetAccessEntry(ctx context.Context, id string) (*domain.AccessEntry, bool, error) {
+	start := time.Now()
+	entry, exists, err := r.repo.GetAccessEntry(ctx, id)
+	r.observe("get_access_entry", start, err)
+	return entry, exists, err
+}
+
+func (r *instrumentedRepository) SaveAccessEntry(ctx context.Context, entry *domain.AccessEntry) error {
+	start := time.Now()
+	err := r.repo.SaveAccessEntry(ctx, entry)
+	r.observe("save_access_entry", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) DeleteAccessEntry(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.repo.DeleteAccessEntry(ctx, id)
+	r.observe("delete_access_entry", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) ListAccessEntries(ctx context.Context) ([]*domain.AccessEntry, error) {
+	start := time.Now()
+	entries, err := r.repo.ListAccessEntries(ctx)
+	r.observe("list_access_entries", start, err)
+	return entries, err
+}
+
+func (r *instrumentedRepository) GetPenalty(ctx context.Context, clientID string) (*domain.Penalty, bool, error) {
+	start := time.Now()
+	penalty, exists, err := r.repo.GetPenalty(ctx, clientID)
+	r.observe("get_penalty", start, err)
+	return penalty, exists, err
+}
+
+func (r *instrumentedRepository) SavePenalty(ctx context.Context, penalty *domain.Penalty, ttl time.Duration) error {
+	start := time.Now()
+	err := r.repo.SavePenalty(ctx, penalty, ttl)
+	r.observe("save_penalty", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) DeletePenalty(ctx context.Context, clientID string) error {
+	start := time.Now()
+	err := r.repo.DeletePenalty(ctx, clientID)
+	r.observe("delete_penalty", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) AcquireLease(ctx context.Context, lease *domain.ConcurrencyLease) (bool, error) {
+	start := time.Now()
+	acquired, err := r.repo.AcquireLease(ctx, lease)
+	r.observe("acquire_lease", start, err)
+	return acquired, err
+}
+
+func (r *instrumentedRepository) RenewLease(ctx context.Context, lease *domain.ConcurrencyLease) error {
+	start := time.Now()
+	err := r.repo.RenewLease(ctx, lease)
+	r.observe("renew_lease", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) ReleaseLease(ctx context.Context, lease *domain.ConcurrencyLease) error {
+	start := time.Now()
+	err := r.repo.ReleaseLease(ctx, lease)
+	r.observe("release_lease", start, err)
+	return err
+}
@@ -0,0 +1,84 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/metrics"
+	"rate-limiter-go/internal/repository"
+	"rate-limiter-go/internal/repository/memory"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type failingRepository struct {
+	repository.RateLimiterRepository
+}
+
+func (failingRepository) GetTier(ctx context.Context, name string) (*domain.Tier, bool, error) {
+	return nil, false, errors.New("backend down")
+}
+
+func (failingRepository) ListRateLimits(ctx context.Context, filter domain.ClientFilter, cursor string, limit int) ([]*domain.RateLimit, string, error) {
+	return nil, "", fmt.Errorf("%w: %q", domain.ErrInvalidCursor, cursor)
+}
+
+func (failingRepository) CreateDefault(ctx context.Context, clientID string) *domain.RateLimit {
+	return &domain.RateLimit{ClientID: clientID, MaxRequests: 100, CycleDuration: 1}
+}
+
+func TestInstrumentedRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInstrumentedRepository(memory.NewRateLimiterMemoryRepository(100, 1), "unit")
+
+	before := testutil.CollectAndCount(metrics.RepositoryDuration, "rate_limiter_repository_duration_seconds")
+
+	if _, _, err := repo.Get(ctx, "client"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if err := repo.Save(ctx, &domain.RateLimit{ClientID: "client", MaxRequests: 1, CycleDuration: 1}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	after := testutil.CollectAndCount(metrics.RepositoryDuration, "rate_limiter_repository_duration_seconds")
+	if after != before+2 {
+		t.Errorf("Expected 2 new operation series, got %d", after-before)
+	}
+
+	failing := repository.NewInstrumentedRepository(failingRepository{}, "unit")
+	if _, _, err := failing.GetTier(ctx, "pro"); err == nil {
+		t.Fatal("Expected error to be passed through")
+	}
+
+	if got := testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("unit", "get_tier")); got != 1 {
+		t.Errorf("Expected 1 error, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.RepositoryErrors.WithLabelValues("unit", "get")); got != 0 {
+		t.Errorf("Expected no errors for get, got %v", got)
+	}
+}
+
+func TestInstrumentedRepository_ObserversSeeBackendErrorsOnly(t *testing.T) {
+	ctx := context.Background()
+
+	var observed []error
+	repo := repository.NewInstrumentedRepository(failingRepository{}, "unit", func(err error) {
+		observed = append(observed, err)
+	})
+
+	repo.ListRateLimits(ctx, domain.ClientFilter{}, "bogus", 10)
+	repo.GetTier(ctx, "pro")
+	repo.CreateDefault(ctx, "client")
+
+	if len(observed) != 2 {
+		t.Fatalf("Expected 2 observed outcomes, CreateDefault not among them, got %d", len(observed))
+	}
+	if observed[0] != nil {
+		t.Errorf("An invalid cursor is not a backend failure, got %v", observed[0])
+	}
+	if observed[1] == nil {
+		t.Error("Expected the backend failure to be observed")
+	}
+}
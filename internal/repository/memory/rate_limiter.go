@@ -4,6 +4,7 @@ import (
 	"context"
 	"rate-limiter-go/internal/domain"
 	"rate-limiter-go/internal/repository"
+	"sort"
 	"sync"
 	"time"
 )
@@ -11,13 +12,29 @@ import (
 type memoryRateLimiterRepository struct {
 	mu                   sync.RWMutex
 	store                map[string]*domain.RateLimit
+	configMu             sync.RWMutex
+	tiers                map[string]*domain.Tier
+	rules                map[string]*domain.RouteRule
+	access               map[string]*domain.AccessEntry
+	penalties            map[string]*penaltyEntry
+	leases               map[string]map[string]time.Time
 	defaultMaxRequest    int
 	defaultCycleDuration int
 }
 
+type penaltyEntry struct {
+	Penalty   *domain.Penalty `json:"penalty"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
 func NewRateLimiterMemoryRepository(defaultMaxRequests, defaultCycleDuration int) repository.RateLimiterRepository {
 	return &memoryRateLimiterRepository{
 		store:                make(map[string]*domain.RateLimit),
+		tiers:                make(map[string]*domain.Tier),
+		rules:                make(map[string]*domain.RouteRule),
+		access:               make(map[string]*domain.AccessEntry),
+		penalties:            make(map[string]*penaltyEntry),
+		leases:               make(map[string]map[string]time.Time),
 		defaultMaxRequest:    defaultMaxRequests,
 		defaultCycleDuration: defaultCycleDuration,
 	}
@@ -47,13 +64,22 @@ func (r *memoryRateLimiterRepository) Save(ctx context.Context, rateLimit *domai
 }
 
 func (r *memoryRateLimiterRepository) CreateDefault(ctx context.Context, clientID string) *domain.RateLimit {
-	return &domain.RateLimit{
+	rateLimit := &domain.RateLimit{
 		ClientID:      clientID,
 		RequestCount:  0,
 		CycleStart:    time.Now(),
 		CycleDuration: r.defaultCycleDuration,
 		MaxRequests:   r.defaultMaxRequest,
 	}
+
+	if tier, exists, _ := r.GetTier(ctx, domain.DefaultTier); exists {
+		rateLimit.MaxRequests = tier.MaxRequests
+		rateLimit.CycleDuration = tier.CycleDuration
+		rateLimit.Window = tier.Window
+		rateLimit.TimeZone = tier.TimeZone
+	}
+
+	return rateLimit
 }
 
 func (r *memoryRateLimiterRepository) Delete(ctx context.Context, clientID string) error {
@@ -63,3 +89,319 @@ func (r *memoryRateLimiterRepository) Delete(ctx context.Context, clientID strin
 	delete(r.store, clientID)
 	return nil
 }
+
+func (r *memoryRateLimiterRepository) ConsumeBatch(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeResult, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded := make(map[string]*domain.RateLimit)
+	limits := make([]*domain.RateLimit, len(requests))
+
+	for i, req := range requests {
+		rateLimit, ok := loaded[req.ClientID]
+		if !ok {
+			if stored, exists := r.store[req.ClientID]; exists {
+				copy := *stored
+				rateLimit = &copy
+			} else {
+				rateLimit = r.CreateDefault(ctx, req.ClientID)
+			}
+			loaded[req.ClientID] = rateLimit
+		}
+		limits[i] = rateLimit
+	}
+
+	results, allowed := domain.EvaluateBatch(limits, requests)
+
+	for clientID, rateLimit := range loaded {
+		copy := *rateLimit
+		r.store[clientID] = &copy
+	}
+
+	return results, allowed, nil
+}
+
+// ListRateLimits pages through clients in ID order; the cursor is the last ID
+// returned.
+func (r *memoryRateLimiterRepository) ListRateLimits(ctx context.Context, filter domain.ClientFilter, cursor string, limit int) ([]*domain.RateLimit, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.store))
+	for id, rateLimit := range r.store {
+		if id > cursor && filter.Matches(rateLimit) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	next := ""
+	if len(ids) > limit {
+		ids = ids[:limit]
+		next = ids[limit-1]
+	}
+
+	rateLimits := make([]*domain.RateLimit, 0, len(ids))
+	for _, id := range ids {
+		copy := *r.store[id]
+		rateLimits = append(rateLimits, &copy)
+	}
+
+	return rateLimits, next, nil
+}
+
+func (r *memoryRateLimiterRepository) HasTierClients(ctx context.Context, tier string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filter := domain.ClientFilter{Tier: tier}
+	for _, rateLimit := range r.store {
+		if filter.Matches(rateLimit) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *memoryRateLimiterRepository) GetTier(ctx context.Context, name string) (*domain.Tier, bool, error) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+
+	tier, exists := r.tiers[name]
+	if !exists {
+		return nil, false, nil
+	}
+
+	copy := *tier
+	return &copy, true, nil
+}
+
+func (r *memoryRateLimiterRepository) SaveTier(ctx context.Context, tier *domain.Tier) error {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	copy := *tier
+	r.tiers[tier.Name] = &copy
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) DeleteTier(ctx context.Context, name string) error {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	delete(r.tiers, name)
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) ListTiers(ctx context.Context) ([]*domain.Tier, error) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+
+	tiers := make([]*domain.Tier, 0, len(r.tiers))
+	for _, tier := range r.tiers {
+		copy := *tier
+		tiers = append(tiers, &copy)
+	}
+
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Name < tiers[j].Name })
+	return tiers, nil
+}
+
+func (r *memoryRateLimiterRepository) GetRule(ctx context.Context, id string) (*domain.RouteRule, bool, error) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+
+	rule, exists := r.rules[id]
+	if !exists {
+		return nil, false, nil
+	}
+
+	copy := *rule
+	return &copy, true, nil
+}
+
+func (r *memoryRateLimiterRepository) SaveRule(ctx context.Context, rule *domain.RouteRule) error {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	copy := *rule
+	r.rules[rule.ID] = &copy
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) DeleteRule(ctx context.Context, id string) error {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	delete(r.rules, id)
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) ListRules(ctx context.Context) ([]*domain.RouteRule, error) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+
+	rules := make([]*domain.RouteRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		copy := *rule
+		rules = append(rules, &copy)
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+func (r *memoryRateLimiterRepository) GetAccessEntry(ctx context.Context, id string) (*domain.AccessEntry, bool, error) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+
+	entry, exists := r.access[id]
+	if !exists || entry.Expired(time.Now()) {
+		return nil, false, nil
+	}
+
+	copy := *entry
+	return &copy, true, nil
+}
+
+func (r *memoryRateLimiterRepository) SaveAccessEntry(ctx context.Context, entry *domain.AccessEntry) error {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	copy := *entry
+	r.access[entry.ID] = &copy
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) DeleteAccessEntry(ctx context.Context, id string) error {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	delete(r.access, id)
+	return nil
+}
+
+// ListAccessEntries drops expired entries as it goes.
+func (r *memoryRateLimiterRepository) ListAccessEntries(ctx context.Context) ([]*domain.AccessEntry, error) {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	now := time.Now()
+	entries := make([]*domain.AccessEntry, 0, len(r.access))
+	for id, entry := range r.access {
+		if entry.Expired(now) {
+			delete(r.access, id)
+			continue
+		}
+		copy := *entry
+		entries = append(entries, &copy)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+func (r *memoryRateLimiterRepository) GetPenalty(ctx context.Context, clientID string) (*domain.Penalty, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.penalties[clientID]
+	if !exists || !time.Now().Before(entry.ExpiresAt) {
+		return nil, false, nil
+	}
+
+	copy := *entry.Penalty
+	return &copy, true, nil
+}
+
+func (r *memoryRateLimiterRepository) SavePenalty(ctx context.Context, penalty *domain.Penalty, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(r.penalties, penalty.ClientID)
+		return nil
+	}
+
+	copy := *penalty
+	r.penalties[penalty.ClientID] = &penaltyEntry{Penalty: &copy, ExpiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) DeletePenalty(ctx context.Context, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.penalties, clientID)
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) AcquireLease(ctx context.Context, lease *domain.ConcurrencyLease) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	leases := r.leases[lease.Key]
+	if leases == nil {
+		leases = make(map[string]time.Time)
+		r.leases[lease.Key] = leases
+	}
+
+	for id, expiresAt := range leases {
+		if !now.Before(expiresAt) {
+			delete(leases, id)
+		}
+	}
+
+	lease.InFlight = len(leases)
+	if lease.InFlight >= lease.Limit {
+		return false, nil
+	}
+
+	leases[lease.ID] = now.Add(lease.TTL)
+	lease.InFlight++
+	return true, nil
+}
+
+func (r *memoryRateLimiterRepository) RenewLease(ctx context.Context, lease *domain.ConcurrencyLease) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.leases[lease.Key][lease.ID]; exists {
+		r.leases[lease.Key][lease.ID] = time.Now().Add(lease.TTL)
+	}
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) ReleaseLease(ctx context.Context, lease *domain.ConcurrencyLease) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.leases[lease.Key], lease.ID)
+	if len(r.leases[lease.Key]) == 0 {
+		delete(r.leases, lease.Key)
+	}
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) Sizes() map[string]int {
+	r.mu.RLock()
+	r.configMu.RLock()
+	defer r.mu.RUnlock()
+	defer r.configMu.RUnlock()
+
+	return map[string]int{
+		"rate_limits": len(r.store),
+		"tiers":       len(r.tiers),
+		"rules":       len(r.rules),
+		"access":      len(r.access),
+		"penalties":   len(r.penalties),
+		"leases":      len(r.leases),
+	}
+}
+
+func (r *memoryRateLimiterRepository) Ping(ctx context.Context) error {
+	return nil
+}
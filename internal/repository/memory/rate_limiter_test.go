@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"rate-limiter-go/internal/domain"
+	"strings"
 	"testing"
 	"time"
 )
@@ -115,3 +116,230 @@ func TestCreateDefault(t *testing.T) {
 		t.Error("Cycle start should not be zero")
 	}
 }
+
+func TestConsumeBatch(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	ctx := context.Background()
+
+	repo.Save(ctx, &domain.RateLimit{
+		ClientID:      "org",
+		RequestCount:  4,
+		MaxRequests:   5,
+		CycleDuration: 1,
+		CycleStart:    time.Now(),
+	})
+
+	requests := []domain.ConsumeRequest{{ClientID: "user", Cost: 1}, {ClientID: "org", Cost: 1}}
+
+	_, allowed, err := repo.ConsumeBatch(ctx, requests)
+	if err != nil {
+		t.Fatalf("ConsumeBatch failed: %v", err)
+	}
+	if !allowed {
+		t.Fatal("Expected first batch to be allowed")
+	}
+
+	_, allowed, _ = repo.ConsumeBatch(ctx, requests)
+	if allowed {
+		t.Fatal("Expected second batch to be blocked")
+	}
+
+	got, _, _ := repo.Get(ctx, "user")
+	if got.RequestCount != 1 {
+		t.Errorf("Expected user count 1 after blocked batch, got %d", got.RequestCount)
+	}
+}
+
+func TestListRateLimits(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	ctx := context.Background()
+
+	for _, id := range []string{"user-c", "user-a", "user-b", "admin", "user-a|rule:search"} {
+		repo.Save(ctx, &domain.RateLimit{ClientID: id, MaxRequests: 10, CycleDuration: 1})
+	}
+	repo.Save(ctx, &domain.RateLimit{ClientID: "user-d", Tier: "pro", MaxRequests: 10, CycleDuration: 1})
+
+	var ids []string
+	cursor := ""
+	for {
+		page, next, err := repo.ListRateLimits(ctx, domain.ClientFilter{Prefix: "user-"}, cursor, 2)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		for _, rateLimit := range page {
+			ids = append(ids, rateLimit.ClientID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if strings.Join(ids, ",") != "user-a,user-b,user-c,user-d" {
+		t.Errorf("Expected user clients in order without counters, got %v", ids)
+	}
+
+	page, _, _ := repo.ListRateLimits(ctx, domain.ClientFilter{Tier: "pro"}, "", 10)
+	if len(page) != 1 || page[0].ClientID != "user-d" {
+		t.Errorf("Expected only the pro client, got %v", page)
+	}
+}
+
+func TestTiers(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	ctx := context.Background()
+
+	repo.SaveTier(ctx, &domain.Tier{Name: "pro", MaxRequests: 1000, CycleDuration: 1})
+	repo.SaveTier(ctx, &domain.Tier{Name: "free", MaxRequests: 10, CycleDuration: 1})
+
+	tier, exists, err := repo.GetTier(ctx, "pro")
+	if err != nil || !exists {
+		t.Fatalf("Expected tier to exist, err %v", err)
+	}
+	if tier.MaxRequests != 1000 {
+		t.Errorf("Expected max requests 1000, got %d", tier.MaxRequests)
+	}
+
+	tiers, _ := repo.ListTiers(ctx)
+	if len(tiers) != 2 || tiers[0].Name != "free" {
+		t.Errorf("Expected tiers sorted by name, got %v", tiers)
+	}
+
+	repo.DeleteTier(ctx, "pro")
+	if _, exists, _ := repo.GetTier(ctx, "pro"); exists {
+		t.Error("Tier should not exist after delete")
+	}
+}
+
+func TestCreateDefaultUsesDefaultTier(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(50, 5)
+	ctx := context.Background()
+
+	repo.SaveTier(ctx, &domain.Tier{Name: domain.DefaultTier, MaxRequests: 20, CycleDuration: 2})
+
+	defaultRL := repo.CreateDefault(ctx, "new-client")
+	if defaultRL.MaxRequests != 20 || defaultRL.CycleDuration != 2 {
+		t.Errorf("Expected default tier limits, got %d/%d", defaultRL.MaxRequests, defaultRL.CycleDuration)
+	}
+}
+
+func TestRules(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	ctx := context.Background()
+
+	repo.SaveRule(ctx, &domain.RouteRule{ID: "search", Method: "GET", Pattern: "/search", MaxRequests: 100, CycleDuration: 1})
+
+	rule, exists, err := repo.GetRule(ctx, "search")
+	if err != nil || !exists {
+		t.Fatalf("Expected rule to exist, err %v", err)
+	}
+	if rule.Pattern != "/search" {
+		t.Errorf("Wrong pattern %s", rule.Pattern)
+	}
+
+	rules, _ := repo.ListRules(ctx)
+	if len(rules) != 1 {
+		t.Errorf("Expected 1 rule, got %d", len(rules))
+	}
+
+	repo.DeleteRule(ctx, "search")
+	if _, exists, _ := repo.GetRule(ctx, "search"); exists {
+		t.Error("Rule should not exist after delete")
+	}
+}
+
+func TestAccessEntries(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	ctx := context.Background()
+
+	repo.SaveAccessEntry(ctx, &domain.AccessEntry{ID: "office", List: domain.AccessAllow, Pattern: "10.0.0.0/8"})
+	repo.SaveAccessEntry(ctx, &domain.AccessEntry{ID: "expired", List: domain.AccessDeny, Pattern: "abuser", ExpiresAt: time.Now().Add(-time.Second)})
+
+	entry, exists, err := repo.GetAccessEntry(ctx, "office")
+	if err != nil || !exists {
+		t.Fatalf("Expected entry to exist, err %v", err)
+	}
+	if entry.Pattern != "10.0.0.0/8" {
+		t.Errorf("Wrong pattern %s", entry.Pattern)
+	}
+
+	if _, exists, _ := repo.GetAccessEntry(ctx, "expired"); exists {
+		t.Error("Expired entry should not be returned")
+	}
+
+	entries, _ := repo.ListAccessEntries(ctx)
+	if len(entries) != 1 {
+		t.Errorf("Expected 1 entry, got %d", len(entries))
+	}
+
+	repo.DeleteAccessEntry(ctx, "office")
+	if _, exists, _ := repo.GetAccessEntry(ctx, "office"); exists {
+		t.Error("Entry should not exist after delete")
+	}
+}
+
+func TestPenalties(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	ctx := context.Background()
+
+	repo.SavePenalty(ctx, &domain.Penalty{ClientID: "noisy", Offenses: 2}, time.Minute)
+
+	penalty, exists, err := repo.GetPenalty(ctx, "noisy")
+	if err != nil || !exists || penalty.Offenses != 2 {
+		t.Fatalf("Expected penalty, got %+v, err %v", penalty, err)
+	}
+
+	repo.SavePenalty(ctx, &domain.Penalty{ClientID: "expired"}, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, exists, _ := repo.GetPenalty(ctx, "expired"); exists {
+		t.Error("Penalty should expire after its TTL")
+	}
+
+	repo.DeletePenalty(ctx, "noisy")
+	if _, exists, _ := repo.GetPenalty(ctx, "noisy"); exists {
+		t.Error("Penalty should not exist after delete")
+	}
+}
+
+func TestLeases(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	ctx := context.Background()
+
+	first := &domain.ConcurrencyLease{Key: "client|concurrency", ID: "a", Limit: 1, TTL: time.Minute}
+	if acquired, _ := repo.AcquireLease(ctx, first); !acquired || first.InFlight != 1 {
+		t.Fatalf("Expected first lease, got %+v", first)
+	}
+
+	second := &domain.ConcurrencyLease{Key: "client|concurrency", ID: "b", Limit: 1, TTL: time.Minute}
+	if acquired, _ := repo.AcquireLease(ctx, second); acquired {
+		t.Fatal("Second lease should be rejected")
+	}
+
+	repo.ReleaseLease(ctx, first)
+	if acquired, _ := repo.AcquireLease(ctx, second); !acquired {
+		t.Error("Lease should be available after release")
+	}
+
+	expired := &domain.ConcurrencyLease{Key: "crashed|concurrency", ID: "c", Limit: 1, TTL: time.Millisecond}
+	repo.AcquireLease(ctx, expired)
+	time.Sleep(5 * time.Millisecond)
+
+	next := &domain.ConcurrencyLease{Key: "crashed|concurrency", ID: "d", Limit: 1, TTL: time.Minute}
+	if acquired, _ := repo.AcquireLease(ctx, next); !acquired {
+		t.Error("Expired lease should not hold a slot")
+	}
+}
+
+func TestSizes(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1).(*memoryRateLimiterRepository)
+	ctx := context.Background()
+
+	repo.Save(ctx, &domain.RateLimit{ClientID: "a", MaxRequests: 1, CycleDuration: 1, CycleStart: time.Now()})
+	repo.Save(ctx, &domain.RateLimit{ClientID: "b", MaxRequests: 1, CycleDuration: 1, CycleStart: time.Now()})
+	repo.SaveTier(ctx, &domain.Tier{Name: "pro", MaxRequests: 10, CycleDuration: 1})
+
+	sizes := repo.Sizes()
+	if sizes["rate_limits"] != 2 || sizes["tiers"] != 1 || sizes["rules"] != 0 {
+		t.Errorf("Unexpected sizes: %v", sizes)
+	}
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/repository"
+)
+
+type snapshot struct {
+	RateLimits []*domain.RateLimit   `json:"rate_limits"`
+	Tiers      []*domain.Tier        `json:"tiers"`
+	Rules      []*domain.RouteRule   `json:"rules"`
+	Access     []*domain.AccessEntry `json:"access"`
+	Penalties  []*penaltyEntry       `json:"penalties"`
+}
+
+func (r *memoryRateLimiterRepository) Snapshot(w io.Writer) error {
+	r.mu.RLock()
+	r.configMu.RLock()
+
+	var snap snapshot
+	for _, rateLimit := range r.store {
+		copy := *rateLimit
+		snap.RateLimits = append(snap.RateLimits, &copy)
+	}
+	for _, tier := range r.tiers {
+		copy := *tier
+		snap.Tiers = append(snap.Tiers, &copy)
+	}
+	for _, rule := range r.rules {
+		copy := *rule
+		snap.Rules = append(snap.Rules, &copy)
+	}
+	for _, entry := range r.access {
+		copy := *entry
+		snap.Access = append(snap.Access, &copy)
+	}
+	for _, entry := range r.penalties {
+		penalty := *entry.Penalty
+		snap.Penalties = append(snap.Penalties, &penaltyEntry{Penalty: &penalty, ExpiresAt: entry.ExpiresAt})
+	}
+
+	r.configMu.RUnlock()
+	r.mu.RUnlock()
+
+	if err := json.NewEncoder(w).Encode(snap); err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	return nil
+}
+
+func (r *memoryRateLimiterRepository) Restore(rd io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(rd).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to unmarshal: %w", err)
+	}
+
+	r.mu.Lock()
+	r.configMu.Lock()
+	defer r.mu.Unlock()
+	defer r.configMu.Unlock()
+
+	for _, rateLimit := range snap.RateLimits {
+		r.store[rateLimit.ClientID] = rateLimit
+	}
+	for _, tier := range snap.Tiers {
+		r.tiers[tier.Name] = tier
+	}
+	for _, rule := range snap.Rules {
+		r.rules[rule.ID] = rule
+	}
+	for _, entry := range snap.Access {
+		r.access[entry.ID] = entry
+	}
+	for _, entry := range snap.Penalties {
+		r.penalties[entry.Penalty.ClientID] = entry
+	}
+
+	slog.Debug("memory snapshot restored", "rate_limits", len(snap.RateLimits), "tiers", len(snap.Tiers), "rules", len(snap.Rules))
+	return nil
+}
+
+func SaveSnapshotFile(s repository.Snapshotter, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.Snapshot(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir makes the rename of a snapshot durable.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot directory: %w", err)
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("failed to sync snapshot directory: %w", err)
+	}
+
+	return nil
+}
+
+func LoadSnapshotFile(s repository.Snapshotter, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	return s.Restore(f)
+}
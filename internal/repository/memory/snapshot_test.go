@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	repo := NewRateLimiterMemoryRepository(100, 1)
+	repo.Save(ctx, &domain.RateLimit{
+		ClientID:     "billing-client",
+		Custom:       true,
+		RequestCount: 42,
+		MaxRequests:  100000,
+		Window:       domain.WindowMonth,
+		CycleStart:   time.Now(),
+	})
+	repo.SaveTier(ctx, &domain.Tier{Name: "pro", MaxRequests: 1000, CycleDuration: 1})
+
+	if err := SaveSnapshotFile(repo.(repository.Snapshotter), path); err != nil {
+		t.Fatalf("SaveSnapshotFile failed: %v", err)
+	}
+
+	restored := NewRateLimiterMemoryRepository(100, 1)
+	if err := LoadSnapshotFile(restored.(repository.Snapshotter), path); err != nil {
+		t.Fatalf("LoadSnapshotFile failed: %v", err)
+	}
+
+	got, exists, _ := restored.Get(ctx, "billing-client")
+	if !exists || got.RequestCount != 42 || got.Window != domain.WindowMonth {
+		t.Errorf("Rate limit not restored, got %+v", got)
+	}
+
+	if _, exists, _ := restored.GetTier(ctx, "pro"); !exists {
+		t.Error("Tier not restored")
+	}
+}
+
+func TestLoadSnapshotFileMissing(t *testing.T) {
+	repo := NewRateLimiterMemoryRepository(100, 1)
+
+	err := LoadSnapshotFile(repo.(repository.Snapshotter), filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Errorf("Expected no error for missing snapshot, got %v", err)
+	}
+}
@@ -3,14 +3,29 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"rate-limiter-go/internal/domain"
 	"rate-limiter-go/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	maxTxRetries  = 10
+	tierScanCount = 1000
+	tiersKey      = "rate_limit_tiers"
+	rulesKey      = "rate_limit_rules"
+	accessKey     = "rate_limit_access"
+)
+
+var globReplacer = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
 type redisRateLimiterRepository struct {
 	client               *redis.Client
 	defaultMaxRequests   int
@@ -52,8 +67,7 @@ func (r *redisRateLimiterRepository) Save(ctx context.Context, rateLimit *domain
 		return fmt.Errorf("failed to marshal: %w", err)
 	}
 
-	expiration := time.Duration(rateLimit.CycleDuration*2) * time.Minute
-	if err := r.client.Set(ctx, key, data, expiration).Err(); err != nil {
+	if err := r.client.Set(ctx, key, data, expiration(rateLimit)).Err(); err != nil {
 		return fmt.Errorf("failed save to redis: %w", err)
 	}
 
@@ -71,11 +85,568 @@ func (r *redisRateLimiterRepository) Delete(ctx context.Context, clientID string
 }
 
 func (r *redisRateLimiterRepository) CreateDefault(ctx context.Context, clientID string) *domain.RateLimit {
-	return &domain.RateLimit{
+	rateLimit := &domain.RateLimit{
 		ClientID:      clientID,
 		RequestCount:  0,
 		CycleStart:    time.Now(),
 		CycleDuration: r.defaultCycleDuration,
 		MaxRequests:   r.defaultMaxRequests,
 	}
+
+	if tier, exists, _ := r.GetTier(ctx, domain.DefaultTier); exists {
+		rateLimit.MaxRequests = tier.MaxRequests
+		rateLimit.CycleDuration = tier.CycleDuration
+		rateLimit.Window = tier.Window
+		rateLimit.TimeZone = tier.TimeZone
+	}
+
+	return rateLimit
+}
+
+// ConsumeBatch evaluates the batch in a single script, so it is atomic
+// without retries. Calendar period boundaries depend on time zones the script
+// cannot resolve and are computed here from each request's limits.
+func (r *redisRateLimiterRepository) ConsumeBatch(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeResult, bool, error) {
+	requests, err := r.withStoredLimits(ctx, requests)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var keys []string
+	index := make(map[string]int)
+
+	now := time.Now()
+	args := []interface{}{now.UnixMilli()}
+
+	for _, req := range requests {
+		if _, ok := index[req.ClientID]; !ok {
+			index[req.ClientID] = len(keys)
+			keys = append(keys, fmt.Sprintf("rate_limit:%s", req.ClientID))
+		}
+
+		limits := &domain.RateLimit{
+			CycleDuration: req.CycleDuration,
+			Window:        req.Window,
+			TimeZone:      req.TimeZone,
+		}
+
+		var periodStart, previousStart int64
+		if domain.IsCalendarWindow(req.Window) {
+			loc, err := domain.LoadTimeZone(req.TimeZone)
+			if err != nil {
+				loc = time.UTC
+			}
+			start := domain.PeriodStart(req.Window, now, loc)
+			periodStart = start.UnixMilli()
+			previousStart = domain.PeriodStart(req.Window, start.Add(-time.Nanosecond), loc).UnixMilli()
+		}
+
+		args = append(args,
+			index[req.ClientID]+1,
+			req.Cost,
+			req.MaxRequests,
+			req.CycleDuration,
+			req.Window,
+			req.TimeZone,
+			periodStart,
+			previousStart,
+			(time.Duration(req.CycleDuration) * time.Minute).Milliseconds(),
+			limits.Retention().Milliseconds(),
+		)
+	}
+
+	reply, err := consumeBatchScript.Run(ctx, r.client, keys, args...).Slice()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to consume batch: %w", err)
+	}
+	if len(reply) != 3 {
+		return nil, false, fmt.Errorf("failed to consume batch: unexpected reply %v", reply)
+	}
+
+	flags, _ := reply[1].([]interface{})
+	records, _ := reply[2].([]interface{})
+	if len(flags) != len(requests) || len(records) != len(keys) {
+		return nil, false, fmt.Errorf("failed to consume batch: unexpected reply %v", reply)
+	}
+
+	loaded := make([]*domain.RateLimit, len(keys))
+	for i, record := range records {
+		data, _ := record.(string)
+		loaded[i] = &domain.RateLimit{}
+		if err := json.Unmarshal([]byte(data), loaded[i]); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal: %w", err)
+		}
+	}
+
+	results := make([]domain.ConsumeResult, len(requests))
+	for i, req := range requests {
+		allowed, _ := flags[i].(int64)
+		results[i] = domain.ConsumeResult{
+			ClientID:  req.ClientID,
+			Allowed:   allowed == 1,
+			Policy:    req.Policy,
+			RateLimit: loaded[index[req.ClientID]],
+		}
+	}
+
+	allowed, _ := reply[0].(int64)
+	return results, allowed == 1, nil
+}
+
+// withStoredLimits fills in the limits of requests that do not carry their
+// own from the stored record or the defaults. Only the counters are updated
+// atomically; a concurrent change of those limits may be missed.
+func (r *redisRateLimiterRepository) withStoredLimits(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeRequest, error) {
+	resolved := make([]domain.ConsumeRequest, len(requests))
+	copy(resolved, requests)
+
+	for i, req := range resolved {
+		if req.MaxRequests > 0 {
+			continue
+		}
+
+		rateLimit, exists, err := r.Get(ctx, req.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			rateLimit = r.CreateDefault(ctx, req.ClientID)
+		}
+
+		resolved[i].MaxRequests = rateLimit.MaxRequests
+		resolved[i].CycleDuration = rateLimit.CycleDuration
+		resolved[i].Window = rateLimit.Window
+		resolved[i].TimeZone = rateLimit.TimeZone
+	}
+
+	return resolved, nil
+}
+
+// ListRateLimits walks the keyspace with SCAN, so the cursor is the SCAN
+// cursor and a page may hold slightly more or fewer than limit clients.
+func (r *redisRateLimiterRepository) ListRateLimits(ctx context.Context, filter domain.ClientFilter, cursor string, limit int) ([]*domain.RateLimit, string, error) {
+	var scanCursor uint64
+	if cursor != "" {
+		parsed, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %q", domain.ErrInvalidCursor, cursor)
+		}
+		scanCursor = parsed
+	}
+
+	match := "rate_limit:" + escapeGlob(filter.Prefix) + "*"
+	var rateLimits []*domain.RateLimit
+
+	for {
+		keys, next, err := r.client.Scan(ctx, scanCursor, match, int64(limit)).Result()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan redis: %w", err)
+		}
+		scanCursor = next
+
+		if len(keys) > 0 {
+			values, err := r.client.MGet(ctx, keys...).Result()
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to get redis: %w", err)
+			}
+
+			for _, value := range values {
+				data, ok := value.(string)
+				if !ok {
+					continue
+				}
+
+				var rateLimit domain.RateLimit
+				if err := json.Unmarshal([]byte(data), &rateLimit); err != nil {
+					return nil, "", fmt.Errorf("failed to unmarshal: %w", err)
+				}
+				if filter.Matches(&rateLimit) {
+					rateLimits = append(rateLimits, &rateLimit)
+				}
+			}
+		}
+
+		if scanCursor == 0 {
+			return rateLimits, "", nil
+		}
+		if len(rateLimits) >= limit {
+			return rateLimits, strconv.FormatUint(scanCursor, 10), nil
+		}
+	}
+}
+
+// HasTierClients scans the whole keyspace in large batches and stops at the
+// first client assigned to tier.
+func (r *redisRateLimiterRepository) HasTierClients(ctx context.Context, tier string) (bool, error) {
+	filter := domain.ClientFilter{Tier: tier}
+
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, "rate_limit:*", tierScanCount).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to scan redis: %w", err)
+		}
+		cursor = next
+
+		if len(keys) > 0 {
+			values, err := r.client.MGet(ctx, keys...).Result()
+			if err != nil {
+				return false, fmt.Errorf("failed to get redis: %w", err)
+			}
+
+			for _, value := range values {
+				data, ok := value.(string)
+				if !ok {
+					continue
+				}
+
+				var rateLimit domain.RateLimit
+				if err := json.Unmarshal([]byte(data), &rateLimit); err != nil {
+					return false, fmt.Errorf("failed to unmarshal: %w", err)
+				}
+				if filter.Matches(&rateLimit) {
+					return true, nil
+				}
+			}
+		}
+
+		if cursor == 0 {
+			return false, nil
+		}
+	}
+}
+
+func (r *redisRateLimiterRepository) GetTier(ctx context.Context, name string) (*domain.Tier, bool, error) {
+	data, err := r.client.HGet(ctx, tiersKey, name).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get tier from redis: %w", err)
+	}
+
+	var tier domain.Tier
+	if err := json.Unmarshal([]byte(data), &tier); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+
+	return &tier, true, nil
+}
+
+func (r *redisRateLimiterRepository) SaveTier(ctx context.Context, tier *domain.Tier) error {
+	data, err := json.Marshal(tier)
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	if err := r.client.HSet(ctx, tiersKey, tier.Name, data).Err(); err != nil {
+		return fmt.Errorf("failed save tier to redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRateLimiterRepository) DeleteTier(ctx context.Context, name string) error {
+	if err := r.client.HDel(ctx, tiersKey, name).Err(); err != nil {
+		return fmt.Errorf("failed to delete tier from redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRateLimiterRepository) ListTiers(ctx context.Context) ([]*domain.Tier, error) {
+	values, err := r.client.HGetAll(ctx, tiersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tiers from redis: %w", err)
+	}
+
+	tiers := make([]*domain.Tier, 0, len(values))
+	for _, data := range values {
+		var tier domain.Tier
+		if err := json.Unmarshal([]byte(data), &tier); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal: %w", err)
+		}
+		tiers = append(tiers, &tier)
+	}
+
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Name < tiers[j].Name })
+	return tiers, nil
+}
+
+func (r *redisRateLimiterRepository) GetRule(ctx context.Context, id string) (*domain.RouteRule, bool, error) {
+	data, err := r.client.HGet(ctx, rulesKey, id).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rule from redis: %w", err)
+	}
+
+	var rule domain.RouteRule
+	if err := json.Unmarshal([]byte(data), &rule); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+
+	return &rule, true, nil
+}
+
+func (r *redisRateLimiterRepository) SaveRule(ctx context.Context, rule *domain.RouteRule) error {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	if err := r.client.HSet(ctx, rulesKey, rule.ID, data).Err(); err != nil {
+		return fmt.Errorf("failed save rule to redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRateLimiterRepository) DeleteRule(ctx context.Context, id string) error {
+	if err := r.client.HDel(ctx, rulesKey, id).Err(); err != nil {
+		return fmt.Errorf("failed to delete rule from redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRateLimiterRepository) ListRules(ctx context.Context) ([]*domain.RouteRule, error) {
+	values, err := r.client.HGetAll(ctx, rulesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules from redis: %w", err)
+	}
+
+	rules := make([]*domain.RouteRule, 0, len(values))
+	for _, data := range values {
+		var rule domain.RouteRule
+		if err := json.Unmarshal([]byte(data), &rule); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal: %w", err)
+		}
+		rules = append(rules, &rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+func (r *redisRateLimiterRepository) GetAccessEntry(ctx context.Context, id string) (*domain.AccessEntry, bool, error) {
+	data, err := r.client.HGet(ctx, accessKey, id).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get access entry from redis: %w", err)
+	}
+
+	var entry domain.AccessEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+	if entry.Expired(time.Now()) {
+		return nil, false, nil
+	}
+
+	return &entry, true, nil
+}
+
+func (r *redisRateLimiterRepository) SaveAccessEntry(ctx context.Context, entry *domain.AccessEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	if err := r.client.HSet(ctx, accessKey, entry.ID, data).Err(); err != nil {
+		return fmt.Errorf("failed save access entry to redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRateLimiterRepository) DeleteAccessEntry(ctx context.Context, id string) error {
+	if err := r.client.HDel(ctx, accessKey, id).Err(); err != nil {
+		return fmt.Errorf("failed to delete access entry from redis: %w", err)
+	}
+
+	return nil
+}
+
+// ListAccessEntries removes expired entries from the hash as it goes, since
+// hash fields cannot carry their own TTL.
+func (r *redisRateLimiterRepository) ListAccessEntries(ctx context.Context) ([]*domain.AccessEntry, error) {
+	values, err := r.client.HGetAll(ctx, accessKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access entries from redis: %w", err)
+	}
+
+	now := time.Now()
+	var expired []string
+
+	entries := make([]*domain.AccessEntry, 0, len(values))
+	for id, data := range values {
+		var entry domain.AccessEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal: %w", err)
+		}
+		if entry.Expired(now) {
+			expired = append(expired, id)
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+
+	if len(expired) > 0 {
+		if err := r.client.HDel(ctx, accessKey, expired...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to delete expired access entries from redis: %w", err)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+func (r *redisRateLimiterRepository) GetPenalty(ctx context.Context, clientID string) (*domain.Penalty, bool, error) {
+	key := fmt.Sprintf("rate_limit_penalty:%s", clientID)
+
+	data, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get penalty from redis: %w", err)
+	}
+
+	var penalty domain.Penalty
+	if err := json.Unmarshal([]byte(data), &penalty); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+
+	return &penalty, true, nil
+}
+
+func (r *redisRateLimiterRepository) SavePenalty(ctx context.Context, penalty *domain.Penalty, ttl time.Duration) error {
+	key := fmt.Sprintf("rate_limit_penalty:%s", penalty.ClientID)
+
+	if ttl <= 0 {
+		return r.DeletePenalty(ctx, penalty.ClientID)
+	}
+
+	data, err := json.Marshal(penalty)
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed save penalty to redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRateLimiterRepository) DeletePenalty(ctx context.Context, clientID string) error {
+	key := fmt.Sprintf("rate_limit_penalty:%s", clientID)
+
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete penalty from redis: %w", err)
+	}
+
+	return nil
+}
+
+// AcquireLease keeps the client's leases in a sorted set scored by expiry, so
+// leases left behind by a crashed replica stop counting once they expire.
+func (r *redisRateLimiterRepository) AcquireLease(ctx context.Context, lease *domain.ConcurrencyLease) (bool, error) {
+	key := fmt.Sprintf("rate_limit_concurrency:%s", lease.Key)
+
+	var acquired bool
+
+	txf := func(tx *redis.Tx) error {
+		now := time.Now()
+
+		inFlight, err := tx.ZCount(ctx, key, fmt.Sprintf("(%d", now.UnixMilli()), "+inf").Result()
+		if err != nil {
+			return fmt.Errorf("failed to count leases: %w", err)
+		}
+
+		lease.InFlight = int(inFlight)
+		acquired = lease.InFlight < lease.Limit
+		if !acquired {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+			pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.Add(lease.TTL).UnixMilli()), Member: lease.ID})
+			pipe.PExpire(ctx, key, lease.TTL)
+			return nil
+		})
+		if err == nil {
+			lease.InFlight++
+		}
+
+		return err
+	}
+
+	for attempt := 0; attempt < maxTxRetries; attempt++ {
+		err := r.client.Watch(ctx, txf, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			slog.DebugContext(ctx, "redis transaction conflict, retrying", "operation", "acquire_lease", "attempt", attempt+1)
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to acquire lease: %w", err)
+		}
+
+		return acquired, nil
+	}
+
+	return false, fmt.Errorf("failed to acquire lease: too much contention")
+}
+
+func (r *redisRateLimiterRepository) RenewLease(ctx context.Context, lease *domain.ConcurrencyLease) error {
+	key := fmt.Sprintf("rate_limit_concurrency:%s", lease.Key)
+	expiresAt := time.Now().Add(lease.TTL)
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAddXX(ctx, key, redis.Z{Score: float64(expiresAt.UnixMilli()), Member: lease.ID})
+		pipe.PExpire(ctx, key, lease.TTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to renew lease: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisRateLimiterRepository) ReleaseLease(ctx context.Context, lease *domain.ConcurrencyLease) error {
+	key := fmt.Sprintf("rate_limit_concurrency:%s", lease.Key)
+
+	if err := r.client.ZRem(ctx, key, lease.ID).Err(); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+
+	return nil
+}
+
+func escapeGlob(pattern string) string {
+	return globReplacer.Replace(pattern)
+}
+
+func expiration(rateLimit *domain.RateLimit) time.Duration {
+	if rateLimit.HasConfig() {
+		return 0
+	}
+	return rateLimit.Retention()
+}
+
+func (r *redisRateLimiterRepository) Close() error {
+	return r.client.Close()
+}
+
+func (r *redisRateLimiterRepository) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
 }
@@ -2,12 +2,20 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"rate-limiter-go/internal/domain"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func setupTestRedis(t *testing.T) (*redis.Client, func()) {
@@ -147,6 +155,13 @@ func TestRedisCreateDefault(t *testing.T) {
 	if defaultRL.CycleDuration != 5 {
 		t.Error("Wrong cycle duration")
 	}
+
+	repo.SaveTier(ctx, &domain.Tier{Name: domain.DefaultTier, MaxRequests: 2, Window: domain.WindowMonth, TimeZone: "Asia/Jakarta"})
+
+	defaultRL = repo.CreateDefault(ctx, "new-client")
+	if defaultRL.MaxRequests != 2 || defaultRL.Window != domain.WindowMonth || defaultRL.TimeZone != "Asia/Jakarta" {
+		t.Errorf("Expected the calendar window of the default tier, got %+v", defaultRL)
+	}
 }
 
 func TestRedisExpiration(t *testing.T) {
@@ -178,4 +193,415 @@ func TestRedisExpiration(t *testing.T) {
 	if ttl.Seconds() < 60 || ttl.Seconds() > 180 {
 		t.Errorf("Unexpected TTL: %v", ttl)
 	}
+
+	_, _, err = repo.ConsumeBatch(ctx, []domain.ConsumeRequest{
+		{ClientID: "monthly", Cost: 1, MaxRequests: 10, Window: domain.WindowMonth},
+	})
+	if err != nil {
+		t.Fatal("ConsumeBatch failed:", err)
+	}
+
+	ttl, _ = client.TTL(ctx, "rate_limit:monthly").Result()
+	if ttl <= 0 {
+		t.Errorf("Expected a record inheriting a calendar window to expire, got TTL %v", ttl)
+	}
+}
+
+func TestRedisConsumeBatch(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	repo.Save(ctx, &domain.RateLimit{
+		ClientID:      "org",
+		RequestCount:  4,
+		MaxRequests:   5,
+		CycleDuration: 1,
+		CycleStart:    time.Now(),
+	})
+
+	requests := []domain.ConsumeRequest{{ClientID: "user", Cost: 1}, {ClientID: "org", Cost: 1}}
+
+	_, allowed, err := repo.ConsumeBatch(ctx, requests)
+	if err != nil {
+		t.Fatalf("ConsumeBatch failed: %v", err)
+	}
+	if !allowed {
+		t.Fatal("Expected first batch to be allowed")
+	}
+
+	results, allowed, err := repo.ConsumeBatch(ctx, requests)
+	if err != nil {
+		t.Fatalf("ConsumeBatch failed: %v", err)
+	}
+	if allowed {
+		t.Fatal("Expected second batch to be blocked")
+	}
+	if !results[0].Allowed || results[1].Allowed {
+		t.Error("Expected only org to be reported as blocked")
+	}
+
+	got, _, _ := repo.Get(ctx, "user")
+	if got.RequestCount != 1 {
+		t.Errorf("Expected user count 1 after blocked batch, got %d", got.RequestCount)
+	}
+}
+
+func TestRedisConsumeBatchRollsWindows(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now()
+	current := domain.PeriodStart(domain.WindowDay, now, loc)
+	previous := domain.PeriodStart(domain.WindowDay, current.Add(-time.Nanosecond), loc)
+
+	repo.Save(ctx, &domain.RateLimit{ClientID: "daily", RequestCount: 7, MaxRequests: 10, Custom: true, CycleStart: previous, Window: domain.WindowDay, TimeZone: "Asia/Jakarta"})
+	repo.Save(ctx, &domain.RateLimit{ClientID: "rolling", RequestCount: 3, MaxRequests: 5, CycleDuration: 1, CycleStart: now.Add(-90 * time.Second)})
+
+	results, allowed, err := repo.ConsumeBatch(ctx, []domain.ConsumeRequest{
+		{ClientID: "daily", Cost: 2, MaxRequests: 10, Window: domain.WindowDay, TimeZone: "Asia/Jakarta"},
+		{ClientID: "rolling", Cost: 1, MaxRequests: 5, CycleDuration: 1},
+	})
+	if err != nil || !allowed {
+		t.Fatalf("Expected batch to be allowed, got %v %v", allowed, err)
+	}
+
+	daily := results[0].RateLimit
+	if daily.RequestCount != 2 || daily.PreviousCount != 7 {
+		t.Errorf("Expected daily counts 2/7, got %d/%d", daily.RequestCount, daily.PreviousCount)
+	}
+	if !daily.CycleStart.Equal(current) || !daily.PreviousStart.Equal(previous) {
+		t.Errorf("Expected periods %v/%v, got %v/%v", current, previous, daily.CycleStart, daily.PreviousStart)
+	}
+
+	rolling := results[1].RateLimit
+	if rolling.RequestCount != 1 || rolling.PreviousCount != 3 {
+		t.Errorf("Expected rolling counts 1/3, got %d/%d", rolling.RequestCount, rolling.PreviousCount)
+	}
+	if rolling.CycleStart.Before(now.Add(-time.Second)) {
+		t.Errorf("Expected rolling window to restart, got %v", rolling.CycleStart)
+	}
+
+	if ttl := client.TTL(ctx, "rate_limit:rolling").Val(); ttl <= 0 || ttl > 2*time.Minute {
+		t.Errorf("Expected unconfigured record to expire within 2m, got %v", ttl)
+	}
+	if ttl := client.TTL(ctx, "rate_limit:daily").Val(); ttl != -1 {
+		t.Errorf("Expected configured record to be kept, got TTL %v", ttl)
+	}
+}
+
+func TestRedisConsumeBatchConcurrent(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := repo.ConsumeBatch(ctx, []domain.ConsumeRequest{
+				{ClientID: "user", Cost: 1, MaxRequests: 1000, CycleDuration: 1},
+				{ClientID: "org", Cost: 1, MaxRequests: 1000, CycleDuration: 1},
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no contention errors, got %v", err)
+		}
+	}
+
+	for _, clientID := range []string{"user", "org"} {
+		got, _, _ := repo.Get(ctx, clientID)
+		if got.RequestCount != 50 {
+			t.Errorf("Expected %s count 50, got %d", clientID, got.RequestCount)
+		}
+	}
+}
+
+func TestRedisListRateLimits(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	for _, id := range []string{"user-c", "user-a", "user-b", "admin", "user-a|rule:search", "user-*"} {
+		repo.Save(ctx, &domain.RateLimit{ClientID: id, MaxRequests: 10, CycleDuration: 1})
+	}
+	repo.Save(ctx, &domain.RateLimit{ClientID: "user-d", Tier: "pro", MaxRequests: 10, CycleDuration: 1})
+	repo.SaveTier(ctx, &domain.Tier{Name: "pro", MaxRequests: 1000, CycleDuration: 1})
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		page, next, err := repo.ListRateLimits(ctx, domain.ClientFilter{Prefix: "user-"}, cursor, 2)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		for _, rateLimit := range page {
+			seen[rateLimit.ClientID] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(seen) != 5 || !seen["user-a"] || !seen["user-d"] || !seen["user-*"] || seen["admin"] || seen["user-a|rule:search"] {
+		t.Errorf("Expected the five user clients without counters, got %v", seen)
+	}
+
+	page, _, _ := repo.ListRateLimits(ctx, domain.ClientFilter{Prefix: "user-*"}, "", 10)
+	if len(page) != 1 || page[0].ClientID != "user-*" {
+		t.Errorf("Expected the prefix to match literally, got %v", page)
+	}
+
+	page, _, _ = repo.ListRateLimits(ctx, domain.ClientFilter{Tier: "pro"}, "", 10)
+	if len(page) != 1 || page[0].ClientID != "user-d" {
+		t.Errorf("Expected only the pro client, got %v", page)
+	}
+
+	if _, _, err := repo.ListRateLimits(ctx, domain.ClientFilter{}, "not-a-cursor", 10); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestRedisHasTierClients(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	for i := 0; i < 2500; i++ {
+		repo.Save(ctx, &domain.RateLimit{ClientID: fmt.Sprintf("user-%d", i), MaxRequests: 10, CycleDuration: 1})
+	}
+	repo.Save(ctx, &domain.RateLimit{ClientID: "user-1|rule:search", Tier: "gold", MaxRequests: 10, CycleDuration: 1})
+
+	if found, err := repo.HasTierClients(ctx, "pro"); err != nil || found {
+		t.Fatalf("Expected no pro clients, got %v, %v", found, err)
+	}
+	if found, _ := repo.HasTierClients(ctx, "gold"); found {
+		t.Error("Expected derived counters not to count as clients")
+	}
+
+	repo.Save(ctx, &domain.RateLimit{ClientID: "user-2499", Tier: "pro"})
+	if found, err := repo.HasTierClients(ctx, "pro"); err != nil || !found {
+		t.Errorf("Expected the pro client to be found, got %v, %v", found, err)
+	}
+}
+
+func TestRedisTiers(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 50, 5)
+	ctx := context.Background()
+
+	repo.SaveTier(ctx, &domain.Tier{Name: "pro", MaxRequests: 1000, CycleDuration: 1})
+	repo.SaveTier(ctx, &domain.Tier{Name: domain.DefaultTier, MaxRequests: 20, CycleDuration: 2})
+
+	tier, exists, err := repo.GetTier(ctx, "pro")
+	if err != nil || !exists {
+		t.Fatalf("Expected tier to exist, err %v", err)
+	}
+	if tier.MaxRequests != 1000 {
+		t.Errorf("Expected max requests 1000, got %d", tier.MaxRequests)
+	}
+
+	tiers, _ := repo.ListTiers(ctx)
+	if len(tiers) != 2 {
+		t.Errorf("Expected 2 tiers, got %d", len(tiers))
+	}
+
+	defaultRL := repo.CreateDefault(ctx, "new-client")
+	if defaultRL.MaxRequests != 20 || defaultRL.CycleDuration != 2 {
+		t.Errorf("Expected default tier limits, got %d/%d", defaultRL.MaxRequests, defaultRL.CycleDuration)
+	}
+
+	repo.DeleteTier(ctx, "pro")
+	if _, exists, _ := repo.GetTier(ctx, "pro"); exists {
+		t.Error("Tier should not exist after delete")
+	}
+}
+
+func TestRedisRules(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	repo.SaveRule(ctx, &domain.RouteRule{ID: "search", Method: "GET", Pattern: "/search", MaxRequests: 100, CycleDuration: 1})
+
+	rule, exists, err := repo.GetRule(ctx, "search")
+	if err != nil || !exists {
+		t.Fatalf("Expected rule to exist, err %v", err)
+	}
+	if rule.Pattern != "/search" {
+		t.Errorf("Wrong pattern %s", rule.Pattern)
+	}
+
+	rules, _ := repo.ListRules(ctx)
+	if len(rules) != 1 {
+		t.Errorf("Expected 1 rule, got %d", len(rules))
+	}
+
+	repo.DeleteRule(ctx, "search")
+	if _, exists, _ := repo.GetRule(ctx, "search"); exists {
+		t.Error("Rule should not exist after delete")
+	}
+}
+
+func TestRedisAccessEntries(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	repo.SaveAccessEntry(ctx, &domain.AccessEntry{ID: "office", List: domain.AccessAllow, Pattern: "10.0.0.0/8"})
+	repo.SaveAccessEntry(ctx, &domain.AccessEntry{ID: "expired", List: domain.AccessDeny, Pattern: "abuser", ExpiresAt: time.Now().Add(-time.Second)})
+
+	entry, exists, err := repo.GetAccessEntry(ctx, "office")
+	if err != nil || !exists {
+		t.Fatalf("Expected entry to exist, err %v", err)
+	}
+	if entry.Pattern != "10.0.0.0/8" {
+		t.Errorf("Wrong pattern %s", entry.Pattern)
+	}
+
+	entries, _ := repo.ListAccessEntries(ctx)
+	if len(entries) != 1 {
+		t.Errorf("Expected 1 entry, got %d", len(entries))
+	}
+	if client.HExists(ctx, accessKey, "expired").Val() {
+		t.Error("Expired entry should be removed from the hash")
+	}
+
+	repo.DeleteAccessEntry(ctx, "office")
+	if _, exists, _ := repo.GetAccessEntry(ctx, "office"); exists {
+		t.Error("Entry should not exist after delete")
+	}
+}
+
+func TestRedisPenalties(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	repo.SavePenalty(ctx, &domain.Penalty{ClientID: "noisy", Offenses: 2}, time.Minute)
+
+	penalty, exists, err := repo.GetPenalty(ctx, "noisy")
+	if err != nil || !exists || penalty.Offenses != 2 {
+		t.Fatalf("Expected penalty, got %+v, err %v", penalty, err)
+	}
+
+	if ttl := client.TTL(ctx, "rate_limit_penalty:noisy").Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected TTL up to a minute, got %v", ttl)
+	}
+
+	repo.DeletePenalty(ctx, "noisy")
+	if _, exists, _ := repo.GetPenalty(ctx, "noisy"); exists {
+		t.Error("Penalty should not exist after delete")
+	}
+}
+
+func TestRedisLeases(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	first := &domain.ConcurrencyLease{Key: "client|concurrency", ID: "a", Limit: 1, TTL: time.Minute}
+	if acquired, err := repo.AcquireLease(ctx, first); err != nil || !acquired || first.InFlight != 1 {
+		t.Fatalf("Expected first lease, got %+v, err %v", first, err)
+	}
+
+	second := &domain.ConcurrencyLease{Key: "client|concurrency", ID: "b", Limit: 1, TTL: time.Minute}
+	if acquired, _ := repo.AcquireLease(ctx, second); acquired {
+		t.Fatal("Second lease should be rejected")
+	}
+
+	if err := repo.RenewLease(ctx, first); err != nil {
+		t.Fatalf("RenewLease failed: %v", err)
+	}
+
+	repo.ReleaseLease(ctx, first)
+	if acquired, _ := repo.AcquireLease(ctx, second); !acquired {
+		t.Error("Lease should be available after release")
+	}
+
+	client.ZAdd(ctx, "rate_limit_concurrency:crashed|concurrency", redis.Z{Score: float64(time.Now().Add(-time.Second).UnixMilli()), Member: "stale"})
+
+	next := &domain.ConcurrencyLease{Key: "crashed|concurrency", ID: "d", Limit: 1, TTL: time.Minute}
+	if acquired, _ := repo.AcquireLease(ctx, next); !acquired {
+		t.Error("Expired lease should not hold a slot")
+	}
+	if client.ZScore(ctx, "rate_limit_concurrency:crashed|concurrency", "stale").Err() != redis.Nil {
+		t.Error("Expired lease should be removed")
+	}
+}
+
+func TestRedisTracingHook(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	// miniredis does not know CLIENT SETINFO, which would fail the traced
+	// connection handshake.
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DisableIdentity: true})
+	defer client.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	client.AddHook(TracingHook{})
+
+	repo := NewRateLimiterRedisRepository(client, 100, 1)
+	ctx := context.Background()
+
+	if _, _, err := repo.Get(ctx, "traced"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, _, err := repo.ConsumeBatch(ctx, []domain.ConsumeRequest{{ClientID: "traced", Cost: 1, MaxRequests: 5, CycleDuration: 1}}); err != nil {
+		t.Fatalf("ConsumeBatch failed: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, span := range exporter.GetSpans() {
+		names[span.Name] = true
+		if span.SpanKind != trace.SpanKindClient {
+			t.Errorf("Expected client span for %s", span.Name)
+		}
+		if span.Status.Code == codes.Error {
+			t.Errorf("Expected %s to succeed, got %s", span.Name, span.Status.Description)
+		}
+	}
+
+	for _, want := range []string{"redis.get", "redis.evalsha", "redis.eval"} {
+		if !names[want] {
+			t.Errorf("Expected span %s, got %v", want, names)
+		}
+	}
 }
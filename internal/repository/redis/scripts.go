@@ -0,0 +1,159 @@
+package redis
+
+import "github.com/redis/go-redis/v9"
+
+// consumeBatchScript applies domain.EvaluateBatch to the stored records in
+// one step. ARGV[1] is the current time in milliseconds, followed by ten
+// values per request: key index, cost, max requests, cycle duration, window,
+// time zone, calendar period start and previous period start (0 for rolling
+// windows), rolling window length and retention of an unconfigured record.
+// It returns whether the batch was allowed, whether each request fit its
+// limit, and the updated record of every key.
+//
+// Times are kept in milliseconds; records rolled by the script store their
+// period starts in UTC.
+var consumeBatchScript = redis.NewScript(`
+local function days_from_civil(y, m, d)
+	if m <= 2 then y = y - 1 end
+	local era = math.floor(y / 400)
+	local yoe = y - era * 400
+	local mp = m > 2 and m - 3 or m + 9
+	local doy = math.floor((153 * mp + 2) / 5) + d - 1
+	local doe = yoe * 365 + math.floor(yoe / 4) - math.floor(yoe / 100) + doy
+	return era * 146097 + doe - 719468
+end
+
+local function civil_from_days(z)
+	z = z + 719468
+	local era = math.floor(z / 146097)
+	local doe = z - era * 146097
+	local yoe = math.floor((doe - math.floor(doe / 1460) + math.floor(doe / 36524) - math.floor(doe / 146096)) / 365)
+	local doy = doe - (365 * yoe + math.floor(yoe / 4) - math.floor(yoe / 100))
+	local mp = math.floor((5 * doy + 2) / 153)
+	local d = doy - math.floor((153 * mp + 2) / 5) + 1
+	local m = mp < 10 and mp + 3 or mp - 9
+	local y = yoe + era * 400
+	if m <= 2 then y = y + 1 end
+	return y, m, d
+end
+
+local function parse_time(s)
+	local y, mo, d, h, mi, sec, frac, zone = string.match(s, '^(%d+)-(%d+)-(%d+)T(%d+):(%d+):(%d+)(%.?%d*)(.*)$')
+	local ms = days_from_civil(tonumber(y), tonumber(mo), tonumber(d)) * 86400000
+		+ (tonumber(h) * 3600 + tonumber(mi) * 60 + tonumber(sec)) * 1000
+
+	if frac ~= '' then
+		local digits = string.sub(frac, 2, 4)
+		ms = ms + tonumber(digits .. string.rep('0', 3 - #digits))
+	end
+
+	if zone ~= 'Z' and zone ~= '' then
+		local sign, zh, zm = string.match(zone, '^([%+%-])(%d+):(%d+)$')
+		local offset = (tonumber(zh) * 60 + tonumber(zm)) * 60000
+		if sign == '+' then ms = ms - offset else ms = ms + offset end
+	end
+
+	return ms
+end
+
+local function format_time(ms)
+	local days = math.floor(ms / 86400000)
+	local rem = ms - days * 86400000
+	local y, m, d = civil_from_days(days)
+	local h = math.floor(rem / 3600000)
+	rem = rem - h * 3600000
+	local mi = math.floor(rem / 60000)
+	rem = rem - mi * 60000
+	local sec = math.floor(rem / 1000)
+	return string.format('%04d-%02d-%02dT%02d:%02d:%02d.%03dZ', y, m, d, h, mi, sec, rem - sec * 1000)
+end
+
+local now = tonumber(ARGV[1])
+local records, starts, retention = {}, {}, {}
+
+for k = 1, #KEYS do
+	local data = redis.call('GET', KEYS[k])
+	if data then
+		records[k] = cjson.decode(data)
+		starts[k] = parse_time(records[k].CycleStart)
+	else
+		records[k] = {
+			ClientID = string.sub(KEYS[k], string.len('rate_limit:') + 1),
+			RequestCount = 0,
+			PreviousCount = 0,
+			CycleStart = format_time(now),
+		}
+		starts[k] = now
+	end
+end
+
+local count = (#ARGV - 1) / 10
+local flags, allowed = {}, 1
+
+for i = 1, count do
+	local b = 1 + (i - 1) * 10
+	local k = tonumber(ARGV[b + 1])
+	local cost = tonumber(ARGV[b + 2])
+	local rec = records[k]
+
+	rec.MaxRequests = tonumber(ARGV[b + 3])
+	rec.CycleDuration = tonumber(ARGV[b + 4])
+	rec.Window = ARGV[b + 5]
+	rec.TimeZone = ARGV[b + 6]
+	retention[k] = ARGV[b + 10]
+
+	local period_start = tonumber(ARGV[b + 7])
+	if period_start ~= 0 then
+		if starts[k] ~= period_start then
+			local previous_start = tonumber(ARGV[b + 8])
+			rec.PreviousStart = format_time(previous_start)
+			rec.PreviousCount = starts[k] == previous_start and rec.RequestCount or 0
+			rec.CycleStart = format_time(period_start)
+			rec.RequestCount = 0
+			starts[k] = period_start
+		end
+	else
+		local duration = tonumber(ARGV[b + 9])
+		local elapsed = now - starts[k]
+		if elapsed >= duration then
+			rec.PreviousStart = rec.CycleStart
+			rec.PreviousCount = elapsed < 2 * duration and rec.RequestCount or 0
+			rec.CycleStart = format_time(now)
+			rec.RequestCount = 0
+			starts[k] = now
+		end
+	end
+
+	if rec.RequestCount + cost <= rec.MaxRequests then
+		rec.RequestCount = rec.RequestCount + cost
+		flags[i] = 1
+	else
+		flags[i] = 0
+		allowed = 0
+	end
+end
+
+if allowed == 0 then
+	for i = 1, count do
+		if flags[i] == 1 then
+			local b = 1 + (i - 1) * 10
+			local rec = records[tonumber(ARGV[b + 1])]
+			rec.RequestCount = rec.RequestCount - tonumber(ARGV[b + 2])
+		end
+	end
+end
+
+local encoded = {}
+for k = 1, #KEYS do
+	local rec = records[k]
+	encoded[k] = cjson.encode(rec)
+
+	if rec.Custom or (rec.ParentID or '') ~= '' or (rec.Tier or '') ~= '' or retention[k] == '0' then
+		redis.call('SET', KEYS[k], encoded[k])
+	else
+		redis.call('SET', KEYS[k], encoded[k], 'PX', retention[k])
+	end
+end
+
+return {allowed, flags, encoded}
+`)
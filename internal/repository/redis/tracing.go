@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"rate-limiter-go/internal/tracing"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingHook records a client span for every command sent to Redis. Commands
+// sent in one pipeline or transaction share a single span.
+type TracingHook struct{}
+
+func (TracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (TracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startCommandSpan(ctx, "redis."+cmd.Name(), cmd.Name())
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordCommandError(span, err)
+		return err
+	}
+}
+
+func (TracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+
+		ctx, span := startCommandSpan(ctx, "redis.pipeline", "pipeline")
+		defer span.End()
+		span.SetAttributes(attribute.StringSlice("db.redis.commands", names))
+
+		err := next(ctx, cmds)
+		recordCommandError(span, err)
+		return err
+	}
+}
+
+func startCommandSpan(ctx context.Context, name, operation string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", operation),
+		),
+	)
+}
+
+// recordCommandError marks the span as failed, except for redis.Nil, aborted
+// transactions and scripts not yet cached, which are expected outcomes.
+func recordCommandError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, redis.TxFailedErr) || redis.HasErrorPrefix(err, "NOSCRIPT") {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"io"
 	"rate-limiter-go/internal/domain"
+	"time"
 )
 
 type RateLimiterRepository interface {
@@ -10,4 +12,47 @@ type RateLimiterRepository interface {
 	Save(ctx context.Context, rateLimit *domain.RateLimit) error
 	Delete(ctx context.Context, clientID string) error
 	CreateDefault(ctx context.Context, clientID string) *domain.RateLimit
+	ConsumeBatch(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeResult, bool, error)
+	// ListRateLimits returns stored clients matching filter starting at cursor,
+	// and the cursor of the next page or "" when there is none.
+	ListRateLimits(ctx context.Context, filter domain.ClientFilter, cursor string, limit int) ([]*domain.RateLimit, string, error)
+	// HasTierClients reports whether any stored client is assigned to tier.
+	HasTierClients(ctx context.Context, tier string) (bool, error)
+
+	GetTier(ctx context.Context, name string) (*domain.Tier, bool, error)
+	SaveTier(ctx context.Context, tier *domain.Tier) error
+	DeleteTier(ctx context.Context, name string) error
+	ListTiers(ctx context.Context) ([]*domain.Tier, error)
+
+	GetRule(ctx context.Context, id string) (*domain.RouteRule, bool, error)
+	SaveRule(ctx context.Context, rule *domain.RouteRule) error
+	DeleteRule(ctx context.Context, id string) error
+	ListRules(ctx context.Context) ([]*domain.RouteRule, error)
+
+	GetAccessEntry(ctx context.Context, id string) (*domain.AccessEntry, bool, error)
+	SaveAccessEntry(ctx context.Context, entry *domain.AccessEntry) error
+	DeleteAccessEntry(ctx context.Context, id string) error
+	ListAccessEntries(ctx context.Context) ([]*domain.AccessEntry, error)
+
+	GetPenalty(ctx context.Context, clientID string) (*domain.Penalty, bool, error)
+	SavePenalty(ctx context.Context, penalty *domain.Penalty, ttl time.Duration) error
+	DeletePenalty(ctx context.Context, clientID string) error
+
+	AcquireLease(ctx context.Context, lease *domain.ConcurrencyLease) (bool, error)
+	RenewLease(ctx context.Context, lease *domain.ConcurrencyLease) error
+	ReleaseLease(ctx context.Context, lease *domain.ConcurrencyLease) error
+}
+
+type Snapshotter interface {
+	Snapshot(w io.Writer) error
+	Restore(r io.Reader) error
+}
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Sizer reports the number of entries per store of an in-process backend.
+type Sizer interface {
+	Sizes() map[string]int
 }
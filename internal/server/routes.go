@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"rate-limiter-go/api"
+	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/health"
+	"rate-limiter-go/internal/metrics"
+	"rate-limiter-go/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Services is what the routes are served from. LocalUseCase skips cluster
+// forwarding and answers requests forwarded by peers; the peer routes are
+// only mounted when Clustered is set.
+type Services struct {
+	UseCase       usecase.RateLimiterUseCase
+	LocalUseCase  usecase.RateLimiterUseCase
+	Checker       *health.Checker
+	Clustered     bool
+	ClusterSecret string
+	AdminAuth     echo.MiddlewareFunc
+	Limiter       echo.MiddlewareFunc
+}
+
+// RegisterRoutes mounts the public API on e and the admin API on admin, which
+// is e itself unless the admin API has its own listener. Every route must be
+// described in api/openapi.json.
+func RegisterRoutes(e, admin *echo.Echo, s Services) {
+	rateLimiterHandler := handler.NewRateLimiterHandler(s.UseCase)
+	tierHandler := handler.NewTierHandler(s.UseCase)
+	ruleHandler := handler.NewRuleHandler(s.UseCase)
+	accessHandler := handler.NewAccessHandler(s.UseCase)
+	clientHandler := handler.NewClientHandler(s.UseCase)
+
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(200, map[string]string{"status": "OK"})
+	})
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, api.OpenAPI)
+	})
+
+	admin.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})), s.AdminAuth)
+
+	handler.NewHealthHandler(s.Checker).Register(e)
+	if s.Clustered {
+		handler.NewClusterHandler(s.LocalUseCase, s.ClusterSecret).Register(e)
+	}
+
+	publicAPI := e.Group("/api/v1")
+	publicAPI.GET("/rate-limit/:clientID", rateLimiterHandler.CheckRateLimit)
+	publicAPI.POST("/rate-limit/check", rateLimiterHandler.CheckRateLimitBatch)
+
+	adminAPI := admin.Group("/api/v1")
+	adminAPI.Use(handler.AuditActorMiddleware(), s.AdminAuth)
+	adminAPI.PUT("/rate-limit/:clientID", rateLimiterHandler.ConfigureRateLimit)
+	adminAPI.PATCH("/rate-limit/:clientID", rateLimiterHandler.PatchRateLimit)
+	adminAPI.GET("/rate-limit/:clientID/usage", rateLimiterHandler.GetUsage)
+	adminAPI.PUT("/rate-limit/:clientID/tier", tierHandler.AssignTier)
+	adminAPI.DELETE("/rate-limit/:clientID/tier", tierHandler.UnassignTier)
+	adminAPI.DELETE("/rate-limit/:clientID/ban", rateLimiterHandler.Unban)
+
+	adminAPI.GET("/clients", clientHandler.ListClients)
+	adminAPI.PUT("/clients", clientHandler.BulkConfigure)
+	adminAPI.GET("/clients/:clientID", clientHandler.GetClient)
+	adminAPI.DELETE("/clients/:clientID", clientHandler.DeleteClient)
+	adminAPI.POST("/clients/:clientID/reset", clientHandler.ResetClient)
+
+	adminAPI.GET("/tiers", tierHandler.ListTiers)
+	adminAPI.GET("/tiers/:name", tierHandler.GetTier)
+	adminAPI.PUT("/tiers/:name", tierHandler.SaveTier)
+	adminAPI.DELETE("/tiers/:name", tierHandler.DeleteTier)
+
+	adminAPI.GET("/rules", ruleHandler.ListRules)
+	adminAPI.GET("/rules/:id", ruleHandler.GetRule)
+	adminAPI.PUT("/rules/:id", ruleHandler.SaveRule)
+	adminAPI.DELETE("/rules/:id", ruleHandler.DeleteRule)
+	adminAPI.GET("/adaptive-limits", ruleHandler.ListAdaptiveLimits)
+
+	adminAPI.GET("/access", accessHandler.ListAccessEntries)
+	adminAPI.GET("/access/:id", accessHandler.GetAccessEntry)
+	adminAPI.PUT("/access/:id", accessHandler.SaveAccessEntry)
+	adminAPI.DELETE("/access/:id", accessHandler.DeleteAccessEntry)
+
+	protected := publicAPI.Group("/protected")
+	protected.Use(s.Limiter)
+	protected.GET("/data", func(c echo.Context) error {
+		return c.JSON(200, map[string]string{
+			"message": "Protected data",
+		})
+	})
+}
@@ -0,0 +1,410 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"rate-limiter-go/api"
+	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/health"
+	"rate-limiter-go/internal/repository"
+	"rate-limiter-go/internal/repository/memory"
+	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const specURL = "openapi.json"
+
+var pathParam = regexp.MustCompile(`:([a-zA-Z]+)`)
+
+type spec struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+	doc   interface{}
+}
+
+func loadSpec(t *testing.T) *spec {
+	t.Helper()
+
+	var s spec
+	if err := json.Unmarshal(api.OpenAPI, &s); err != nil {
+		t.Fatalf("Invalid OpenAPI document: %v", err)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(api.OpenAPI))
+	if err != nil {
+		t.Fatalf("Invalid OpenAPI document: %v", err)
+	}
+	s.doc = doc
+	return &s
+}
+
+// lookup follows a JSON pointer through the document, resolving a $ref on
+// the final object.
+func (s *spec) lookup(pointer string) (interface{}, string) {
+	node := s.doc
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, ""
+		}
+		node = object[token]
+	}
+
+	if object, ok := node.(map[string]interface{}); ok {
+		if ref, ok := object["$ref"].(string); ok {
+			return s.lookup(strings.TrimPrefix(ref, "#"))
+		}
+	}
+	return node, pointer
+}
+
+func pointerToken(value string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(value)
+}
+
+// responseSchema compiles the schema documented for a response, or the
+// default response when the status is not listed.
+func (s *spec) responseSchema(t *testing.T, template, method string, status int, contentType string) *jsonschema.Schema {
+	t.Helper()
+
+	operation := "/paths/" + pointerToken(template) + "/" + strings.ToLower(method) + "/responses/"
+	resp, pointer := s.lookup(operation + strconv.Itoa(status))
+	if resp == nil {
+		resp, pointer = s.lookup(operation + "default")
+	}
+	if resp == nil {
+		t.Fatalf("%s %s: status %d is not documented", method, template, status)
+	}
+
+	schemaPointer := pointer + "/content/" + pointerToken(contentType) + "/schema"
+	if schema, _ := s.lookup(schemaPointer); schema == nil {
+		t.Fatalf("%s %s: %s is not documented for status %d", method, template, contentType, status)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	if err := compiler.AddResource(specURL, s.doc); err != nil {
+		t.Fatalf("Failed to load OpenAPI document: %v", err)
+	}
+
+	var fragment []string
+	for _, token := range strings.Split(schemaPointer, "/") {
+		fragment = append(fragment, url.PathEscape(token))
+	}
+	schema, err := compiler.Compile(specURL + "#" + strings.Join(fragment, "/"))
+	if err != nil {
+		t.Fatalf("%s %s: invalid schema: %v", method, template, err)
+	}
+	return schema
+}
+
+type testServer struct {
+	echo    *echo.Echo
+	breaker *health.Breaker
+	route   string
+}
+
+func newTestServer(clustered bool) *testServer {
+	repo := memory.NewRateLimiterMemoryRepository(100, 1)
+	uc := usecase.NewRateLimiterUseCase(repository.NewInstrumentedRepository(repo, "test"))
+	breaker := health.NewBreaker(1, time.Minute)
+
+	s := &testServer{echo: echo.New(), breaker: breaker}
+	s.echo.HTTPErrorHandler = response.HTTPErrorHandler
+	s.echo.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			s.route = c.Path()
+			return next(c)
+		}
+	})
+
+	RegisterRoutes(s.echo, s.echo, Services{
+		UseCase:       uc,
+		LocalUseCase:  uc,
+		Checker:       health.NewChecker("memory", repo.(repository.Pinger), breaker, time.Second, nil),
+		Clustered:     clustered,
+		ClusterSecret: "cluster-secret",
+		AdminAuth: handler.AdminAuthMiddleware(handler.AdminAuthConfig{
+			Tokens: []handler.AdminCredential{
+				{Name: "ops", Role: handler.RoleOperator, Secret: "operator-token"},
+				{Name: "dashboard", Role: handler.RoleViewer, Secret: "viewer-token"},
+			},
+		}),
+		Limiter: handler.RateLimiterMiddleware(uc),
+	})
+	return s
+}
+
+func (s *testServer) do(method, path string, header map[string]string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if raw, ok := body.(string); ok {
+		reader = bytes.NewReader([]byte(raw))
+	} else if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+
+	s.route = ""
+	s.echo.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func specPath(route string) string {
+	return pathParam.ReplaceAllString(route, "{$1}")
+}
+
+func TestOpenAPI_DescribesEveryRoute(t *testing.T) {
+	s := loadSpec(t)
+	server := newTestServer(true)
+
+	if !bytes.Contains(api.OpenAPI, []byte(`"openapi": "3.1.0"`)) {
+		t.Error("Expected an OpenAPI 3.1.0 document")
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range server.echo.Routes() {
+		if route.Method == echo.RouteNotFound {
+			continue
+		}
+
+		template := specPath(route.Path)
+		registered[route.Method+" "+template] = true
+		if _, ok := s.Paths[template][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s is not described in the OpenAPI document", route.Method, template)
+		}
+	}
+
+	for template, item := range s.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[strings.ToUpper(method)+" "+template] {
+				t.Errorf("%s %s is described but not registered", strings.ToUpper(method), template)
+			}
+		}
+	}
+}
+
+func TestOpenAPI_ResponsesMatchSchemas(t *testing.T) {
+	s := loadSpec(t)
+	server := newTestServer(true)
+
+	operator := map[string]string{"Authorization": "Bearer operator-token"}
+	viewer := map[string]string{"Authorization": "Bearer viewer-token"}
+	peer := map[string]string{"X-Cluster-Secret": "cluster-secret"}
+	with := func(header map[string]string, name, value string) map[string]string {
+		merged := map[string]string{name: value}
+		for k, v := range header {
+			merged[k] = v
+		}
+		return merged
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		header map[string]string
+		body   interface{}
+		status int
+	}{
+		{http.MethodGet, "/", nil, nil, http.StatusOK},
+		{http.MethodGet, "/openapi.json", nil, nil, http.StatusOK},
+		{http.MethodGet, "/healthz", nil, nil, http.StatusOK},
+		{http.MethodGet, "/readyz", nil, nil, http.StatusOK},
+		{http.MethodGet, "/metrics", operator, nil, http.StatusOK},
+		{http.MethodGet, "/metrics", nil, nil, http.StatusUnauthorized},
+
+		{http.MethodPut, "/api/v1/tiers/pro", operator, map[string]interface{}{"max_requests": 100, "cycle_duration": 1, "priority": 2}, http.StatusOK},
+		{http.MethodPut, "/api/v1/tiers/pro", operator, map[string]interface{}{"max_requests": 0, "window": "fortnight"}, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/tiers/pro", viewer, map[string]interface{}{"max_requests": 100, "cycle_duration": 1}, http.StatusForbidden},
+		{http.MethodGet, "/api/v1/tiers", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/tiers/pro", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/tiers/missing", viewer, nil, http.StatusNotFound},
+
+		{http.MethodPut, "/api/v1/rate-limit/alice", operator, map[string]interface{}{"max_requests": 5, "cycle_duration": 1}, http.StatusOK},
+		{http.MethodPut, "/api/v1/rate-limit/alice", operator, map[string]interface{}{"max_requests": 5, "window": "fortnight"}, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/rate-limit/alice", with(operator, "If-Match", `"9"`), map[string]interface{}{"max_requests": 5, "cycle_duration": 1}, http.StatusPreconditionFailed},
+		{http.MethodPut, "/api/v1/rate-limit/alice", nil, map[string]interface{}{"max_requests": 5, "cycle_duration": 1}, http.StatusUnauthorized},
+		{http.MethodPatch, "/api/v1/rate-limit/alice", operator, map[string]interface{}{"max_requests": 6}, http.StatusOK},
+		{http.MethodPatch, "/api/v1/rate-limit/alice", operator, map[string]interface{}{"tier": "missing"}, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/rate-limit/alice", nil, nil, http.StatusOK},
+		{http.MethodPost, "/api/v1/rate-limit/check", nil, map[string]interface{}{"requests": []map[string]interface{}{{"client_id": "alice", "cost": 2}, {"client_id": "bob"}}}, http.StatusOK},
+		{http.MethodPost, "/api/v1/rate-limit/check", nil, map[string]interface{}{"requests": []interface{}{}}, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/rate-limit/alice/usage", viewer, nil, http.StatusOK},
+		{http.MethodPut, "/api/v1/rate-limit/bob/tier", operator, map[string]interface{}{"tier": "pro"}, http.StatusOK},
+		{http.MethodPut, "/api/v1/rate-limit/bob/tier", operator, map[string]interface{}{"tier": "missing"}, http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/rate-limit/bob/tier", operator, nil, http.StatusOK},
+		{http.MethodDelete, "/api/v1/rate-limit/alice/ban", operator, nil, http.StatusNotFound},
+
+		{http.MethodPut, "/api/v1/clients", operator, []map[string]interface{}{{"client_id": "carol", "max_requests": 1, "cycle_duration": 1}, {"client_id": "dave", "tier": "missing"}}, http.StatusOK},
+		{http.MethodPut, "/api/v1/clients", operator, []map[string]interface{}{{"max_requests": -1}}, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/clients?limit=2", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/clients?limit=0", viewer, nil, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/clients/alice", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/clients/bob", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/clients/nobody", viewer, nil, http.StatusNotFound},
+		{http.MethodPost, "/api/v1/clients/alice/reset", operator, nil, http.StatusOK},
+		{http.MethodPost, "/api/v1/clients/nobody/reset", operator, nil, http.StatusNotFound},
+
+		{http.MethodPut, "/api/v1/rules/search", operator, map[string]interface{}{"method": "GET", "path": "/search/**", "max_requests": 10, "cycle_duration": 1}, http.StatusOK},
+		{http.MethodPut, "/api/v1/rules/search", operator, map[string]interface{}{"path": "/search", "priority_share": 50}, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/rules", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/rules/search", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/rules/missing", viewer, nil, http.StatusNotFound},
+		{http.MethodGet, "/api/v1/adaptive-limits", viewer, nil, http.StatusOK},
+		{http.MethodDelete, "/api/v1/rules/search", operator, nil, http.StatusOK},
+		{http.MethodDelete, "/api/v1/rules/search", operator, nil, http.StatusNotFound},
+
+		{http.MethodPut, "/api/v1/access/office", operator, map[string]interface{}{"list": "allow", "pattern": "10.0.0.0/8", "reason": "office", "ttl_seconds": 60}, http.StatusOK},
+		{http.MethodPut, "/api/v1/access/office", operator, map[string]interface{}{"list": "maybe"}, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/access?list=allow", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/access/office", viewer, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/access/missing", viewer, nil, http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/access/office", operator, nil, http.StatusOK},
+		{http.MethodDelete, "/api/v1/access/office", operator, nil, http.StatusNotFound},
+
+		{http.MethodPost, "/internal/v1/cluster/check/alice", peer, nil, http.StatusOK},
+		{http.MethodPost, "/internal/v1/cluster/check/alice", nil, nil, http.StatusForbidden},
+		{http.MethodPut, "/internal/v1/cluster/config/erin", nil, map[string]interface{}{"max_requests": 1000000}, http.StatusForbidden},
+		{http.MethodPut, "/internal/v1/cluster/config/erin", peer, map[string]interface{}{"max_requests": 1, "cycle_duration": 1}, http.StatusOK},
+		{http.MethodPut, "/internal/v1/cluster/config/erin", with(peer, "If-Match", `"7"`), map[string]interface{}{"max_requests": 1, "cycle_duration": 1}, http.StatusPreconditionFailed},
+		{http.MethodPut, "/internal/v1/cluster/config/erin", peer, "{", http.StatusBadRequest},
+		{http.MethodPut, "/internal/v1/cluster/tiers/gold", peer, map[string]interface{}{"Name": "gold", "MaxRequests": 10, "CycleDuration": 1}, http.StatusOK},
+		{http.MethodPut, "/internal/v1/cluster/tiers/gold", peer, map[string]interface{}{"Name": "silver"}, http.StatusBadRequest},
+		{http.MethodPut, "/internal/v1/cluster/tier/erin", peer, map[string]interface{}{"tier": "gold"}, http.StatusOK},
+		{http.MethodPut, "/internal/v1/cluster/tier/erin", peer, map[string]interface{}{"tier": "missing"}, http.StatusNotFound},
+		{http.MethodGet, "/internal/v1/cluster/usage/erin", peer, nil, http.StatusOK},
+		{http.MethodGet, "/internal/v1/cluster/clients/erin", peer, nil, http.StatusOK},
+		{http.MethodGet, "/internal/v1/cluster/clients/nobody", peer, nil, http.StatusNotFound},
+		{http.MethodPost, "/internal/v1/cluster/clients/erin/reset", peer, nil, http.StatusOK},
+		{http.MethodPut, "/internal/v1/cluster/rules/export", peer, map[string]interface{}{"ID": "export", "Pattern": "/export", "MaxRequests": 1, "CycleDuration": 1}, http.StatusOK},
+		{http.MethodPost, "/internal/v1/cluster/route-check/erin", peer, map[string]interface{}{"rules": []string{"export"}}, http.StatusOK},
+		{http.MethodPost, "/internal/v1/cluster/route-check/erin", peer, map[string]interface{}{"rules": []string{"missing"}}, http.StatusNotFound},
+		{http.MethodPost, "/internal/v1/cluster/refund/erin", peer, map[string]interface{}{"rules": []string{"export"}}, http.StatusOK},
+		{http.MethodPost, "/internal/v1/cluster/refund/erin", peer, map[string]interface{}{}, http.StatusOK},
+		{http.MethodPost, "/internal/v1/cluster/refund/erin", peer, map[string]interface{}{"rules": []string{"missing"}}, http.StatusNotFound},
+		{http.MethodPost, "/internal/v1/cluster/batch", peer, []map[string]interface{}{{"ClientID": "erin", "Cost": 1}}, http.StatusOK},
+		{http.MethodPost, "/internal/v1/cluster/batch", peer, "{", http.StatusBadRequest},
+		{http.MethodGet, "/internal/v1/cluster/penalty/erin", peer, nil, http.StatusNotFound},
+		{http.MethodDelete, "/internal/v1/cluster/penalty/erin", peer, nil, http.StatusNotFound},
+		{http.MethodDelete, "/internal/v1/cluster/rules/export", peer, nil, http.StatusOK},
+		{http.MethodDelete, "/internal/v1/cluster/rules/export", peer, nil, http.StatusNotFound},
+		{http.MethodPut, "/internal/v1/cluster/access/vpn", peer, map[string]interface{}{"ID": "vpn", "List": "deny", "Pattern": "192.0.2.1"}, http.StatusOK},
+		{http.MethodDelete, "/internal/v1/cluster/access/vpn", peer, nil, http.StatusOK},
+		{http.MethodDelete, "/internal/v1/cluster/access/vpn", peer, nil, http.StatusNotFound},
+		{http.MethodDelete, "/internal/v1/cluster/clients/erin", peer, nil, http.StatusOK},
+		{http.MethodDelete, "/internal/v1/cluster/clients/erin", peer, nil, http.StatusNotFound},
+		{http.MethodDelete, "/internal/v1/cluster/tiers/gold", peer, nil, http.StatusOK},
+		{http.MethodDelete, "/internal/v1/cluster/tiers/gold", peer, nil, http.StatusNotFound},
+
+		{http.MethodGet, "/api/v1/protected/data", map[string]string{"X-Client-ID": "carol"}, nil, http.StatusOK},
+		{http.MethodGet, "/api/v1/protected/data", map[string]string{"X-Client-ID": "carol"}, nil, http.StatusTooManyRequests},
+
+		{http.MethodDelete, "/api/v1/clients/alice", operator, nil, http.StatusOK},
+		{http.MethodDelete, "/api/v1/clients/alice", operator, nil, http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/tiers/pro", operator, nil, http.StatusOK},
+		{http.MethodDelete, "/api/v1/tiers/pro", operator, nil, http.StatusNotFound},
+	}
+
+	exercised := make(map[string]bool)
+	check := func(method, path string, header map[string]string, body interface{}, status int) {
+		t.Helper()
+
+		rec := server.do(method, path, header, body)
+		if rec.Code != status {
+			t.Errorf("%s %s: expected %d, got %d: %s", method, path, status, rec.Code, rec.Body.String())
+			return
+		}
+
+		template := specPath(server.route)
+		exercised[method+" "+template] = true
+
+		contentType, _, _ := strings.Cut(rec.Header().Get(echo.HeaderContentType), ";")
+		if contentType == echo.MIMETextPlain {
+			return
+		}
+
+		schema := s.responseSchema(t, template, method, status, contentType)
+		instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(rec.Body.Bytes()))
+		if err != nil {
+			t.Errorf("%s %s: invalid JSON response: %v", method, path, err)
+			return
+		}
+		if err := schema.Validate(instance); err != nil {
+			t.Errorf("%s %s: response %d does not match the schema: %v\n%s", method, path, status, err, rec.Body.String())
+		}
+	}
+
+	for _, tc := range cases {
+		check(tc.method, tc.path, tc.header, tc.body, tc.status)
+	}
+
+	server.breaker.Record(errors.New("backend down"))
+	check(http.MethodGet, "/readyz", nil, nil, http.StatusServiceUnavailable)
+
+	for template, item := range s.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			if key := strings.ToUpper(method) + " " + template; !exercised[key] {
+				t.Errorf("%s is not exercised", key)
+			}
+		}
+	}
+}
+
+func TestClusterRoutes_Denied(t *testing.T) {
+	body := map[string]interface{}{"max_requests": 1000000}
+	operator := map[string]string{"Authorization": "Bearer operator-token"}
+
+	server := newTestServer(false)
+	if rec := server.do(http.MethodPut, "/internal/v1/cluster/config/me", nil, body); rec.Code != http.StatusNotFound {
+		t.Errorf("Cluster routes should not be mounted outside cluster mode, got %d", rec.Code)
+	}
+	if rec := server.do(http.MethodGet, "/api/v1/clients/me", operator, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected the client to stay unconfigured, got %d", rec.Code)
+	}
+
+	uc := usecase.NewRateLimiterUseCase(memory.NewRateLimiterMemoryRepository(100, 1))
+	e := echo.New()
+	handler.NewClusterHandler(uc, "").Register(e)
+
+	for _, header := range []map[string]string{nil, {"X-Cluster-Secret": ""}} {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPut, "/internal/v1/cluster/config/me", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("Expected 403 without a configured cluster secret, got %d", rec.Code)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+
+	for _, exporter := range []string{"", ExporterNone, ExporterStdout} {
+		shutdown, err := Setup(ctx, exporter, "test")
+		if err != nil {
+			t.Fatalf("Setup(%q) failed: %v", exporter, err)
+		}
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("Shutdown(%q) failed: %v", exporter, err)
+		}
+	}
+
+	if _, err := Setup(ctx, "zipkin", "test"); err == nil {
+		t.Error("Expected error for unknown exporter")
+	}
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"rate-limiter-go/internal/domain"
+	"time"
+)
+
+const accessCacheTTL = 5 * time.Second
+
+// CheckAccess evaluates the allow and deny lists for a client before any
+// counter is touched. Entries from the policy file and from the repository
+// are evaluated together; if the repository fails only the policy entries
+// apply.
+func (uc *rateLimiterUseCase) CheckAccess(ctx context.Context, clientID string, ip net.IP) (domain.AccessVerdict, *domain.AccessEntry) {
+	stored, err := uc.cachedAccessEntries(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load access entries", "error", err)
+	}
+
+	uc.accessMu.RLock()
+	entries := append(append([]*domain.AccessEntry{}, uc.policyAccess...), stored...)
+	uc.accessMu.RUnlock()
+
+	verdict, entry := domain.EvaluateAccess(entries, clientID, ip, time.Now())
+	if verdict == domain.AccessDenied {
+		uc.config.Audit.AccessDenied(ctx, clientID, ip, entry)
+	}
+	return verdict, entry
+}
+
+// SetPolicyAccessEntries replaces the access entries defined by the policy
+// file.
+func (uc *rateLimiterUseCase) SetPolicyAccessEntries(entries []*domain.AccessEntry) {
+	uc.accessMu.Lock()
+	uc.policyAccess = entries
+	uc.accessMu.Unlock()
+}
+
+func (uc *rateLimiterUseCase) ListAccessEntries(ctx context.Context) ([]*domain.AccessEntry, error) {
+	return uc.repo.ListAccessEntries(ctx)
+}
+
+func (uc *rateLimiterUseCase) GetAccessEntry(ctx context.Context, id string) (*domain.AccessEntry, error) {
+	entry, exists, err := uc.repo.GetAccessEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrAccessEntryNotFound
+	}
+
+	return entry, nil
+}
+
+func (uc *rateLimiterUseCase) SaveAccessEntry(ctx context.Context, entry *domain.AccessEntry) error {
+	defer uc.invalidateAccess()
+
+	old, _, err := uc.repo.GetAccessEntry(ctx, entry.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.SaveAccessEntry(ctx, entry); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "access_entry", entry.ID, old, entry)
+	return nil
+}
+
+func (uc *rateLimiterUseCase) DeleteAccessEntry(ctx context.Context, id string) error {
+	defer uc.invalidateAccess()
+
+	old, err := uc.GetAccessEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeleteAccessEntry(ctx, id); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "access_entry", id, old, nil)
+	return nil
+}
+
+func (uc *rateLimiterUseCase) cachedAccessEntries(ctx context.Context) ([]*domain.AccessEntry, error) {
+	uc.accessMu.RLock()
+	entries, loadedAt := uc.access, uc.accessLoadedAt
+	uc.accessMu.RUnlock()
+
+	if !loadedAt.IsZero() && time.Since(loadedAt) < accessCacheTTL {
+		return entries, nil
+	}
+
+	entries, err := uc.repo.ListAccessEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.accessMu.Lock()
+	uc.access, uc.accessLoadedAt = entries, time.Now()
+	uc.accessMu.Unlock()
+
+	return entries, nil
+}
+
+func (uc *rateLimiterUseCase) invalidateAccess() {
+	uc.accessMu.Lock()
+	uc.accessLoadedAt = time.Time{}
+	uc.accessMu.Unlock()
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/metrics"
+	"time"
+)
+
+// ObserveRoute feeds the latency and outcome of a handled request into the
+// adaptive limits of the rules it matched. Observations are local to this
+// replica.
+func (uc *rateLimiterUseCase) ObserveRoute(rules []*domain.RouteRule, latency time.Duration, failed bool) {
+	if !uc.config.Adaptive.Enabled() || len(rules) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	uc.adaptiveMu.Lock()
+	defer uc.adaptiveMu.Unlock()
+
+	for _, rule := range rules {
+		limit, exists := uc.adaptive[rule.ID]
+		if !exists {
+			limit = domain.NewAdaptiveLimit(now)
+			uc.adaptive[rule.ID] = limit
+		}
+		limit.Observe(latency, failed, now, uc.config.Adaptive)
+
+		metrics.AdaptiveLimit.WithLabelValues(rule.ID).Set(float64(limit.Apply(rule.MaxRequests)))
+		metrics.AdaptiveFactor.WithLabelValues(rule.ID).Set(limit.Factor)
+	}
+}
+
+func (uc *rateLimiterUseCase) ListAdaptiveLimits(ctx context.Context) ([]domain.AdaptiveStatus, error) {
+	rules, err := uc.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.adaptiveMu.Lock()
+	defer uc.adaptiveMu.Unlock()
+
+	statuses := make([]domain.AdaptiveStatus, len(rules))
+	for i, rule := range rules {
+		status := domain.AdaptiveStatus{RuleID: rule.ID, Ceiling: rule.MaxRequests, Limit: rule.MaxRequests, Factor: 1}
+		if limit, exists := uc.adaptive[rule.ID]; exists {
+			status.Limit = limit.Apply(rule.MaxRequests)
+			status.Factor = limit.Factor
+			status.Latency = limit.Latency
+			status.ErrorRate = limit.ErrorRate
+		}
+		statuses[i] = status
+	}
+
+	return statuses, nil
+}
+
+// adaptiveMax scales max by the rule's current adaptive factor.
+func (uc *rateLimiterUseCase) adaptiveMax(rule *domain.RouteRule, max int) int {
+	if !uc.config.Adaptive.Enabled() {
+		return max
+	}
+
+	uc.adaptiveMu.Lock()
+	defer uc.adaptiveMu.Unlock()
+
+	if limit, exists := uc.adaptive[rule.ID]; exists {
+		return limit.Apply(max)
+	}
+	return max
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"rate-limiter-go/internal/domain"
+	"time"
+)
+
+func (uc *rateLimiterUseCase) ListClients(ctx context.Context, filter domain.ClientFilter, cursor string, limit int) ([]*domain.RateLimit, string, error) {
+	return uc.repo.ListRateLimits(ctx, filter, cursor, limit)
+}
+
+// GetClient returns the stored record of a client together with its usage
+// under the limits it resolves to.
+func (uc *rateLimiterUseCase) GetClient(ctx context.Context, clientID string) (*domain.RateLimit, *domain.Usage, error) {
+	rateLimit, exists, err := uc.repo.Get(ctx, clientID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, domain.ErrClientNotFound
+	}
+
+	usage, err := uc.GetUsage(ctx, clientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rateLimit, usage, nil
+}
+
+// ResetRateLimit clears a client's counters and keeps its configuration.
+func (uc *rateLimiterUseCase) ResetRateLimit(ctx context.Context, clientID string) error {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	rateLimit, exists, err := uc.repo.Get(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrClientNotFound
+	}
+
+	old := rateLimit.RequestCount
+	rateLimit.Reset(time.Now())
+
+	if err := uc.repo.Save(ctx, rateLimit); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "rate_limit_counter", clientID, old, rateLimit.RequestCount)
+	return nil
+}
+
+// DeleteRateLimit removes a client's record, returning it to the default tier
+// with a fresh counter.
+func (uc *rateLimiterUseCase) DeleteRateLimit(ctx context.Context, clientID string) error {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	rateLimit, exists, err := uc.repo.Get(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrClientNotFound
+	}
+
+	if err := uc.repo.Delete(ctx, clientID); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "rate_limit", clientID, configOf(rateLimit), nil)
+	return nil
+}
@@ -0,0 +1,311 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rate-limiter-go/internal/cluster"
+	"rate-limiter-go/internal/domain"
+	"time"
+)
+
+// clusterRateLimiterUseCase forwards calls about a client to the node that
+// owns it and replays tier, rule and access list changes on every node.
+// Everything else, such as listing clients, only sees the local node.
+type clusterRateLimiterUseCase struct {
+	RateLimiterUseCase
+	ring          *cluster.Ring
+	client        *cluster.Client
+	onPeerFailure cluster.FailureMode
+}
+
+func NewClusterRateLimiterUseCase(local RateLimiterUseCase, ring *cluster.Ring, client *cluster.Client, onPeerFailure cluster.FailureMode) RateLimiterUseCase {
+	return &clusterRateLimiterUseCase{
+		RateLimiterUseCase: local,
+		ring:               ring,
+		client:             client,
+		onPeerFailure:      onPeerFailure,
+	}
+}
+
+func (uc *clusterRateLimiterUseCase) CheckRateLimit(ctx context.Context, clientID string) domain.Decision {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.CheckRateLimit(ctx, clientID)
+	}
+
+	result, err := uc.client.Check(ctx, owner, clientID)
+	if err != nil {
+		return uc.peerFailed(ctx, clientID, owner, err, func() domain.Decision {
+			return uc.RateLimiterUseCase.CheckRateLimit(ctx, clientID)
+		})
+	}
+
+	return result.Decision()
+}
+
+func (uc *clusterRateLimiterUseCase) RefundRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		uc.RateLimiterUseCase.RefundRateLimit(ctx, clientID, rules)
+		return
+	}
+
+	ids := make([]string, len(rules))
+	for i, rule := range rules {
+		ids[i] = rule.ID
+	}
+
+	if err := uc.client.Refund(ctx, owner, clientID, ids); err != nil {
+		slog.ErrorContext(ctx, "failed to refund rate limit on peer", "client_id", clientID, "peer", owner, "error", err)
+	}
+}
+
+func (uc *clusterRateLimiterUseCase) CheckRouteRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) domain.Decision {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.CheckRouteRateLimit(ctx, clientID, rules)
+	}
+
+	ids := make([]string, len(rules))
+	for i, rule := range rules {
+		ids[i] = rule.ID
+	}
+
+	result, err := uc.client.CheckRoute(ctx, owner, clientID, ids)
+	if err != nil {
+		return uc.peerFailed(ctx, clientID, owner, err, func() domain.Decision {
+			return uc.RateLimiterUseCase.CheckRouteRateLimit(ctx, clientID, rules)
+		})
+	}
+
+	return result.Decision()
+}
+
+// CheckRateLimitBatch forwards the batch when one node owns all its clients.
+// A batch spanning nodes cannot be applied all or nothing and is rejected.
+func (uc *clusterRateLimiterUseCase) CheckRateLimitBatch(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeResult, bool, error) {
+	owner := uc.ring.Owner(requests[0].ClientID)
+	for _, req := range requests[1:] {
+		if uc.ring.Owner(req.ClientID) != owner {
+			return nil, false, fmt.Errorf("%w: batch clients are owned by different nodes", domain.ErrNotSupportedInCluster)
+		}
+	}
+
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.CheckRateLimitBatch(ctx, requests)
+	}
+
+	result, err := uc.client.CheckBatch(ctx, owner, requests)
+	if err != nil {
+		if uc.onPeerFailure == cluster.FailLocal && !isPeerError(err) {
+			slog.WarnContext(ctx, "cluster batch failed, evaluating locally", "owner", owner, "error", err)
+			return uc.RateLimiterUseCase.CheckRateLimitBatch(ctx, requests)
+		}
+		return nil, false, err
+	}
+
+	return result.Results, result.Allowed, nil
+}
+
+func (uc *clusterRateLimiterUseCase) ConfigureRateLimit(ctx context.Context, clientID string, config domain.RateLimitConfig) error {
+	_, err := uc.ConfigureRateLimitIfMatch(ctx, clientID, config, domain.AnyVersion)
+	return err
+}
+
+func (uc *clusterRateLimiterUseCase) ConfigureRateLimitIfMatch(ctx context.Context, clientID string, config domain.RateLimitConfig, version int) (int, error) {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.ConfigureRateLimitIfMatch(ctx, clientID, config, version)
+	}
+
+	return uc.client.Configure(ctx, owner, clientID, config, version)
+}
+
+func (uc *clusterRateLimiterUseCase) AssignTier(ctx context.Context, clientID string, tier string) error {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.AssignTier(ctx, clientID, tier)
+	}
+
+	return uc.client.AssignTier(ctx, owner, clientID, tier)
+}
+
+func (uc *clusterRateLimiterUseCase) GetUsage(ctx context.Context, clientID string) (*domain.Usage, error) {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.GetUsage(ctx, clientID)
+	}
+
+	return uc.client.Usage(ctx, owner, clientID)
+}
+
+func (uc *clusterRateLimiterUseCase) GetClient(ctx context.Context, clientID string) (*domain.RateLimit, *domain.Usage, error) {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.GetClient(ctx, clientID)
+	}
+
+	result, err := uc.client.GetClient(ctx, owner, clientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result.RateLimit, result.Usage, nil
+}
+
+func (uc *clusterRateLimiterUseCase) ResetRateLimit(ctx context.Context, clientID string) error {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.ResetRateLimit(ctx, clientID)
+	}
+
+	return uc.client.ResetClient(ctx, owner, clientID)
+}
+
+func (uc *clusterRateLimiterUseCase) DeleteRateLimit(ctx context.Context, clientID string) error {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.DeleteRateLimit(ctx, clientID)
+	}
+
+	return uc.client.DeleteClient(ctx, owner, clientID)
+}
+
+func (uc *clusterRateLimiterUseCase) GetPenalty(ctx context.Context, clientID string) (*domain.Penalty, error) {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.GetPenalty(ctx, clientID)
+	}
+
+	return uc.client.Penalty(ctx, owner, clientID)
+}
+
+func (uc *clusterRateLimiterUseCase) Unban(ctx context.Context, clientID string) error {
+	owner := uc.ring.Owner(clientID)
+	if owner == uc.ring.Self() {
+		return uc.RateLimiterUseCase.Unban(ctx, clientID)
+	}
+
+	return uc.client.Unban(ctx, owner, clientID)
+}
+
+func (uc *clusterRateLimiterUseCase) SaveTier(ctx context.Context, tier *domain.Tier) error {
+	if err := uc.RateLimiterUseCase.SaveTier(ctx, tier); err != nil {
+		return err
+	}
+
+	return uc.broadcast(func(peer string) error {
+		return uc.client.SaveTier(ctx, peer, tier)
+	})
+}
+
+// DeleteTier restores the tier on every node when a peer still has clients
+// assigned to it, since each node only sees the clients it owns.
+func (uc *clusterRateLimiterUseCase) DeleteTier(ctx context.Context, name string) error {
+	tier, err := uc.RateLimiterUseCase.GetTier(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.RateLimiterUseCase.DeleteTier(ctx, name); err != nil {
+		return err
+	}
+
+	err = uc.broadcast(func(peer string) error {
+		return ignoreNotFound(uc.client.DeleteTier(ctx, peer, name), domain.ErrTierNotFound)
+	})
+	if errors.Is(err, domain.ErrTierInUse) {
+		if restoreErr := uc.SaveTier(ctx, tier); restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to restore tier %s: %w", name, restoreErr))
+		}
+	}
+
+	return err
+}
+
+func (uc *clusterRateLimiterUseCase) SaveRule(ctx context.Context, rule *domain.RouteRule) error {
+	if err := uc.RateLimiterUseCase.SaveRule(ctx, rule); err != nil {
+		return err
+	}
+
+	return uc.broadcast(func(peer string) error {
+		return uc.client.SaveRule(ctx, peer, rule)
+	})
+}
+
+func (uc *clusterRateLimiterUseCase) DeleteRule(ctx context.Context, id string) error {
+	if err := uc.RateLimiterUseCase.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+
+	return uc.broadcast(func(peer string) error {
+		return ignoreNotFound(uc.client.DeleteRule(ctx, peer, id), domain.ErrRuleNotFound)
+	})
+}
+
+func (uc *clusterRateLimiterUseCase) SaveAccessEntry(ctx context.Context, entry *domain.AccessEntry) error {
+	if err := uc.RateLimiterUseCase.SaveAccessEntry(ctx, entry); err != nil {
+		return err
+	}
+
+	return uc.broadcast(func(peer string) error {
+		return uc.client.SaveAccessEntry(ctx, peer, entry)
+	})
+}
+
+func (uc *clusterRateLimiterUseCase) DeleteAccessEntry(ctx context.Context, id string) error {
+	if err := uc.RateLimiterUseCase.DeleteAccessEntry(ctx, id); err != nil {
+		return err
+	}
+
+	return uc.broadcast(func(peer string) error {
+		return ignoreNotFound(uc.client.DeleteAccessEntry(ctx, peer, id), domain.ErrAccessEntryNotFound)
+	})
+}
+
+// broadcast replays a change that was applied locally on every other node.
+func (uc *clusterRateLimiterUseCase) broadcast(apply func(peer string) error) error {
+	var errs []error
+
+	for _, peer := range uc.ring.Peers() {
+		if peer == uc.ring.Self() {
+			continue
+		}
+		if err := apply(peer); err != nil {
+			errs = append(errs, fmt.Errorf("failed to apply on peer %s: %w", peer, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// peerFailed answers a check whose owner could not be reached according to
+// the configured failure mode.
+func (uc *clusterRateLimiterUseCase) peerFailed(ctx context.Context, clientID, owner string, err error, local func() domain.Decision) domain.Decision {
+	slog.WarnContext(ctx, "cluster check failed", "client_id", clientID, "owner", owner, "mode", uc.onPeerFailure, "error", err)
+
+	switch uc.onPeerFailure {
+	case cluster.FailAllow:
+		return domain.Decision{Allowed: true}
+	case cluster.FailDeny:
+		return domain.Decision{Allowed: false, Reset: time.Now().Add(time.Second), RetryAfter: time.Second}
+	}
+
+	return local()
+}
+
+// isPeerError reports whether the peer answered with a domain error, which a
+// local evaluation would not fix.
+func isPeerError(err error) bool {
+	code, _ := cluster.ErrorCode(err)
+	return code != ""
+}
+
+func ignoreNotFound(err, notFound error) error {
+	if errors.Is(err, notFound) {
+		return nil
+	}
+	return err
+}
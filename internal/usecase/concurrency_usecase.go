@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"rate-limiter-go/internal/domain"
+	"time"
+)
+
+// AcquireConcurrency takes one in-flight slot for the client. Repository
+// errors fail open with a lease that is not held.
+func (uc *rateLimiterUseCase) AcquireConcurrency(ctx context.Context, clientID, scope string, limit int, ttl time.Duration) (*domain.ConcurrencyLease, bool) {
+	lease := &domain.ConcurrencyLease{
+		Key:   domain.ConcurrencyKey(clientID, scope),
+		ID:    newLeaseID(),
+		Limit: limit,
+		TTL:   ttl,
+	}
+
+	acquired, err := uc.repo.AcquireLease(ctx, lease)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to acquire concurrency lease", "client_id", clientID, "error", err)
+		lease.ID = ""
+		return lease, true
+	}
+
+	return lease, acquired
+}
+
+func (uc *rateLimiterUseCase) RenewConcurrency(ctx context.Context, lease *domain.ConcurrencyLease) {
+	if lease.ID == "" {
+		return
+	}
+
+	if err := uc.repo.RenewLease(ctx, lease); err != nil {
+		slog.WarnContext(ctx, "failed to renew concurrency lease", "key", lease.Key, "error", err)
+	}
+}
+
+func (uc *rateLimiterUseCase) ReleaseConcurrency(ctx context.Context, lease *domain.ConcurrencyLease) {
+	if lease.ID == "" {
+		return
+	}
+
+	if err := uc.repo.ReleaseLease(ctx, lease); err != nil {
+		slog.WarnContext(ctx, "failed to release concurrency lease", "key", lease.Key, "error", err)
+	}
+}
+
+func newLeaseID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"rate-limiter-go/internal/domain"
+	"time"
+)
+
+func (uc *rateLimiterUseCase) GetPenalty(ctx context.Context, clientID string) (*domain.Penalty, error) {
+	penalty, exists, err := uc.repo.GetPenalty(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrPenaltyNotFound
+	}
+
+	return penalty, nil
+}
+
+// Unban lifts the ban and forgets the client's offense history.
+func (uc *rateLimiterUseCase) Unban(ctx context.Context, clientID string) error {
+	old, err := uc.GetPenalty(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeletePenalty(ctx, clientID); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "penalty", clientID, old, nil)
+	return nil
+}
+
+// checkBan rejects a banned client without touching its counters. Penalty
+// lookups fail open.
+func (uc *rateLimiterUseCase) checkBan(ctx context.Context, clientID string) (domain.Decision, bool) {
+	penalty := uc.activeBan(ctx, clientID)
+	if penalty == nil {
+		return domain.Decision{}, false
+	}
+
+	return domain.NewBanDecision(penalty, time.Now()), true
+}
+
+// activeBan returns the client's penalty while it is banned.
+func (uc *rateLimiterUseCase) activeBan(ctx context.Context, clientID string) *domain.Penalty {
+	if !uc.config.Penalty.Enabled() {
+		return nil
+	}
+
+	penalty, exists, err := uc.repo.GetPenalty(ctx, clientID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get penalty", "client_id", clientID, "error", err)
+		return nil
+	}
+	if !exists || !penalty.Banned(time.Now()) {
+		return nil
+	}
+
+	return penalty
+}
+
+// penalize records a denied decision against the client and turns it into a
+// ban once the threshold is exceeded.
+func (uc *rateLimiterUseCase) penalize(ctx context.Context, clientID string, decision domain.Decision) domain.Decision {
+	if decision.Allowed {
+		return decision
+	}
+
+	if penalty := uc.recordViolation(ctx, clientID); penalty != nil {
+		return domain.NewBanDecision(penalty, time.Now())
+	}
+
+	return decision
+}
+
+// recordViolation counts a denied request against the client and returns its
+// penalty when that bans the client.
+func (uc *rateLimiterUseCase) recordViolation(ctx context.Context, clientID string) *domain.Penalty {
+	if !uc.config.Penalty.Enabled() {
+		return nil
+	}
+
+	penalty, exists, err := uc.repo.GetPenalty(ctx, clientID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get penalty", "client_id", clientID, "error", err)
+		return nil
+	}
+	if !exists {
+		penalty = &domain.Penalty{ClientID: clientID}
+	}
+
+	now := time.Now()
+	banned := penalty.RecordViolation(now, uc.config.Penalty)
+
+	if err := uc.repo.SavePenalty(ctx, penalty, penalty.Retention(now, uc.config.Penalty)); err != nil {
+		slog.ErrorContext(ctx, "failed to save penalty", "client_id", clientID, "error", err)
+		return nil
+	}
+
+	if !banned {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "client banned", "client_id", clientID, "until", penalty.BannedUntil, "offenses", penalty.Offenses)
+	return penalty
+}
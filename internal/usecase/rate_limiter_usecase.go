@@ -2,66 +2,631 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"rate-limiter-go/internal/audit"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/metrics"
 	"rate-limiter-go/internal/repository"
+	"rate-limiter-go/internal/tracing"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type RateLimiterUseCase interface {
-	CheckRateLimit(ctx context.Context, clientID string) (allowed bool, remaining int, resetTime int64)
-	ConfigureRateLimit(ctx context.Context, clientID string, maxRequests int, windowSizeMin int) error
+	CheckRateLimit(ctx context.Context, clientID string) domain.Decision
+	RefundRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule)
+	CheckRateLimitBatch(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeResult, bool, error)
+	ConfigureRateLimit(ctx context.Context, clientID string, config domain.RateLimitConfig) error
+	ConfigureRateLimitIfMatch(ctx context.Context, clientID string, config domain.RateLimitConfig, version int) (int, error)
+	AssignTier(ctx context.Context, clientID string, tier string) error
+	GetUsage(ctx context.Context, clientID string) (*domain.Usage, error)
+	ListClients(ctx context.Context, filter domain.ClientFilter, cursor string, limit int) ([]*domain.RateLimit, string, error)
+	GetClient(ctx context.Context, clientID string) (*domain.RateLimit, *domain.Usage, error)
+	ResetRateLimit(ctx context.Context, clientID string) error
+	DeleteRateLimit(ctx context.Context, clientID string) error
+
+	ListTiers(ctx context.Context) ([]*domain.Tier, error)
+	GetTier(ctx context.Context, name string) (*domain.Tier, error)
+	SaveTier(ctx context.Context, tier *domain.Tier) error
+	DeleteTier(ctx context.Context, name string) error
+
+	MatchRouteRules(ctx context.Context, method, path string, mode domain.RuleMatchMode) ([]*domain.RouteRule, error)
+	CheckRouteRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) domain.Decision
+	CheckGlobalRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) domain.Decision
+	RefundGlobalRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule)
+	ListRules(ctx context.Context) ([]*domain.RouteRule, error)
+	GetRule(ctx context.Context, id string) (*domain.RouteRule, error)
+	SaveRule(ctx context.Context, rule *domain.RouteRule) error
+	DeleteRule(ctx context.Context, id string) error
+
+	CheckAccess(ctx context.Context, clientID string, ip net.IP) (domain.AccessVerdict, *domain.AccessEntry)
+	SetPolicyAccessEntries(entries []*domain.AccessEntry)
+	ListAccessEntries(ctx context.Context) ([]*domain.AccessEntry, error)
+	GetAccessEntry(ctx context.Context, id string) (*domain.AccessEntry, error)
+	SaveAccessEntry(ctx context.Context, entry *domain.AccessEntry) error
+	DeleteAccessEntry(ctx context.Context, id string) error
+
+	GetPenalty(ctx context.Context, clientID string) (*domain.Penalty, error)
+	Unban(ctx context.Context, clientID string) error
+
+	AcquireConcurrency(ctx context.Context, clientID, scope string, limit int, ttl time.Duration) (*domain.ConcurrencyLease, bool)
+	RenewConcurrency(ctx context.Context, lease *domain.ConcurrencyLease)
+	ReleaseConcurrency(ctx context.Context, lease *domain.ConcurrencyLease)
+
+	ObserveRoute(rules []*domain.RouteRule, latency time.Duration, failed bool)
+	ListAdaptiveLimits(ctx context.Context) ([]domain.AdaptiveStatus, error)
+}
+
+type Config struct {
+	Penalty  domain.PenaltyConfig
+	Adaptive domain.AdaptiveConfig
+	Audit    *audit.Logger
+	// Cluster rejects parent limits and global rules, whose shared counters
+	// would live on another node than the clients consuming them.
+	Cluster bool
 }
 
 type rateLimiterUseCase struct {
-	repo repository.RateLimiterRepository
-	mu   sync.Mutex
+	repo   repository.RateLimiterRepository
+	config Config
+	mu     sync.Mutex
+
+	rulesMu       sync.RWMutex
+	rules         []*domain.RouteRule
+	rulesLoadedAt time.Time
+
+	accessMu       sync.RWMutex
+	policyAccess   []*domain.AccessEntry
+	access         []*domain.AccessEntry
+	accessLoadedAt time.Time
+
+	adaptiveMu sync.Mutex
+	adaptive   map[string]*domain.AdaptiveLimit
 }
 
 func NewRateLimiterUseCase(repo repository.RateLimiterRepository) RateLimiterUseCase {
+	return NewRateLimiterUseCaseWithConfig(repo, Config{})
+}
+
+func NewRateLimiterUseCaseWithConfig(repo repository.RateLimiterRepository, config Config) RateLimiterUseCase {
 	return &rateLimiterUseCase{
-		repo: repo,
+		repo:     repo,
+		config:   config,
+		adaptive: make(map[string]*domain.AdaptiveLimit),
+	}
+}
+
+func (uc *rateLimiterUseCase) CheckRateLimit(ctx context.Context, clientID string) domain.Decision {
+	ctx, span := startCheckSpan(ctx, "CheckRateLimit", clientID)
+	defer span.End()
+
+	decision := uc.checkRateLimit(ctx, clientID)
+	span.SetAttributes(tracing.DecisionAttributes(decision)...)
+	if !decision.Allowed {
+		uc.config.Audit.Denied(ctx, clientID, decision)
+	}
+	return decision
+}
+
+func (uc *rateLimiterUseCase) checkRateLimit(ctx context.Context, clientID string) domain.Decision {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if decision, banned := uc.checkBan(ctx, clientID); banned {
+		recordDecision(decision, "", "")
+		return decision
+	}
+
+	var results []domain.ConsumeResult
+	var allowed bool
+
+	requests, err := uc.resolveHierarchy(ctx, clientID, 1)
+	if err == nil {
+		results, allowed, err = uc.repo.ConsumeBatch(ctx, requests)
+		if err == nil {
+			limiting := limitingResult(results)
+			decision := uc.penalize(ctx, clientID, domain.NewDecision(limiting, allowed, time.Now()))
+			recordDecision(decision, "", tierLabel(limiting, results))
+			return decision
+		}
+	}
+
+	slog.ErrorContext(ctx, "failed to consume rate limit", "client_id", clientID, "error", err)
+
+	rateLimit := uc.repo.CreateDefault(ctx, clientID)
+	allowed = rateLimit.IsAllowed()
+	if allowed {
+		rateLimit.Increment()
+	}
+
+	result := domain.ConsumeResult{ClientID: clientID, Allowed: allowed, Policy: domain.DefaultTier, RateLimit: rateLimit}
+	decision := domain.NewDecision(result, allowed, time.Now())
+	recordDecision(decision, "", domain.DefaultTier)
+	return decision
+}
+
+// RefundRateLimit gives back the request CheckRateLimit took, or the one
+// CheckRouteRateLimit took when given the client's route rules.
+func (uc *rateLimiterUseCase) RefundRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	requests := uc.routeRequests(clientID, rules, -1)
+	if len(rules) == 0 {
+		var err error
+		if requests, err = uc.resolveHierarchy(ctx, clientID, -1); err != nil {
+			slog.ErrorContext(ctx, "failed to refund rate limit", "client_id", clientID, "error", err)
+			return
+		}
+	}
+
+	if _, _, err := uc.repo.ConsumeBatch(ctx, requests); err != nil {
+		slog.ErrorContext(ctx, "failed to refund rate limit", "client_id", clientID, "error", err)
+	}
+}
+
+// CheckRateLimitBatch consumes the whole batch or nothing. A banned client
+// rejects the batch, leaving the other clients uncharged.
+func (uc *rateLimiterUseCase) CheckRateLimitBatch(ctx context.Context, requests []domain.ConsumeRequest) ([]domain.ConsumeResult, bool, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	bans := make([]*domain.Penalty, len(requests))
+	banned := false
+	for i, req := range requests {
+		bans[i] = uc.activeBan(ctx, req.ClientID)
+		banned = banned || bans[i] != nil
+	}
+
+	var expanded []domain.ConsumeRequest
+	offsets := make([]int, len(requests)+1)
+
+	for i, req := range requests {
+		cost := req.Cost
+		if banned {
+			cost = 0
+		}
+
+		chain, err := uc.resolveHierarchy(ctx, req.ClientID, cost)
+		if err != nil {
+			return nil, false, err
+		}
+
+		expanded = append(expanded, chain...)
+		offsets[i+1] = len(expanded)
+	}
+
+	results, allowed, err := uc.repo.ConsumeBatch(ctx, expanded)
+	if err != nil {
+		return nil, false, err
 	}
+	allowed = allowed && !banned
+
+	now := time.Now()
+
+	limiting := make([]domain.ConsumeResult, len(requests))
+	for i, req := range requests {
+		chain := results[offsets[i]:offsets[i+1]]
+		limiting[i] = limitingResult(chain)
+		tier := tierLabel(limiting[i], chain)
+
+		switch {
+		case bans[i] != nil:
+			limiting[i].Allowed = false
+			limiting[i].Penalty = bans[i]
+			tier = ""
+		case !limiting[i].Allowed && !banned:
+			limiting[i].Penalty = uc.recordViolation(ctx, req.ClientID)
+		}
+
+		decision := limiting[i].Decision(now)
+		recordDecision(decision, "", tier)
+		if !decision.Allowed {
+			uc.config.Audit.Denied(ctx, req.ClientID, decision)
+		}
+		limiting[i].ClientID = req.ClientID
+	}
+
+	return limiting, allowed, nil
 }
 
-func (uc *rateLimiterUseCase) CheckRateLimit(ctx context.Context, clientID string) (allowed bool, remaining int, resetTime int64) {
+func (uc *rateLimiterUseCase) ConfigureRateLimit(ctx context.Context, clientID string, config domain.RateLimitConfig) error {
+	_, err := uc.ConfigureRateLimitIfMatch(ctx, clientID, config, domain.AnyVersion)
+	return err
+}
+
+// ConfigureRateLimitIfMatch saves config only while the stored config is at
+// version, and returns the version after the update.
+func (uc *rateLimiterUseCase) ConfigureRateLimitIfMatch(ctx context.Context, clientID string, config domain.RateLimitConfig, version int) (int, error) {
 	uc.mu.Lock()
 	defer uc.mu.Unlock()
 
 	rateLimit, exists, err := uc.repo.Get(ctx, clientID)
 	if err != nil {
-		rateLimit = uc.repo.CreateDefault(ctx, clientID)
+		return 0, err
 	}
-	if !exists {
+
+	var old *domain.RateLimitConfig
+	if exists {
+		old = configOf(rateLimit)
+	} else {
 		rateLimit = uc.repo.CreateDefault(ctx, clientID)
-		uc.repo.Save(ctx, rateLimit)
 	}
 
-	allowed = rateLimit.IsAllowed()
+	if version != domain.AnyVersion && version != rateLimit.Version {
+		return rateLimit.Version, domain.ErrVersionMismatch
+	}
 
-	if allowed {
-		rateLimit.Increment()
-		uc.repo.Save(ctx, rateLimit)
+	if config.ParentID != "" && uc.config.Cluster {
+		return 0, fmt.Errorf("%w: parent limits are %w", domain.ErrInvalidHierarchy, domain.ErrNotSupportedInCluster)
+	}
+	if err := uc.validateParent(ctx, clientID, config.ParentID); err != nil {
+		return 0, err
+	}
+	if err := uc.validateTier(ctx, config.Tier); err != nil {
+		return 0, err
 	}
 
-	remaining = rateLimit.GetRemainingRequests()
-	resetTime = rateLimit.GetResetTime().Unix()
+	rateLimit.ParentID = config.ParentID
+	rateLimit.Tier = config.Tier
+	rateLimit.Custom = config.MaxRequests > 0
+	if rateLimit.Custom {
+		rateLimit.MaxRequests = config.MaxRequests
+		rateLimit.CycleDuration = config.CycleDuration
+		rateLimit.Window = config.Window
+		rateLimit.TimeZone = config.TimeZone
+	}
+	bumpVersion(rateLimit, old)
 
-	return allowed, remaining, resetTime
+	if err := uc.repo.Save(ctx, rateLimit); err != nil {
+		return 0, err
+	}
+
+	uc.config.Audit.Change(ctx, "rate_limit", clientID, old, configOf(rateLimit))
+	return rateLimit.Version, nil
 }
 
-func (uc *rateLimiterUseCase) ConfigureRateLimit(ctx context.Context, clientID string, maxRequests int, cycleDurationMin int) error {
+func (uc *rateLimiterUseCase) AssignTier(ctx context.Context, clientID string, tier string) error {
 	uc.mu.Lock()
 	defer uc.mu.Unlock()
 
+	if err := uc.validateTier(ctx, tier); err != nil {
+		return err
+	}
+
 	rateLimit, exists, err := uc.repo.Get(ctx, clientID)
 	if err != nil {
 		return err
 	}
+
+	var old *domain.RateLimitConfig
+	if exists {
+		old = configOf(rateLimit)
+	} else {
+		rateLimit = uc.repo.CreateDefault(ctx, clientID)
+	}
+
+	rateLimit.Tier = tier
+	rateLimit.Custom = false
+	bumpVersion(rateLimit, old)
+
+	if err := uc.repo.Save(ctx, rateLimit); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "rate_limit", clientID, old, configOf(rateLimit))
+	return nil
+}
+
+func (uc *rateLimiterUseCase) GetUsage(ctx context.Context, clientID string) (*domain.Usage, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	rateLimit, exists, err := uc.repo.Get(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
 	if !exists {
 		rateLimit = uc.repo.CreateDefault(ctx, clientID)
 	}
 
-	rateLimit.MaxRequests = maxRequests
-	rateLimit.CycleDuration = cycleDurationMin
+	requests, err := uc.resolveHierarchy(ctx, clientID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := requests[0]
+	rateLimit.MaxRequests = effective.MaxRequests
+	rateLimit.CycleDuration = effective.CycleDuration
+	rateLimit.Window = effective.Window
+	rateLimit.TimeZone = effective.TimeZone
+
+	usage := rateLimit.Usage(time.Now())
+	return &usage, nil
+}
+
+func (uc *rateLimiterUseCase) ListTiers(ctx context.Context) ([]*domain.Tier, error) {
+	tiers, err := uc.repo.ListTiers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tier := range tiers {
+		if tier.Name == domain.DefaultTier {
+			return tiers, nil
+		}
+	}
+
+	return append([]*domain.Tier{uc.builtinDefaultTier(ctx)}, tiers...), nil
+}
+
+func (uc *rateLimiterUseCase) GetTier(ctx context.Context, name string) (*domain.Tier, error) {
+	tier, exists, err := uc.repo.GetTier(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists && name == domain.DefaultTier {
+		return uc.builtinDefaultTier(ctx), nil
+	}
+	if !exists {
+		return nil, domain.ErrTierNotFound
+	}
+
+	return tier, nil
+}
+
+func (uc *rateLimiterUseCase) SaveTier(ctx context.Context, tier *domain.Tier) error {
+	old, _, err := uc.repo.GetTier(ctx, tier.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.SaveTier(ctx, tier); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "tier", tier.Name, old, tier)
+	return nil
+}
+
+// DeleteTier removes a stored tier. A tier still assigned to clients is kept;
+// the default tier falls back to the built-in limits and can always go.
+func (uc *rateLimiterUseCase) DeleteTier(ctx context.Context, name string) error {
+	old, exists, err := uc.repo.GetTier(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrTierNotFound
+	}
+
+	if name != domain.DefaultTier {
+		inUse, err := uc.repo.HasTierClients(ctx, name)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return fmt.Errorf("%w: %s", domain.ErrTierInUse, name)
+		}
+	}
+
+	if err := uc.repo.DeleteTier(ctx, name); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "tier", name, old, nil)
+	return nil
+}
+
+// resolveHierarchy walks from clientID up through its parents and returns one
+// consume request per level, each carrying the limits it inherits unless the
+// level overrides them.
+func (uc *rateLimiterUseCase) resolveHierarchy(ctx context.Context, clientID string, cost int) ([]domain.ConsumeRequest, error) {
+	var chain []*domain.RateLimit
+	visited := make(map[string]bool)
+
+	for id := clientID; id != ""; {
+		if visited[id] || len(chain) >= domain.MaxHierarchyDepth {
+			return nil, fmt.Errorf("%w: cycle or depth exceeded at %s", domain.ErrInvalidHierarchy, id)
+		}
+		visited[id] = true
+
+		rateLimit, exists, err := uc.repo.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			rateLimit = uc.repo.CreateDefault(ctx, id)
+		}
+
+		chain = append(chain, rateLimit)
+		id = rateLimit.ParentID
+	}
+
+	requests := make([]domain.ConsumeRequest, len(chain))
+
+	var effective domain.ConsumeRequest
+	for i := len(chain) - 1; i >= 0; i-- {
+		rateLimit := chain[i]
+
+		own, ok, err := uc.ownLimits(ctx, rateLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case ok:
+			effective = own
+		case i == len(chain)-1:
+			effective = limitsOf(uc.repo.CreateDefault(ctx, rateLimit.ClientID))
+			effective.Policy = domain.DefaultTier
+		}
+
+		effective.ClientID = rateLimit.ClientID
+		effective.Cost = cost
+		requests[i] = effective
+	}
+
+	return requests, nil
+}
+
+// ownLimits returns the limits a level defines itself, either as a custom
+// override or through its assigned tier, so that tier changes apply at once.
+func (uc *rateLimiterUseCase) ownLimits(ctx context.Context, rateLimit *domain.RateLimit) (domain.ConsumeRequest, bool, error) {
+	if rateLimit.Custom {
+		own := limitsOf(rateLimit)
+		own.Policy = rateLimit.ClientID
+		return own, true, nil
+	}
+
+	if rateLimit.Tier != "" {
+		tier, exists, err := uc.repo.GetTier(ctx, rateLimit.Tier)
+		if err != nil {
+			return domain.ConsumeRequest{}, false, err
+		}
+		if exists {
+			return domain.ConsumeRequest{
+				MaxRequests:   tier.MaxRequests,
+				CycleDuration: tier.CycleDuration,
+				Window:        tier.Window,
+				TimeZone:      tier.TimeZone,
+				Policy:        tier.Name,
+			}, true, nil
+		}
+	}
+
+	return domain.ConsumeRequest{}, false, nil
+}
+
+func limitsOf(rateLimit *domain.RateLimit) domain.ConsumeRequest {
+	return domain.ConsumeRequest{
+		MaxRequests:   rateLimit.MaxRequests,
+		CycleDuration: rateLimit.CycleDuration,
+		Window:        rateLimit.Window,
+		TimeZone:      rateLimit.TimeZone,
+	}
+}
+
+// builtinDefaultTier describes the limits CreateDefault falls back to while no
+// default tier has been stored.
+func (uc *rateLimiterUseCase) builtinDefaultTier(ctx context.Context) *domain.Tier {
+	defaults := uc.repo.CreateDefault(ctx, "")
+
+	return &domain.Tier{
+		Name:          domain.DefaultTier,
+		MaxRequests:   defaults.MaxRequests,
+		CycleDuration: defaults.CycleDuration,
+		Window:        defaults.Window,
+		TimeZone:      defaults.TimeZone,
+	}
+}
+
+func (uc *rateLimiterUseCase) validateTier(ctx context.Context, name string) error {
+	if name == "" || name == domain.DefaultTier {
+		return nil
+	}
+
+	_, exists, err := uc.repo.GetTier(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", domain.ErrTierNotFound, name)
+	}
+
+	return nil
+}
+
+func (uc *rateLimiterUseCase) validateParent(ctx context.Context, clientID, parentID string) error {
+	for id, depth := parentID, 1; id != ""; depth++ {
+		if id == clientID {
+			return fmt.Errorf("%w: %s cannot be its own ancestor", domain.ErrInvalidHierarchy, clientID)
+		}
+		if depth >= domain.MaxHierarchyDepth {
+			return fmt.Errorf("%w: hierarchy deeper than %d levels", domain.ErrInvalidHierarchy, domain.MaxHierarchyDepth)
+		}
+
+		parent, exists, err := uc.repo.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+		id = parent.ParentID
+	}
+
+	return nil
+}
+
+// limitingResult picks the level that decided the outcome: the first blocked
+// level, or the one with the fewest remaining requests.
+func limitingResult(results []domain.ConsumeResult) domain.ConsumeResult {
+	limiting := results[0]
+
+	for _, result := range results[1:] {
+		if !limiting.Allowed {
+			break
+		}
+		if !result.Allowed || result.RateLimit.GetRemainingRequests() < limiting.RateLimit.GetRemainingRequests() {
+			limiting = result
+		}
+	}
+
+	return limiting
+}
+
+// configOf is the configured part of a client's rate limit, as recorded in
+// the audit log.
+func configOf(rateLimit *domain.RateLimit) *domain.RateLimitConfig {
+	config := rateLimit.Config()
+	return &config
+}
+
+// bumpVersion moves the config version forward when the update changed the
+// configuration, so repeating the same PUT keeps its ETag.
+func bumpVersion(rateLimit *domain.RateLimit, old *domain.RateLimitConfig) {
+	var previous domain.RateLimitConfig
+	if old != nil {
+		previous = *old
+	}
+	if previous != rateLimit.Config() {
+		rateLimit.Version++
+	}
+}
+
+// tierLabel names the tier behind the limiting result of a hierarchy for
+// metrics. Limits set on the client or inherited from a parent are named
+// after that client, so they are folded into "custom" to keep client IDs out
+// of labels.
+func tierLabel(limiting domain.ConsumeResult, chain []domain.ConsumeResult) string {
+	for _, result := range chain {
+		if limiting.Policy == result.ClientID {
+			return "custom"
+		}
+	}
+	return limiting.Policy
+}
+
+func recordDecision(decision domain.Decision, rule, tier string) {
+	result := "denied"
+	switch {
+	case decision.Banned:
+		result = "banned"
+	case decision.Shed:
+		result = "shed"
+	case decision.Allowed:
+		result = "allowed"
+	}
+
+	metrics.Decisions.WithLabelValues(result, rule, tier).Inc()
+}
 
-	return uc.repo.Save(ctx, rateLimit)
+func startCheckSpan(ctx context.Context, name, clientID string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attribute.String("ratelimit.client_id", clientID)))
 }
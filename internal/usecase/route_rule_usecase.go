@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/tracing"
+	"time"
+)
+
+const rulesCacheTTL = 5 * time.Second
+
+func (uc *rateLimiterUseCase) MatchRouteRules(ctx context.Context, method, path string, mode domain.RuleMatchMode) ([]*domain.RouteRule, error) {
+	rules, err := uc.cachedRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.MatchRules(rules, method, path, mode), nil
+}
+
+// CheckRouteRateLimit consumes one request from the client's counter of every
+// given rule, all or nothing. The rules replace the client's own, tier and
+// parent limits for the request. When the repository fails the request is
+// admitted, since the rule counters cannot be read.
+func (uc *rateLimiterUseCase) CheckRouteRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) domain.Decision {
+	ctx, span := startCheckSpan(ctx, "CheckRouteRateLimit", clientID)
+	defer span.End()
+
+	decision := uc.checkRouteRateLimit(ctx, clientID, rules)
+	span.SetAttributes(tracing.DecisionAttributes(decision)...)
+	if !decision.Allowed {
+		uc.config.Audit.Denied(ctx, clientID, decision)
+	}
+	return decision
+}
+
+func (uc *rateLimiterUseCase) checkRouteRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) domain.Decision {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if decision, banned := uc.checkBan(ctx, clientID); banned {
+		recordDecision(decision, "", "")
+		return decision
+	}
+
+	requests := uc.routeRequests(clientID, rules, 1)
+
+	results, allowed, err := uc.repo.ConsumeBatch(ctx, requests)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to consume route rules", "client_id", clientID, "error", err)
+
+		rateLimit := &domain.RateLimit{
+			ClientID:      requests[0].ClientID,
+			MaxRequests:   rules[0].MaxRequests,
+			CycleDuration: rules[0].CycleDuration,
+			CycleStart:    time.Now(),
+		}
+		result := domain.ConsumeResult{ClientID: clientID, Allowed: true, Policy: rules[0].ID, RateLimit: rateLimit}
+		decision := domain.NewDecision(result, true, time.Now())
+		recordDecision(decision, rules[0].ID, "")
+		return decision
+	}
+
+	decision := uc.penalize(ctx, clientID, domain.NewDecision(limitingResult(results), allowed, time.Now()))
+	recordDecision(decision, decision.Policy, "")
+	return decision
+}
+
+// CheckGlobalRateLimit consumes one request from the shared counter of every
+// given global rule. The client's tier priority decides how much of each
+// budget it may use.
+func (uc *rateLimiterUseCase) CheckGlobalRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) domain.Decision {
+	ctx, span := startCheckSpan(ctx, "CheckGlobalRateLimit", clientID)
+	defer span.End()
+
+	decision := uc.checkGlobalRateLimit(ctx, clientID, rules)
+	span.SetAttributes(tracing.DecisionAttributes(decision)...)
+	if !decision.Allowed {
+		uc.config.Audit.Denied(ctx, clientID, decision)
+	}
+	return decision
+}
+
+func (uc *rateLimiterUseCase) checkGlobalRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) domain.Decision {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	results, allowed, err := uc.repo.ConsumeBatch(ctx, uc.globalRequests(ctx, clientID, rules, 1))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to consume global rules", "client_id", clientID, "error", err)
+		return domain.Decision{Allowed: true, Policy: rules[0].ID}
+	}
+
+	decision := domain.NewDecision(limitingResult(results), allowed, time.Now())
+	if !allowed {
+		// Admitted requests are counted by the per-client decision that follows.
+		decision.Shed = true
+		recordDecision(decision, decision.Policy, "")
+	}
+	return decision
+}
+
+// RefundGlobalRateLimit gives back the request CheckGlobalRateLimit took
+// when the client is refused afterwards, so banned or limited clients do not
+// use up the shared budget.
+func (uc *rateLimiterUseCase) RefundGlobalRateLimit(ctx context.Context, clientID string, rules []*domain.RouteRule) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if _, _, err := uc.repo.ConsumeBatch(ctx, uc.globalRequests(ctx, clientID, rules, -1)); err != nil {
+		slog.ErrorContext(ctx, "failed to refund global rules", "client_id", clientID, "error", err)
+	}
+}
+
+func (uc *rateLimiterUseCase) routeRequests(clientID string, rules []*domain.RouteRule, cost int) []domain.ConsumeRequest {
+	requests := make([]domain.ConsumeRequest, len(rules))
+	for i, rule := range rules {
+		requests[i] = domain.ConsumeRequest{
+			ClientID:      rule.CounterKey(clientID),
+			Cost:          cost,
+			MaxRequests:   uc.adaptiveMax(rule, rule.MaxRequests),
+			CycleDuration: rule.CycleDuration,
+			Policy:        rule.ID,
+		}
+	}
+	return requests
+}
+
+func (uc *rateLimiterUseCase) globalRequests(ctx context.Context, clientID string, rules []*domain.RouteRule, cost int) []domain.ConsumeRequest {
+	priority := uc.clientPriority(ctx, clientID)
+
+	requests := make([]domain.ConsumeRequest, len(rules))
+	for i, rule := range rules {
+		requests[i] = domain.ConsumeRequest{
+			ClientID:      rule.GlobalCounterKey(),
+			Cost:          cost,
+			MaxRequests:   uc.adaptiveMax(rule, rule.EffectiveMax(priority)),
+			CycleDuration: rule.CycleDuration,
+			Policy:        rule.ID,
+		}
+	}
+	return requests
+}
+
+// clientPriority is the priority of the first tier found walking up the
+// client's hierarchy, or of the default tier.
+func (uc *rateLimiterUseCase) clientPriority(ctx context.Context, clientID string) int {
+	name := domain.DefaultTier
+
+	for id, depth := clientID, 0; id != "" && depth < domain.MaxHierarchyDepth; depth++ {
+		rateLimit, exists, err := uc.repo.Get(ctx, id)
+		if err != nil || !exists {
+			break
+		}
+		if rateLimit.Tier != "" {
+			name = rateLimit.Tier
+			break
+		}
+		id = rateLimit.ParentID
+	}
+
+	tier, exists, err := uc.repo.GetTier(ctx, name)
+	if err != nil || !exists {
+		return 0
+	}
+
+	return tier.Priority
+}
+
+func (uc *rateLimiterUseCase) ListRules(ctx context.Context) ([]*domain.RouteRule, error) {
+	return uc.repo.ListRules(ctx)
+}
+
+func (uc *rateLimiterUseCase) GetRule(ctx context.Context, id string) (*domain.RouteRule, error) {
+	rule, exists, err := uc.repo.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrRuleNotFound
+	}
+
+	return rule, nil
+}
+
+func (uc *rateLimiterUseCase) SaveRule(ctx context.Context, rule *domain.RouteRule) error {
+	if rule.Global && uc.config.Cluster {
+		return fmt.Errorf("%w: global rules", domain.ErrNotSupportedInCluster)
+	}
+
+	defer uc.invalidateRules()
+
+	old, _, err := uc.repo.GetRule(ctx, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.SaveRule(ctx, rule); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "rule", rule.ID, old, rule)
+	return nil
+}
+
+func (uc *rateLimiterUseCase) DeleteRule(ctx context.Context, id string) error {
+	defer uc.invalidateRules()
+
+	old, err := uc.GetRule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+
+	uc.config.Audit.Change(ctx, "rule", id, old, nil)
+	return nil
+}
+
+// cachedRules keeps the rule set for a few seconds so the middleware does not
+// hit the repository on every request; other replicas pick up changes once
+// their cache expires.
+func (uc *rateLimiterUseCase) cachedRules(ctx context.Context) ([]*domain.RouteRule, error) {
+	uc.rulesMu.RLock()
+	rules, loadedAt := uc.rules, uc.rulesLoadedAt
+	uc.rulesMu.RUnlock()
+
+	if !loadedAt.IsZero() && time.Since(loadedAt) < rulesCacheTTL {
+		return rules, nil
+	}
+
+	rules, err := uc.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.rulesMu.Lock()
+	uc.rules, uc.rulesLoadedAt = rules, time.Now()
+	uc.rulesMu.Unlock()
+
+	return rules, nil
+}
+
+func (uc *rateLimiterUseCase) invalidateRules() {
+	uc.rulesMu.Lock()
+	uc.rulesLoadedAt = time.Time{}
+	uc.rulesMu.Unlock()
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+type denial struct {
+	decision Decision
+	until    time.Time
+}
+
+// denyCache remembers denied clients until their Retry-After elapses, capped
+// at ttl, so callers over their limit do not reach the service.
+type denyCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]denial
+	nextSweep time.Time
+}
+
+func newDenyCache(ttl time.Duration) *denyCache {
+	return &denyCache{
+		ttl:     ttl,
+		entries: make(map[string]denial),
+	}
+}
+
+func (c *denyCache) get(clientID string, now time.Time) (Decision, bool) {
+	if c.ttl < 0 {
+		return Decision{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[clientID]
+	if !ok {
+		return Decision{}, false
+	}
+	if !now.Before(entry.until) {
+		delete(c.entries, clientID)
+		return Decision{}, false
+	}
+
+	decision := entry.decision
+	decision.Remaining = 0
+	decision.RetryAfter = entry.until.Sub(now)
+	decision.Cached = true
+	return decision, true
+}
+
+func (c *denyCache) put(clientID string, decision Decision, now time.Time) {
+	if c.ttl < 0 || decision.RetryAfter <= 0 {
+		return
+	}
+
+	ttl := decision.RetryAfter
+	if ttl > c.ttl {
+		ttl = c.ttl
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Expired entries are dropped at most once per ttl so clients that are
+	// never checked again do not accumulate. No entry outlives ttl, so the
+	// cache never holds more than two ttl's worth of denials.
+	if !now.Before(c.nextSweep) {
+		for id, entry := range c.entries {
+			if !now.Before(entry.until) {
+				delete(c.entries, id)
+			}
+		}
+		c.nextSweep = now.Add(c.ttl)
+	}
+	c.entries[clientID] = denial{decision: decision, until: now.Add(ttl)}
+}
+
+func (c *denyCache) forget(clientID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, clientID)
+}
@@ -0,0 +1,439 @@
+// Package client is a Go client for the rate limiter service.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout      = 2 * time.Second
+	defaultRetries      = 2
+	defaultRetryBackoff = 50 * time.Millisecond
+	defaultDenyCacheTTL = 5 * time.Second
+	defaultMaxIdleConns = 16
+	maxRetryBackoff     = 2 * time.Second
+)
+
+type Options struct {
+	// BaseURL is where the service listens, e.g. http://limiter:1234. Admin
+	// operations go to AdminURL when the admin API has its own listener.
+	BaseURL  string
+	AdminURL string
+	// Token is sent as a bearer token on admin operations.
+	Token string
+	// Timeout bounds each attempt. Retries is the number of extra attempts
+	// after a transport error or a 502, 503 or 504; negative disables them.
+	Timeout      time.Duration
+	Retries      int
+	RetryBackoff time.Duration
+	// DenyCacheTTL caps how long a denial is answered locally; denials are
+	// cached for their Retry-After. Negative disables the cache.
+	DenyCacheTTL time.Duration
+	// FailOpen allows requests while the service is unavailable.
+	FailOpen bool
+	// HTTPClient replaces the pooled client, e.g. to present a client
+	// certificate. Timeout still applies per attempt.
+	HTTPClient *http.Client
+}
+
+type Client struct {
+	httpClient   *http.Client
+	baseURL      string
+	adminURL     string
+	token        string
+	timeout      time.Duration
+	retries      int
+	retryBackoff time.Duration
+	failOpen     bool
+	denials      *denyCache
+}
+
+func New(opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	} else if opts.Retries == 0 {
+		opts.Retries = defaultRetries
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	if opts.DenyCacheTTL == 0 {
+		opts.DenyCacheTTL = defaultDenyCacheTTL
+	}
+	if opts.AdminURL == "" {
+		opts.AdminURL = opts.BaseURL
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = defaultMaxIdleConns
+		transport.MaxIdleConnsPerHost = defaultMaxIdleConns
+		httpClient = &http.Client{Transport: transport}
+	}
+
+	return &Client{
+		httpClient:   httpClient,
+		baseURL:      opts.BaseURL,
+		adminURL:     opts.AdminURL,
+		token:        opts.Token,
+		timeout:      opts.Timeout,
+		retries:      opts.Retries,
+		retryBackoff: opts.RetryBackoff,
+		failOpen:     opts.FailOpen,
+		denials:      newDenyCache(opts.DenyCacheTTL),
+	}
+}
+
+// Check consumes one request for clientID. A client denied within its
+// Retry-After is denied again from the local cache. When the service is
+// unavailable Check returns the error with a decision that allows the request
+// under FailOpen and denies it otherwise.
+//
+// A retried check may be counted more than once if the service handled an
+// attempt whose response was lost.
+func (c *Client) Check(ctx context.Context, clientID string) (Decision, error) {
+	if decision, ok := c.denials.get(clientID, time.Now()); ok {
+		return decision, nil
+	}
+
+	path, err := clientPath("/api/v1/rate-limit/%s", clientID)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	var resp decisionResponse
+	if _, err := c.do(ctx, http.MethodGet, c.baseURL, path, nil, nil, &resp); err != nil {
+		return Decision{Allowed: c.failOpen && errors.Is(err, ErrUnavailable)}, err
+	}
+
+	decision := resp.decision()
+	if !decision.Allowed {
+		c.denials.put(clientID, decision, time.Now())
+	}
+	return decision, nil
+}
+
+// Peek reports a client's usage and penalty without consuming a request.
+func (c *Client) Peek(ctx context.Context, clientID string) (*Usage, error) {
+	path, err := clientPath("/api/v1/rate-limit/%s/usage", clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage Usage
+	if _, err := c.do(ctx, http.MethodGet, c.adminURL, path, nil, nil, &usage); err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+// Configure replaces a client's configuration and returns its new version.
+func (c *Client) Configure(ctx context.Context, clientID string, config RateLimitConfig) (int, error) {
+	return c.ConfigureIfMatch(ctx, clientID, config, AnyVersion)
+}
+
+// ConfigureIfMatch replaces a client's configuration only while it is still
+// at version, failing with ErrVersionMismatch otherwise.
+func (c *Client) ConfigureIfMatch(ctx context.Context, clientID string, config RateLimitConfig, version int) (int, error) {
+	return c.configure(ctx, http.MethodPut, clientID, config, version)
+}
+
+// Patch changes the fields set in patch. Unless version is AnyVersion the
+// change is only made while the configuration is still at version.
+func (c *Client) Patch(ctx context.Context, clientID string, patch RateLimitPatch, version int) (int, error) {
+	return c.configure(ctx, http.MethodPatch, clientID, patch, version)
+}
+
+func (c *Client) configure(ctx context.Context, method, clientID string, body interface{}, version int) (int, error) {
+	path, err := clientPath("/api/v1/rate-limit/%s", clientID)
+	if err != nil {
+		return 0, err
+	}
+
+	header := http.Header{}
+	if version != AnyVersion {
+		header.Set("If-Match", strconv.Quote(strconv.Itoa(version)))
+	}
+
+	resp, err := c.do(ctx, method, c.adminURL, path, header, body, nil)
+	if err != nil {
+		return 0, err
+	}
+	c.denials.forget(clientID)
+
+	return etagVersion(resp.Header.Get("ETag")), nil
+}
+
+func (c *Client) AssignTier(ctx context.Context, clientID, tier string) error {
+	path, err := clientPath("/api/v1/rate-limit/%s/tier", clientID)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]string{"tier": tier}
+	if _, err := c.do(ctx, http.MethodPut, c.adminURL, path, nil, body, nil); err != nil {
+		return err
+	}
+	c.denials.forget(clientID)
+	return nil
+}
+
+func (c *Client) UnassignTier(ctx context.Context, clientID string) error {
+	path, err := clientPath("/api/v1/rate-limit/%s/tier", clientID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.do(ctx, http.MethodDelete, c.adminURL, path, nil, nil, nil); err != nil {
+		return err
+	}
+	c.denials.forget(clientID)
+	return nil
+}
+
+func (c *Client) Unban(ctx context.Context, clientID string) error {
+	path, err := clientPath("/api/v1/rate-limit/%s/ban", clientID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.do(ctx, http.MethodDelete, c.adminURL, path, nil, nil, nil); err != nil {
+		return err
+	}
+	c.denials.forget(clientID)
+	return nil
+}
+
+func (c *Client) ListClients(ctx context.Context, opts ListOptions) (*ClientPage, error) {
+	query := url.Values{}
+	if opts.Prefix != "" {
+		query.Set("prefix", opts.Prefix)
+	}
+	if opts.Tier != "" {
+		query.Set("tier", opts.Tier)
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	path := "/api/v1/clients"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var page ClientPage
+	if _, err := c.do(ctx, http.MethodGet, c.adminURL, path, nil, nil, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+func (c *Client) GetClient(ctx context.Context, clientID string) (*ClientInfo, error) {
+	path, err := clientPath("/api/v1/clients/%s", clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var info ClientInfo
+	if _, err := c.do(ctx, http.MethodGet, c.adminURL, path, nil, nil, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// ResetClient clears a client's counters and keeps its configuration.
+func (c *Client) ResetClient(ctx context.Context, clientID string) error {
+	path, err := clientPath("/api/v1/clients/%s/reset", clientID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.do(ctx, http.MethodPost, c.adminURL, path, nil, nil, nil); err != nil {
+		return err
+	}
+	c.denials.forget(clientID)
+	return nil
+}
+
+func (c *Client) DeleteClient(ctx context.Context, clientID string) error {
+	path, err := clientPath("/api/v1/clients/%s", clientID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.do(ctx, http.MethodDelete, c.adminURL, path, nil, nil, nil); err != nil {
+		return err
+	}
+	c.denials.forget(clientID)
+	return nil
+}
+
+// BulkConfigure saves many configurations. Nothing is saved when any entry
+// is invalid; otherwise the outcome of each entry is reported separately.
+func (c *Client) BulkConfigure(ctx context.Context, items []BulkConfigureItem) (*BulkConfigureResult, error) {
+	var result BulkConfigureResult
+	if _, err := c.do(ctx, http.MethodPut, c.adminURL, "/api/v1/clients", nil, items, &result); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		c.denials.forget(item.ClientID)
+	}
+	return &result, nil
+}
+
+// do sends a request, retrying transport errors and gateway errors, and
+// decodes the data of the response envelope into out.
+func (c *Client) do(ctx context.Context, method, baseURL, path string, header http.Header, body, out interface{}) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to marshal: %w", err)
+		}
+	}
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, data, err := c.attempt(ctx, method, baseURL+path, header, payload)
+		if err == nil {
+			if out != nil {
+				if err := json.Unmarshal(data, out); err != nil {
+					return nil, fmt.Errorf("failed to decode response: %w", err)
+				}
+			}
+			return resp, nil
+		}
+
+		if attempt >= c.retries || !retryable(ctx, resp) {
+			return nil, err
+		}
+
+		wait := backoff
+		if resp != nil {
+			if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+		}
+		if wait > maxRetryBackoff {
+			wait = maxRetryBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt returns the response with the data of its envelope, or an error
+// together with the response when the service answered with an error.
+func (c *Client) attempt(ctx context.Context, method, endpoint string, header http.Header, payload []byte) (*http.Response, json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reach limiter: %w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	// The body is read to the end so the connection can be reused.
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, fmt.Errorf("failed to read response: %w: %w", ErrUnavailable, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(raw, apiErr)
+		apiErr.StatusCode = resp.StatusCode
+		return resp, nil, apiErr
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return resp, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return resp, envelope.Data, nil
+}
+
+// retryable reports whether a failed attempt may succeed when repeated: the
+// service was not reached or a gateway in front of it failed.
+func retryable(ctx context.Context, resp *http.Response) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if resp == nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// etagVersion parses the configuration version from an ETag such as "3".
+func etagVersion(tag string) int {
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// clientPath escapes clientID into format. IDs holding "/" are rejected
+// because the service does not unescape "%2F" in path parameters, so they
+// would be counted under another ID.
+func clientPath(format, clientID string) (string, error) {
+	if clientID == "" || strings.Contains(clientID, "/") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidClientID, clientID)
+	}
+	return fmt.Sprintf(format, url.PathEscape(clientID)), nil
+}
@@ -0,0 +1,350 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/repository/memory"
+	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const adminToken = "operator-token"
+
+type testServer struct {
+	*httptest.Server
+	requests    atomic.Int32
+	connections atomic.Int32
+	// failures is the number of upcoming requests answered with 503.
+	failures atomic.Int32
+	delay    atomic.Int64
+}
+
+func newTestServer(t *testing.T) *testServer {
+	uc := usecase.NewRateLimiterUseCase(memory.NewRateLimiterMemoryRepository(100, 1))
+	h := handler.NewRateLimiterHandler(uc)
+	th := handler.NewTierHandler(uc)
+	ch := handler.NewClientHandler(uc)
+
+	e := echo.New()
+	e.HTTPErrorHandler = response.HTTPErrorHandler
+
+	api := e.Group("/api/v1")
+	api.GET("/rate-limit/:clientID", h.CheckRateLimit)
+
+	admin := e.Group("/api/v1")
+	admin.Use(handler.AdminAuthMiddleware(handler.AdminAuthConfig{
+		Tokens: []handler.AdminCredential{{Name: "test", Role: handler.RoleOperator, Secret: adminToken}},
+	}))
+	admin.PUT("/rate-limit/:clientID", h.ConfigureRateLimit)
+	admin.PATCH("/rate-limit/:clientID", h.PatchRateLimit)
+	admin.GET("/rate-limit/:clientID/usage", h.GetUsage)
+	admin.PUT("/rate-limit/:clientID/tier", th.AssignTier)
+	admin.DELETE("/rate-limit/:clientID/tier", th.UnassignTier)
+	admin.DELETE("/rate-limit/:clientID/ban", h.Unban)
+	admin.PUT("/tiers/:name", th.SaveTier)
+	admin.GET("/clients", ch.ListClients)
+	admin.PUT("/clients", ch.BulkConfigure)
+	admin.GET("/clients/:clientID", ch.GetClient)
+	admin.DELETE("/clients/:clientID", ch.DeleteClient)
+	admin.POST("/clients/:clientID/reset", ch.ResetClient)
+
+	s := &testServer{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if delay := time.Duration(s.delay.Load()); delay > 0 {
+			time.Sleep(delay)
+		}
+		if s.failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		e.ServeHTTP(w, r)
+	}))
+	s.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.connections.Add(1)
+		}
+	}
+	s.Start()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newTestClient(s *testServer, opts Options) *Client {
+	opts.BaseURL = s.URL
+	opts.Token = adminToken
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = time.Millisecond
+	}
+	return New(opts)
+}
+
+func TestCheck_CachesDenials(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(s, Options{})
+	ctx := context.Background()
+
+	if _, err := c.Configure(ctx, "alice", RateLimitConfig{MaxRequests: 2, CycleDuration: 1}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		decision, err := c.Check(ctx, "alice")
+		if err != nil || !decision.Allowed {
+			t.Fatalf("Expected request %d to be allowed, got %+v, %v", i+1, decision, err)
+		}
+	}
+
+	decision, err := c.Check(ctx, "alice")
+	if err != nil || decision.Allowed || decision.Cached || decision.RetryAfter <= 0 {
+		t.Fatalf("Expected a denial with Retry-After, got %+v, %v", decision, err)
+	}
+
+	before := s.requests.Load()
+	decision, err = c.Check(ctx, "alice")
+	if err != nil || decision.Allowed || !decision.Cached {
+		t.Fatalf("Expected a cached denial, got %+v, %v", decision, err)
+	}
+	if s.requests.Load() != before {
+		t.Error("Expected a cached denial not to reach the service")
+	}
+
+	if err := c.ResetClient(ctx, "alice"); err != nil {
+		t.Fatalf("ResetClient failed: %v", err)
+	}
+	if decision, _ := c.Check(ctx, "alice"); !decision.Allowed {
+		t.Errorf("Expected reset to clear the cached denial, got %+v", decision)
+	}
+}
+
+func TestDenyCache_RespectsRetryAfter(t *testing.T) {
+	cache := newDenyCache(time.Minute)
+	now := time.Now()
+
+	cache.put("alice", Decision{RetryAfter: 2 * time.Second}, now)
+
+	if decision, ok := cache.get("alice", now.Add(time.Second)); !ok || decision.RetryAfter != time.Second {
+		t.Errorf("Expected a cached denial with 1s left, got %+v, %v", decision, ok)
+	}
+	if _, ok := cache.get("alice", now.Add(2*time.Second)); ok {
+		t.Error("Expected the denial to expire after its Retry-After")
+	}
+
+	capped := newDenyCache(time.Second)
+	capped.put("bob", Decision{RetryAfter: time.Hour}, now)
+	if _, ok := capped.get("bob", now.Add(time.Second)); ok {
+		t.Error("Expected the cache TTL to cap Retry-After")
+	}
+
+	disabled := newDenyCache(-1)
+	disabled.put("carol", Decision{RetryAfter: time.Hour}, now)
+	if _, ok := disabled.get("carol", now); ok {
+		t.Error("Expected a negative TTL to disable the cache")
+	}
+}
+
+func TestDenyCache_SweepsExpiredEntries(t *testing.T) {
+	cache := newDenyCache(time.Second)
+	now := time.Now()
+
+	cache.put("alice", Decision{RetryAfter: time.Second}, now)
+	cache.put("bob", Decision{RetryAfter: time.Second}, now.Add(500*time.Millisecond))
+	if len(cache.entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(cache.entries))
+	}
+
+	cache.put("carol", Decision{RetryAfter: time.Second}, now.Add(2*time.Second))
+	if _, ok := cache.entries["alice"]; ok || len(cache.entries) != 1 {
+		t.Errorf("Expected expired entries to be swept, got %d entries", len(cache.entries))
+	}
+}
+
+func TestCheck_RejectsClientIDWithSlash(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(s, Options{FailOpen: true})
+
+	decision, err := c.Check(context.Background(), "a/b")
+	if !errors.Is(err, ErrInvalidClientID) || decision.Allowed {
+		t.Errorf("Expected ErrInvalidClientID, got %+v, %v", decision, err)
+	}
+	if s.requests.Load() != 0 {
+		t.Error("Expected the invalid ID not to reach the service")
+	}
+}
+
+func TestCheck_RetriesUnavailableService(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	s.failures.Store(2)
+	decision, err := newTestClient(s, Options{Retries: 2}).Check(ctx, "alice")
+	if err != nil || !decision.Allowed {
+		t.Fatalf("Expected the third attempt to succeed, got %+v, %v", decision, err)
+	}
+	if s.requests.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", s.requests.Load())
+	}
+
+	s.failures.Store(1)
+	_, err = newTestClient(s, Options{Retries: -1}).Check(ctx, "alice")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable without retries, got %v", err)
+	}
+}
+
+func TestCheck_FailOpenAndClosed(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	s.failures.Store(100)
+
+	decision, err := newTestClient(s, Options{Retries: -1, FailOpen: true}).Check(ctx, "alice")
+	if !errors.Is(err, ErrUnavailable) || !decision.Allowed {
+		t.Errorf("Expected fail-open to allow with an error, got %+v, %v", decision, err)
+	}
+
+	decision, err = newTestClient(s, Options{Retries: -1}).Check(ctx, "alice")
+	if !errors.Is(err, ErrUnavailable) || decision.Allowed {
+		t.Errorf("Expected fail-closed to deny with an error, got %+v, %v", decision, err)
+	}
+
+	s.Close()
+	decision, err = newTestClient(s, Options{Retries: -1, FailOpen: true}).Check(ctx, "alice")
+	if !errors.Is(err, ErrUnavailable) || !decision.Allowed {
+		t.Errorf("Expected fail-open on a connection error, got %+v, %v", decision, err)
+	}
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	s := newTestServer(t)
+	s.delay.Store(int64(200 * time.Millisecond))
+
+	start := time.Now()
+	_, err := newTestClient(s, Options{Timeout: 20 * time.Millisecond, Retries: 1}).Check(context.Background(), "alice")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected a timeout to be reported as unavailable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected each attempt to time out, took %v", elapsed)
+	}
+}
+
+func TestClient_ReusesConnections(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(s, Options{})
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.Check(ctx, "alice"); err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+	}
+	if _, err := c.GetClient(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := c.Check(ctx, "alice"); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	if n := s.connections.Load(); n != 1 {
+		t.Errorf("Expected 1 connection, got %d", n)
+	}
+}
+
+func TestClient_ConfigureAndAdmin(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(s, Options{})
+	ctx := context.Background()
+
+	version, err := c.Configure(ctx, "team-a", RateLimitConfig{MaxRequests: 5, CycleDuration: 1})
+	if err != nil || version != 1 {
+		t.Fatalf("Expected version 1, got %d, %v", version, err)
+	}
+
+	maxRequests := 10
+	if _, err := c.Patch(ctx, "team-a", RateLimitPatch{MaxRequests: &maxRequests}, 0); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+	version, err = c.Patch(ctx, "team-a", RateLimitPatch{MaxRequests: &maxRequests}, version)
+	if err != nil || version != 2 {
+		t.Fatalf("Expected version 2, got %d, %v", version, err)
+	}
+
+	_, err = c.Configure(ctx, "team-a", RateLimitConfig{MaxRequests: 5, Window: "fortnight"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Errors) == 0 {
+		t.Errorf("Expected field errors, got %v", err)
+	}
+
+	if _, err := c.Check(ctx, "team-a"); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	usage, err := c.Peek(ctx, "team-a")
+	if err != nil || usage.MaxRequests != 10 || usage.Current == nil || usage.Current.Count != 1 {
+		t.Fatalf("Unexpected usage %+v, %v", usage, err)
+	}
+	if after, _ := c.Peek(ctx, "team-a"); after.Current.Count != 1 {
+		t.Errorf("Expected Peek not to consume, got count %d", after.Current.Count)
+	}
+
+	info, err := c.GetClient(ctx, "team-a")
+	if err != nil || info.Version != 2 || info.MaxRequests == nil || *info.MaxRequests != 10 || info.Usage == nil {
+		t.Fatalf("Unexpected client %+v, %v", info, err)
+	}
+
+	if _, err := c.do(ctx, http.MethodPut, c.adminURL, "/api/v1/tiers/pro", nil, map[string]int{"max_requests": 100, "cycle_duration": 1}, nil); err != nil {
+		t.Fatalf("Failed to save tier: %v", err)
+	}
+	if err := c.AssignTier(ctx, "team-b", "pro"); err != nil {
+		t.Fatalf("AssignTier failed: %v", err)
+	}
+
+	result, err := c.BulkConfigure(ctx, []BulkConfigureItem{
+		{ClientID: "team-c", RateLimitConfig: RateLimitConfig{MaxRequests: 1, CycleDuration: 1}},
+		{ClientID: "team-d", RateLimitConfig: RateLimitConfig{Tier: "missing"}},
+	})
+	if err != nil || result.Saved != 1 || result.Results[1].Error == nil {
+		t.Fatalf("Unexpected bulk result %+v, %v", result, err)
+	}
+
+	page, err := c.ListClients(ctx, ListOptions{Prefix: "team-", Limit: 2})
+	if err != nil || len(page.Clients) != 2 || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page %+v, %v", page, err)
+	}
+	page, err = c.ListClients(ctx, ListOptions{Prefix: "team-", Cursor: page.NextCursor})
+	if err != nil || len(page.Clients) != 1 || page.Clients[0].ClientID != "team-c" {
+		t.Fatalf("Unexpected second page %+v, %v", page, err)
+	}
+	page, _ = c.ListClients(ctx, ListOptions{Tier: "pro"})
+	if len(page.Clients) != 1 || page.Clients[0].ClientID != "team-b" {
+		t.Errorf("Expected team-b in tier pro, got %+v", page.Clients)
+	}
+
+	if err := c.UnassignTier(ctx, "team-b"); err != nil {
+		t.Errorf("UnassignTier failed: %v", err)
+	}
+	if err := c.Unban(ctx, "team-a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound unbanning a client without penalty, got %v", err)
+	}
+	if err := c.DeleteClient(ctx, "team-c"); err != nil {
+		t.Errorf("DeleteClient failed: %v", err)
+	}
+	if _, err := c.GetClient(ctx, "team-c"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	unauthenticated := New(Options{BaseURL: s.URL})
+	if _, err := unauthenticated.Configure(ctx, "team-a", RateLimitConfig{}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized without a token, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrVersionMismatch = errors.New("config version mismatch")
+	ErrUnavailable     = errors.New("limiter unavailable")
+	ErrInvalidClientID = errors.New("invalid client ID")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError is a problem details response of the service. errors.Is matches
+// it against the sentinel errors of its status code.
+type APIError struct {
+	StatusCode int          `json:"status"`
+	Title      string       `json:"title"`
+	Detail     string       `json:"detail"`
+	Errors     []FieldError `json:"errors"`
+}
+
+func (e *APIError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("limiter responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("limiter responded with status %d: %s", e.StatusCode, e.Detail)
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusPreconditionFailed:
+		return ErrVersionMismatch
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import "time"
+
+// AnyVersion configures a client regardless of its current version.
+const AnyVersion = -1
+
+type Decision struct {
+	Allowed    bool
+	Banned     bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+	Policy     string
+	// Cached is set when the denial was answered from the local cache
+	// without asking the service.
+	Cached bool
+}
+
+type decisionResponse struct {
+	Allowed    bool   `json:"allowed"`
+	Banned     bool   `json:"banned"`
+	Limit      int    `json:"limit"`
+	Remaining  int    `json:"remaining"`
+	Reset      int64  `json:"reset"`
+	RetryAfter int64  `json:"retry_after"`
+	Policy     string `json:"policy"`
+}
+
+func (r decisionResponse) decision() Decision {
+	return Decision{
+		Allowed:    r.Allowed,
+		Banned:     r.Banned,
+		Limit:      r.Limit,
+		Remaining:  r.Remaining,
+		Reset:      time.Unix(r.Reset, 0),
+		RetryAfter: time.Duration(r.RetryAfter) * time.Second,
+		Policy:     r.Policy,
+	}
+}
+
+// RateLimitConfig is a client's configuration. Without MaxRequests the
+// client inherits the limits of its parent or tier.
+type RateLimitConfig struct {
+	MaxRequests   int    `json:"max_requests"`
+	CycleDuration int    `json:"cycle_duration"`
+	ParentID      string `json:"parent_id"`
+	Tier          string `json:"tier"`
+	Window        string `json:"window"`
+	TimeZone      string `json:"timezone"`
+}
+
+// RateLimitPatch changes only the fields that are set. Setting MaxRequests
+// to 0 drops the client's own limits.
+type RateLimitPatch struct {
+	MaxRequests   *int    `json:"max_requests,omitempty"`
+	CycleDuration *int    `json:"cycle_duration,omitempty"`
+	ParentID      *string `json:"parent_id,omitempty"`
+	Tier          *string `json:"tier,omitempty"`
+	Window        *string `json:"window,omitempty"`
+	TimeZone      *string `json:"timezone,omitempty"`
+}
+
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Count int       `json:"count"`
+}
+
+type Penalty struct {
+	Banned      bool       `json:"banned"`
+	BannedUntil *time.Time `json:"banned_until"`
+	Offenses    int        `json:"offenses"`
+	Violations  int        `json:"violations"`
+}
+
+// Current and Previous are nil until the client has made a request in that
+// period.
+type Usage struct {
+	ClientID    string   `json:"client_id"`
+	MaxRequests int      `json:"max_requests"`
+	Window      string   `json:"window"`
+	TimeZone    string   `json:"timezone"`
+	Current     *Period  `json:"current"`
+	Previous    *Period  `json:"previous"`
+	Penalty     *Penalty `json:"penalty"`
+}
+
+// ClientInfo is a stored client. The limits are nil unless Custom is set.
+type ClientInfo struct {
+	ClientID      string   `json:"client_id"`
+	ParentID      string   `json:"parent_id"`
+	Tier          string   `json:"tier"`
+	Custom        bool     `json:"custom"`
+	Version       int      `json:"version"`
+	MaxRequests   *int     `json:"max_requests"`
+	CycleDuration *int     `json:"cycle_duration"`
+	Window        *string  `json:"window"`
+	TimeZone      *string  `json:"timezone"`
+	Usage         *Usage   `json:"usage,omitempty"`
+	Penalty       *Penalty `json:"penalty,omitempty"`
+}
+
+type ListOptions struct {
+	Prefix string
+	Tier   string
+	Cursor string
+	Limit  int
+}
+
+type ClientPage struct {
+	Clients    []ClientInfo `json:"clients"`
+	NextCursor string       `json:"next_cursor"`
+}
+
+type BulkConfigureItem struct {
+	ClientID string `json:"client_id"`
+	RateLimitConfig
+}
+
+type BulkConfigureResult struct {
+	Saved   int `json:"saved"`
+	Results []struct {
+		ClientID string  `json:"client_id"`
+		Saved    bool    `json:"saved"`
+		Error    *string `json:"error"`
+	} `json:"results"`
+}
@@ -1,35 +1,142 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	ServerPort           string
+	AdminPort            string
+	AdminTokens          []string
+	AdminHMACKeys        []string
+	AdminClientCerts     []string
+	AdminTLSCert         string
+	AdminTLSKey          string
+	AdminTLSClientCA     string
+	AdminAuthDisabled    bool
+	UnixSocket           string
+	ShutdownTimeoutSec   int
+	HealthTimeoutMs      int
+	BreakerThreshold     int
+	BreakerCooldownSec   int
 	UseRedis             bool
 	RedisURL             string
 	RedisPassword        string
 	DefaultCycleDuration int
 	DefaultMaxRequests   int
+	NodeAddr             string
+	Peers                []string
+	ClusterSecret        string
+	ClusterTimeoutMs     int
+	ClusterPeerFailure   string
+	SnapshotPath         string
+	SnapshotIntervalSec  int
+	RateLimitHeaders     string
+	ClientKeyStrategy    string
+	JWTHMACSecret        string
+	JWTRSAPublicKeyFile  string
+	UserContextKey       string
+	IPv6PrefixLen        int
+	ClientKeyPrefix      bool
+	TrustedProxies       []string
+	ProxyProtocol        bool
+	RouteRulesFile       string
+	RouteRuleMatch       string
+	PolicyFile           string
+	PolicyReloadSec      int
+	PenaltyThreshold     int
+	PenaltyWindowSec     int
+	PenaltyDurations     []string
+	PenaltyResetSec      int
+	LimiterMode          string
+	ConcurrencyLimit     int
+	ConcurrencyLeaseSec  int
+	ConcurrencyScope     string
+	AdaptiveLatencyMs    int
+	AdaptiveErrorPercent int
+	AdaptiveIntervalSec  int
+	AdaptiveMinPercent   int
+	AdaptiveIncreasePct  int
+	AdaptiveDecreasePct  int
+	TraceExporter        string
+	TraceServiceName     string
+	LogLevel             string
+	AuditLog             string
+	AuditLogMaxMB        int
+	AuditLogMaxBackups   int
 }
 
 func LoadConfig() *Config {
 
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+		slog.Info("no .env file found")
 	}
 
 	cfg := &Config{
-		ServerPort:           getEnv("SERVER_PORT", "8080"),
+		ServerPort:           getEnv("SERVER_PORT", "1234"),
+		AdminPort:            getEnv("ADMIN_PORT", ""),
+		AdminTokens:          getEnvAsSlice("ADMIN_TOKENS", nil),
+		AdminHMACKeys:        getEnvAsSlice("ADMIN_HMAC_KEYS", nil),
+		AdminClientCerts:     getEnvAsSlice("ADMIN_CLIENT_CERTS", nil),
+		AdminTLSCert:         getEnv("ADMIN_TLS_CERT", ""),
+		AdminTLSKey:          getEnv("ADMIN_TLS_KEY", ""),
+		AdminTLSClientCA:     getEnv("ADMIN_TLS_CLIENT_CA", ""),
+		AdminAuthDisabled:    getEnvAsBool("ADMIN_AUTH_DISABLED", false),
+		UnixSocket:           getEnv("UNIX_SOCKET", ""),
+		ShutdownTimeoutSec:   getEnvAsInt("SHUTDOWN_TIMEOUT_SECONDS", 15),
+		HealthTimeoutMs:      getEnvAsInt("HEALTH_TIMEOUT_MS", 1000),
+		BreakerThreshold:     getEnvAsInt("BREAKER_THRESHOLD", 5),
+		BreakerCooldownSec:   getEnvAsInt("BREAKER_COOLDOWN_SECONDS", 30),
 		UseRedis:             getEnvAsBool("USE_REDIS", false),
 		RedisURL:             getEnv("REDIS_URL", "redis://localhost:6379"),
 		RedisPassword:        getEnv("REDIS_PASSWORD", ""),
 		DefaultCycleDuration: getEnvAsInt("DEFAULT_CYCLE_DURATION", 1),
 		DefaultMaxRequests:   getEnvAsInt("DEFAULT_MAX_REQUESTS", 100),
+		NodeAddr:             getEnv("NODE_ADDR", ""),
+		Peers:                getEnvAsSlice("PEERS", nil),
+		ClusterSecret:        getEnv("CLUSTER_SECRET", ""),
+		ClusterTimeoutMs:     getEnvAsInt("CLUSTER_TIMEOUT_MS", 500),
+		ClusterPeerFailure:   getEnv("CLUSTER_PEER_FAILURE", "local"),
+		SnapshotPath:         getEnv("SNAPSHOT_PATH", ""),
+		SnapshotIntervalSec:  getEnvAsInt("SNAPSHOT_INTERVAL_SECONDS", 30),
+		RateLimitHeaders:     getEnv("RATE_LIMIT_HEADERS", "legacy"),
+		ClientKeyStrategy:    getEnv("CLIENT_KEY_STRATEGY", "header:X-Client-ID|ip"),
+		JWTHMACSecret:        getEnv("JWT_HMAC_SECRET", ""),
+		JWTRSAPublicKeyFile:  getEnv("JWT_RSA_PUBLIC_KEY_FILE", ""),
+		UserContextKey:       getEnv("USER_CONTEXT_KEY", "user"),
+		IPv6PrefixLen:        getEnvAsInt("IPV6_PREFIX_LEN", 64),
+		ClientKeyPrefix:      getEnvAsBool("CLIENT_KEY_PREFIX", false),
+		TrustedProxies:       getEnvAsSlice("TRUSTED_PROXIES", nil),
+		ProxyProtocol:        getEnvAsBool("PROXY_PROTOCOL", false),
+		RouteRulesFile:       getEnv("ROUTE_RULES_FILE", ""),
+		RouteRuleMatch:       getEnv("ROUTE_RULE_MATCH", "most_specific"),
+		PolicyFile:           getEnv("POLICY_FILE", ""),
+		PolicyReloadSec:      getEnvAsInt("POLICY_RELOAD_INTERVAL_SECONDS", 5),
+		PenaltyThreshold:     getEnvAsInt("PENALTY_THRESHOLD", 0),
+		PenaltyWindowSec:     getEnvAsInt("PENALTY_WINDOW_SECONDS", 60),
+		PenaltyDurations:     getEnvAsSlice("PENALTY_DURATIONS", []string{"1m", "10m", "1h"}),
+		PenaltyResetSec:      getEnvAsInt("PENALTY_RESET_SECONDS", 86400),
+		LimiterMode:          getEnv("LIMITER_MODE", "both"),
+		ConcurrencyLimit:     getEnvAsInt("CONCURRENCY_LIMIT", 0),
+		ConcurrencyLeaseSec:  getEnvAsInt("CONCURRENCY_LEASE_SECONDS", 30),
+		ConcurrencyScope:     getEnv("CONCURRENCY_SCOPE", ""),
+		AdaptiveLatencyMs:    getEnvAsInt("ADAPTIVE_LATENCY_TARGET_MS", 0),
+		AdaptiveErrorPercent: getEnvAsInt("ADAPTIVE_ERROR_PERCENT", 5),
+		AdaptiveIntervalSec:  getEnvAsInt("ADAPTIVE_INTERVAL_SECONDS", 5),
+		AdaptiveMinPercent:   getEnvAsInt("ADAPTIVE_MIN_PERCENT", 10),
+		AdaptiveIncreasePct:  getEnvAsInt("ADAPTIVE_INCREASE_PERCENT", 5),
+		AdaptiveDecreasePct:  getEnvAsInt("ADAPTIVE_DECREASE_PERCENT", 50),
+		TraceExporter:        getEnv("TRACE_EXPORTER", "none"),
+		TraceServiceName:     getEnv("TRACE_SERVICE_NAME", "rate-limiter-go"),
+		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		AuditLog:             getEnv("AUDIT_LOG", ""),
+		AuditLogMaxMB:        getEnvAsInt("AUDIT_LOG_MAX_MB", 100),
+		AuditLogMaxBackups:   getEnvAsInt("AUDIT_LOG_MAX_BACKUPS", 5),
 	}
 
 	return cfg
@@ -59,3 +166,16 @@ func getEnvAsInt(key string, value int) int {
 	}
 	return value
 }
+
+func getEnvAsSlice(key string, value []string) []string {
+	if v := os.Getenv(key); v != "" {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return value
+}
@@ -0,0 +1,76 @@
+package netutil
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1", ""})
+	if err != nil {
+		t.Fatalf("ParseCIDRs failed: %v", err)
+	}
+	if len(nets) != 3 {
+		t.Fatalf("Expected 3 ranges, got %d", len(nets))
+	}
+
+	if !ContainsIP(nets, net.ParseIP("10.20.30.40")) {
+		t.Error("Expected 10.20.30.40 to be contained")
+	}
+	if ContainsIP(nets, net.ParseIP("192.0.2.2")) {
+		t.Error("Bare address should only match itself")
+	}
+
+	if _, err := ParseCIDRs([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("Expected error for invalid cidr")
+	}
+	if _, err := ParseCIDRs([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected error for invalid address")
+	}
+}
+
+func proxyRemoteAddr(t *testing.T, trusted []*net.IPNet) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyLn := NewProxyProtocolListener(ln, trusted)
+	defer proxyLn.Close()
+
+	result := make(chan string, 1)
+	go func() {
+		conn, err := proxyLn.Accept()
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer conn.Close()
+
+		bufio.NewReader(conn).ReadString('\n')
+		result <- conn.RemoteAddr().String()
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "PROXY TCP4 198.51.100.7 10.0.0.1 51000 443\r\nhello\n")
+
+	return <-result
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	loopback, _ := ParseCIDRs([]string{"127.0.0.0/8"})
+
+	if got := proxyRemoteAddr(t, loopback); got != "198.51.100.7:51000" {
+		t.Errorf("Expected address from PROXY header, got %s", got)
+	}
+
+	if got := proxyRemoteAddr(t, nil); got == "198.51.100.7:51000" {
+		t.Error("PROXY header from untrusted upstream must be ignored")
+	}
+}
@@ -1,6 +1,31 @@
 package response
 
-import "github.com/labstack/echo/v4"
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document. Errors and Data are
+// extension members for field-level validation errors and extra context.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	Data     interface{}  `json:"data,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
 
 func Success(c echo.Context, data interface{}) error {
 	return c.JSON(200, map[string]interface{}{
@@ -10,8 +35,61 @@ func Success(c echo.Context, data interface{}) error {
 }
 
 func Error(c echo.Context, statusCode int, message string) error {
-	return c.JSON(statusCode, map[string]interface{}{
-		"success": false,
-		"error":   message,
-	})
+	return WriteProblem(c, newProblem(c, statusCode, message))
+}
+
+func ErrorWithData(c echo.Context, statusCode int, message string, data interface{}) error {
+	problem := newProblem(c, statusCode, message)
+	problem.Data = data
+	return WriteProblem(c, problem)
+}
+
+// ValidationError rejects a request with 400 and one entry per invalid field.
+func ValidationError(c echo.Context, message string, errors []FieldError) error {
+	problem := newProblem(c, http.StatusBadRequest, message)
+	problem.Errors = errors
+	return WriteProblem(c, problem)
+}
+
+func WriteProblem(c echo.Context, problem Problem) error {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Blob(problem.Status, ProblemContentType, body)
+}
+
+// HTTPErrorHandler renders errors returned by handlers and middleware, such
+// as unknown routes, as problem details.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	statusCode, message := http.StatusInternalServerError, "Internal server error"
+
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) {
+		statusCode = httpError.Code
+		message = http.StatusText(statusCode)
+		if detail, ok := httpError.Message.(string); ok {
+			message = detail
+		}
+	}
+
+	if c.Request().Method == http.MethodHead {
+		c.NoContent(statusCode)
+		return
+	}
+	Error(c, statusCode, message)
+}
+
+func newProblem(c echo.Context, statusCode int, message string) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   message,
+		Instance: c.Request().URL.Path,
+	}
 }
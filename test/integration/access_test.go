@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAccess_AllowlistSkipsLimiting(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/default", map[string]int{"max_requests": 1, "cycle_duration": 60})
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/access/health", map[string]string{"list": "allow", "pattern": "health-*"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 creating entry, got %d", rec.Code)
+	}
+
+	for i := 0; i < 5; i++ {
+		if code := protectedRequest(app, "health-checker-1"); code != http.StatusOK {
+			t.Fatalf("Allowlisted client should not be limited, request %d got %d", i+1, code)
+		}
+	}
+
+	protectedRequest(app, "regular")
+	if code := protectedRequest(app, "regular"); code != http.StatusTooManyRequests {
+		t.Errorf("Other clients should still be limited, got %d", code)
+	}
+}
+
+func TestAccess_DenylistBlocks(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/access/abuser", map[string]string{"list": "deny", "pattern": "abuser-42"})
+	sendJSON(app, http.MethodPut, "/api/v1/access/test-net", map[string]string{"list": "deny", "pattern": "192.0.2.0/24"})
+	sendJSON(app, http.MethodPut, "/api/v1/access/all", map[string]string{"list": "allow", "pattern": "*"})
+
+	if code := protectedRequest(app, "abuser-42"); code != http.StatusForbidden {
+		t.Errorf("Denylisted client should get 403, got %d", code)
+	}
+
+	sendJSON(app, http.MethodDelete, "/api/v1/access/abuser", nil)
+
+	if code := protectedRequest(app, "abuser-42"); code != http.StatusForbidden {
+		t.Errorf("Denylisted CIDR should win over allowlist, got %d", code)
+	}
+}
+
+func TestAccess_EntriesExpire(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	expiresAt := time.Now().Add(50 * time.Millisecond)
+	sendJSON(app, http.MethodPut, "/api/v1/access/temp", map[string]interface{}{"list": "deny", "pattern": "temp-ban", "expires_at": expiresAt})
+
+	if code := protectedRequest(app, "temp-ban"); code != http.StatusForbidden {
+		t.Fatalf("Expected 403 before expiry, got %d", code)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	rec := sendJSON(app, http.MethodGet, "/api/v1/access/temp", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expired entry should not be returned, got %d", rec.Code)
+	}
+}
+
+func TestAccess_Validation(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	tests := []map[string]interface{}{
+		{"list": "block", "pattern": "client"},
+		{"list": "deny", "pattern": ""},
+		{"list": "deny", "pattern": "10.0.0.0/33"},
+		{"list": "deny", "pattern": "client-[", "ttl_seconds": 10},
+		{"list": "deny", "pattern": "client", "ttl_seconds": -1},
+		{"list": "deny", "pattern": "client", "expires_at": time.Now().Add(-time.Minute)},
+		{"list": "deny", "kind": "ip", "pattern": "client-*"},
+		{"list": "deny", "kind": "host", "pattern": "client"},
+	}
+
+	for _, body := range tests {
+		rec := sendJSON(app, http.MethodPut, "/api/v1/access/invalid", body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %v, got %d", body, rec.Code)
+		}
+	}
+
+	rec := sendJSON(app, http.MethodDelete, "/api/v1/access/missing", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting unknown entry, got %d", rec.Code)
+	}
+}
+
+func TestAccess_IPEntriesIgnoreClientID(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/default", map[string]int{"max_requests": 1, "cycle_duration": 60})
+	sendJSON(app, http.MethodPut, "/api/v1/access/office", map[string]string{"list": "allow", "pattern": "10.1.2.3"})
+
+	protectedRequest(app, "10.1.2.3")
+	if code := protectedRequest(app, "10.1.2.3"); code != http.StatusTooManyRequests {
+		t.Errorf("Client ID equal to an allowlisted IP should still be limited, got %d", code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/protected/data", nil)
+	req.Header.Set("X-Client-ID", "office-host")
+	req.RemoteAddr = "10.1.2.3:5000"
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		app.Echo.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Allowlisted address should not be limited, request %d got %d", i+1, rec.Code)
+		}
+	}
+
+	rec := sendJSON(app, http.MethodGet, "/api/v1/access/office", nil)
+	if kind := responseData(t, rec.Body.Bytes())["kind"]; kind != "ip" {
+		t.Errorf("Expected kind inferred as ip, got %v", kind)
+	}
+}
+
+func TestAccess_DenylistAppliesToCheckAPI(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/access/abuser", map[string]string{"list": "deny", "kind": "client", "pattern": "abuser-*"})
+
+	if rec := sendJSON(app, http.MethodGet, "/api/v1/rate-limit/abuser-1", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 checking a denylisted client, got %d", rec.Code)
+	}
+
+	code, _ := checkBatch(t, app, map[string]interface{}{
+		"requests": []map[string]interface{}{{"client_id": "fine"}, {"client_id": "abuser-2"}},
+	})
+	if code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a batch with a denylisted client, got %d", code)
+	}
+}
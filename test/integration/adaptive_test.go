@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAdaptive_ErrorsLowerEffectiveLimit(t *testing.T) {
+	app := SetupTestAppWithConfig(t, false, usecase.Config{
+		Adaptive: domain.AdaptiveConfig{
+			LatencyTarget:  time.Second,
+			ErrorThreshold: 0.1,
+			Interval:       time.Nanosecond,
+			MinFactor:      0.1,
+			Increase:       0.1,
+			Decrease:       0.5,
+		},
+	})
+
+	sendJSON(app, http.MethodPut, "/api/v1/rules/flaky", map[string]interface{}{
+		"path":           "/flaky",
+		"max_requests":   100,
+		"cycle_duration": 60,
+	})
+
+	healthy := false
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddleware(app.UseCase))
+	e.GET("/flaky", func(c echo.Context) error {
+		if healthy {
+			return c.String(http.StatusOK, "ok")
+		}
+		return c.String(http.StatusBadGateway, "upstream failed")
+	})
+
+	for i := 0; i < 3; i++ {
+		routeRequest(e, http.MethodGet, "/flaky", "adaptive-client")
+	}
+
+	rec := sendJSON(app, http.MethodGet, "/api/v1/adaptive-limits", nil)
+
+	var body struct {
+		Data []struct {
+			RuleID  string `json:"rule_id"`
+			Ceiling int    `json:"ceiling"`
+			Limit   int    `json:"limit"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+
+	if len(body.Data) != 1 || body.Data[0].Ceiling != 100 || body.Data[0].Limit != 25 {
+		t.Fatalf("Expected limit 25 of 100 after failing intervals, got %s", rec.Body.String())
+	}
+
+	for i := 0; i < 5; i++ {
+		routeRequest(e, http.MethodGet, "/flaky", "adaptive-client")
+	}
+
+	for i := 0; i < 10; i++ {
+		if code := routeRequest(e, http.MethodGet, "/flaky", "capped-client"); code != http.StatusBadGateway {
+			t.Fatalf("Request %d should reach the handler, got %d", i+1, code)
+		}
+	}
+	if code := routeRequest(e, http.MethodGet, "/flaky", "capped-client"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 at the minimum limit of 10, got %d", code)
+	}
+
+	healthy = true
+	routeRequest(e, http.MethodGet, "/flaky", "other-client")
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/adaptive-limits", nil)
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body.Data[0].Limit != 20 {
+		t.Errorf("Expected limit to grow after a healthy interval, got %d", body.Data[0].Limit)
+	}
+}
@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/handler"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAdminAuth_PublicCheckStaysOpen(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	auth := handler.AdminAuthMiddleware(handler.AdminAuthConfig{
+		Tokens: []handler.AdminCredential{
+			{Name: "alice", Role: handler.RoleOperator, Secret: "operator-token"},
+			{Name: "grafana", Role: handler.RoleViewer, Secret: "viewer-token"},
+		},
+	})
+
+	e := echo.New()
+	api := e.Group("/api/v1")
+	api.GET("/rate-limit/:clientID", app.Handler.CheckRateLimit)
+	api.POST("/rate-limit/check", app.Handler.CheckRateLimitBatch)
+
+	admin := e.Group("/api/v1", auth)
+	admin.PUT("/rate-limit/:clientID", app.Handler.ConfigureRateLimit)
+	admin.GET("/rate-limit/:clientID/usage", app.Handler.GetUsage)
+
+	send := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	limit := `{"max_requests": 1000000, "cycle_duration": 1}`
+
+	if code := send(http.MethodGet, "/api/v1/rate-limit/user-1", "", ""); code != http.StatusOK {
+		t.Errorf("Expected public check to stay open, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/v1/rate-limit/check", "", `{"requests":[{"client_id":"user-1","cost":1}]}`); code != http.StatusOK {
+		t.Errorf("Expected public batch check to stay open, got %d", code)
+	}
+
+	if code := send(http.MethodPut, "/api/v1/rate-limit/user-1", "", limit); code != http.StatusUnauthorized {
+		t.Errorf("Expected anonymous configure to be rejected, got %d", code)
+	}
+	if code := send(http.MethodPut, "/api/v1/rate-limit/user-1", "viewer-token", limit); code != http.StatusForbidden {
+		t.Errorf("Expected viewer configure to be forbidden, got %d", code)
+	}
+	if code := send(http.MethodGet, "/api/v1/rate-limit/user-1/usage", "viewer-token", ""); code != http.StatusOK {
+		t.Errorf("Expected viewer to read usage, got %d", code)
+	}
+	if code := send(http.MethodPut, "/api/v1/rate-limit/user-1", "operator-token", limit); code != http.StatusOK {
+		t.Errorf("Expected operator configure to succeed, got %d", code)
+	}
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"rate-limiter-go/internal/audit"
+	"rate-limiter-go/internal/usecase"
+	"strings"
+	"testing"
+)
+
+func auditEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Audit line is not JSON: %q", line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAudit_RecordsChangesAndDenials(t *testing.T) {
+	var buf bytes.Buffer
+	app := SetupTestAppWithConfig(t, false, usecase.Config{Audit: audit.New(&buf)})
+
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/audited", map[string]interface{}{
+		"max_requests":   1,
+		"cycle_duration": 60,
+	})
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/audited", map[string]interface{}{
+		"max_requests":   2,
+		"cycle_duration": 60,
+	})
+
+	for i := 0; i < 3; i++ {
+		protectedRequest(app, "audited")
+	}
+
+	entries := auditEntries(t, &buf)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 2 changes and 1 denial, got %d entries: %s", len(entries), buf.String())
+	}
+
+	created, updated, denied := entries[0], entries[1], entries[2]
+
+	if created["msg"] != "config_change" || created["resource"] != "rate_limit" || created["id"] != "audited" {
+		t.Errorf("Unexpected change entry: %v", created)
+	}
+	if created["old"] != nil {
+		t.Errorf("Expected no old value on creation, got %v", created["old"])
+	}
+	if created["actor"] == "" || created["actor"] == audit.SystemActor {
+		t.Errorf("Expected the caller as actor, got %v", created["actor"])
+	}
+	if created["time"] == nil {
+		t.Error("Expected a timestamp")
+	}
+
+	old, _ := updated["old"].(map[string]interface{})
+	new, _ := updated["new"].(map[string]interface{})
+	if old["MaxRequests"] != float64(1) || new["MaxRequests"] != float64(2) {
+		t.Errorf("Expected old and new values, got %v", updated)
+	}
+
+	if denied["msg"] != "decision_denied" || denied["client_id"] != "audited" || denied["reason"] != "rate_limited" {
+		t.Errorf("Unexpected denial entry: %v", denied)
+	}
+}
@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func checkBatch(t *testing.T, app *TestApp, body interface{}) (int, map[string]interface{}) {
+	bodyJSON, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rate-limit/check", bytes.NewReader(bodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.Echo.ServeHTTP(rec, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+
+	return rec.Code, response
+}
+
+func TestBatchCheck(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	bodyJSON, _ := json.Marshal(map[string]int{"max_requests": 2, "cycle_duration": 1})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/rate-limit/batch-org", bytes.NewReader(bodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	app.Echo.ServeHTTP(httptest.NewRecorder(), req)
+
+	batch := map[string]interface{}{
+		"requests": []map[string]interface{}{
+			{"client_id": "batch-user"},
+			{"client_id": "batch-org", "cost": 2},
+		},
+	}
+
+	code, response := checkBatch(t, app, batch)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if response["data"].(map[string]interface{})["allowed"] != true {
+		t.Fatal("First batch should be allowed")
+	}
+
+	_, response = checkBatch(t, app, batch)
+	data := response["data"].(map[string]interface{})
+	if data["allowed"] != false {
+		t.Fatal("Second batch should be blocked")
+	}
+
+	results := data["results"].([]interface{})
+	user := results[0].(map[string]interface{})
+	if user["remaining"].(float64) != 99 {
+		t.Errorf("Blocked batch should not consume user quota, remaining %v", user["remaining"])
+	}
+
+	code, _ = checkBatch(t, app, map[string]interface{}{"requests": []interface{}{}})
+	if code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for empty batch, got %d", code)
+	}
+}
+
+func TestBatchCheckTooLarge(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	requests := make([]map[string]interface{}, 101)
+	for i := range requests {
+		requests[i] = map[string]interface{}{"client_id": "batch-user"}
+	}
+
+	code, response := checkBatch(t, app, map[string]interface{}{"requests": requests})
+	if code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for oversized batch, got %d", code)
+	}
+	if response["detail"] != "Batch must contain between 1 and 100 requests" {
+		t.Errorf("Unexpected detail %v", response["detail"])
+	}
+}
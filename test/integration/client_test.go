@@ -0,0 +1,189 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func responseData(t *testing.T, body []byte) map[string]interface{} {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Invalid JSON response: %v", err)
+	}
+	return response["data"].(map[string]interface{})
+}
+
+func TestClients_ListPaginatesAndFilters(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/pro", map[string]interface{}{"max_requests": 100, "cycle_duration": 1})
+
+	configure(t, app, "team-a", map[string]interface{}{"max_requests": 5, "cycle_duration": 1})
+	configure(t, app, "team-b", map[string]interface{}{"tier": "pro"})
+	configure(t, app, "team-c", map[string]interface{}{"tier": "pro"})
+	configure(t, app, "other", map[string]interface{}{"max_requests": 5, "cycle_duration": 1})
+
+	var ids []string
+	path := "/api/v1/clients?prefix=team-&limit=2"
+	for pages := 0; pages < 5; pages++ {
+		rec := sendJSON(app, http.MethodGet, path, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		data := responseData(t, rec.Body.Bytes())
+		for _, item := range data["clients"].([]interface{}) {
+			ids = append(ids, item.(map[string]interface{})["client_id"].(string))
+		}
+
+		next := data["next_cursor"].(string)
+		if next == "" {
+			break
+		}
+		path = "/api/v1/clients?prefix=team-&limit=2&cursor=" + next
+	}
+
+	if len(ids) != 3 || ids[0] != "team-a" || ids[2] != "team-c" {
+		t.Errorf("Expected team-a..team-c across pages, got %v", ids)
+	}
+
+	rec := sendJSON(app, http.MethodGet, "/api/v1/clients?tier=pro", nil)
+	if clients := responseData(t, rec.Body.Bytes())["clients"].([]interface{}); len(clients) != 2 {
+		t.Errorf("Expected 2 pro clients, got %v", clients)
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/clients?limit=0", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", rec.Code)
+	}
+}
+
+func TestClients_GetResetAndDelete(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	configure(t, app, "reset-client", map[string]interface{}{"max_requests": 2, "cycle_duration": 1})
+	protectedRequest(app, "reset-client")
+	protectedRequest(app, "reset-client")
+
+	if code := protectedRequest(app, "reset-client"); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 before reset, got %d", code)
+	}
+
+	rec := sendJSON(app, http.MethodGet, "/api/v1/clients/reset-client", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	data := responseData(t, rec.Body.Bytes())
+	if data["max_requests"].(float64) != 2 || data["custom"] != true {
+		t.Errorf("Unexpected config %v", data)
+	}
+	if count := data["usage"].(map[string]interface{})["current"].(map[string]interface{})["count"].(float64); count != 2 {
+		t.Errorf("Expected usage count 2, got %v", count)
+	}
+
+	rec = sendJSON(app, http.MethodPost, "/api/v1/clients/reset-client/reset", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 on reset, got %d", rec.Code)
+	}
+	if code := protectedRequest(app, "reset-client"); code != http.StatusOK {
+		t.Errorf("Expected requests to pass after reset, got %d", code)
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/clients/reset-client", nil)
+	if data := responseData(t, rec.Body.Bytes()); data["max_requests"].(float64) != 2 {
+		t.Errorf("Expected reset to keep the config, got %v", data)
+	}
+
+	rec = sendJSON(app, http.MethodDelete, "/api/v1/clients/reset-client", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 on delete, got %d", rec.Code)
+	}
+
+	if rec := sendJSON(app, http.MethodGet, "/api/v1/clients/reset-client", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", rec.Code)
+	}
+	if rec := sendJSON(app, http.MethodDelete, "/api/v1/clients/reset-client", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting twice, got %d", rec.Code)
+	}
+}
+
+func TestClients_BulkConfigure(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/clients", []map[string]interface{}{
+		{"client_id": "bulk-a", "max_requests": 1, "cycle_duration": 1},
+		{"client_id": "bulk-b", "tier": "missing"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	data := responseData(t, rec.Body.Bytes())
+	if data["saved"].(float64) != 1 {
+		t.Errorf("Expected 1 saved configuration, got %v", data["saved"])
+	}
+
+	results := data["results"].([]interface{})
+	if failed := results[1].(map[string]interface{}); failed["saved"] != false || failed["error"] != "Tier not found" {
+		t.Errorf("Expected bulk-b to fail on its tier, got %v", failed)
+	}
+
+	protectedRequest(app, "bulk-a")
+	if code := protectedRequest(app, "bulk-a"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected bulk config to apply, got %d", code)
+	}
+
+	rec = sendJSON(app, http.MethodPut, "/api/v1/clients", []map[string]interface{}{
+		{"client_id": "bulk-c", "max_requests": 1, "cycle_duration": 1},
+		{"client_id": "bulk-d", "max_requests": -1},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an invalid entry, got %d", rec.Code)
+	}
+	if rec := sendJSON(app, http.MethodGet, "/api/v1/clients/bulk-c", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected nothing to be saved from an invalid bulk, got %d", rec.Code)
+	}
+
+	for _, item := range []map[string]interface{}{
+		{"client_id": "bulk-e"},
+		{"client_id": "bulk-f|rule:api", "max_requests": 1, "cycle_duration": 1},
+	} {
+		if rec := sendJSON(app, http.MethodPut, "/api/v1/clients", []map[string]interface{}{item}); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %v, got %d", item, rec.Code)
+		}
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/clients?limit=1001", nil)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "between 1 and 1000") {
+		t.Errorf("Expected the page size limit in the error, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestClients_ManageIPv6Prefix(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	if code := configure(t, app, "2001:db8:1:2::~64", map[string]interface{}{"max_requests": 1, "cycle_duration": 1}); code != http.StatusOK {
+		t.Fatalf("Expected 200 configuring IPv6 prefix, got %d", code)
+	}
+
+	codes := make([]int, 0, 2)
+	for _, addr := range []string{"[2001:db8:1:2::1]:5000", "[2001:db8:1:2::2]:5000"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/protected/data", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		app.Echo.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("Expected the prefix limit to be shared, got %v", codes)
+	}
+
+	if rec := sendJSON(app, http.MethodGet, "/api/v1/clients/2001:db8:1:2::~64", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 reading IPv6 prefix client, got %d", rec.Code)
+	}
+}
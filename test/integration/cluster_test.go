@@ -0,0 +1,309 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/cluster"
+	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/repository/memory"
+	"rate-limiter-go/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type clusterNode struct {
+	Server *httptest.Server
+	Ring   *cluster.Ring
+}
+
+func setupCluster(t *testing.T, size int) []*clusterNode {
+	return setupClusterWithFailureMode(t, size, cluster.FailLocal)
+}
+
+func setupClusterWithFailureMode(t *testing.T, size int, onPeerFailure cluster.FailureMode) []*clusterNode {
+	nodes := make([]*clusterNode, size)
+	addrs := make([]string, size)
+
+	for i := range nodes {
+		srv := httptest.NewServer(nil)
+		t.Cleanup(srv.Close)
+
+		nodes[i] = &clusterNode{Server: srv}
+		addrs[i] = srv.URL
+	}
+
+	for _, node := range nodes {
+		repo := memory.NewRateLimiterMemoryRepository(100, 1)
+		local := usecase.NewRateLimiterUseCaseWithConfig(repo, usecase.Config{Cluster: true})
+
+		node.Ring = cluster.NewRing(node.Server.URL, addrs)
+		client := cluster.NewClient(2*time.Second, "secret")
+		uc := usecase.NewClusterRateLimiterUseCase(local, node.Ring, client, onPeerFailure)
+		h := handler.NewRateLimiterHandler(uc)
+		tierHandler := handler.NewTierHandler(uc)
+
+		e := echo.New()
+		handler.NewClusterHandler(local, "secret").Register(e)
+
+		api := e.Group("/api/v1")
+		api.PUT("/rate-limit/:clientID", h.ConfigureRateLimit)
+		api.GET("/rate-limit/:clientID/usage", h.GetUsage)
+		api.PUT("/rate-limit/:clientID/tier", tierHandler.AssignTier)
+		api.GET("/tiers/:name", tierHandler.GetTier)
+		api.PUT("/tiers/:name", tierHandler.SaveTier)
+
+		protected := api.Group("/protected")
+		protected.Use(handler.RateLimiterMiddleware(uc))
+		protected.GET("/data", func(c echo.Context) error {
+			return c.JSON(200, map[string]string{"message": "OK"})
+		})
+
+		node.Server.Config.Handler = e
+	}
+
+	return nodes
+}
+
+func configureOn(t *testing.T, node *clusterNode, clientID string, maxRequests int) {
+	bodyJSON, _ := json.Marshal(map[string]int{
+		"max_requests":   maxRequests,
+		"cycle_duration": 1,
+	})
+
+	req, _ := http.NewRequest(http.MethodPut, node.Server.URL+"/api/v1/rate-limit/"+clientID, bytes.NewReader(bodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Configure failed:", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 on configure, got %d", resp.StatusCode)
+	}
+}
+
+func requestOn(t *testing.T, node *clusterNode, clientID string) int {
+	req, _ := http.NewRequest(http.MethodGet, node.Server.URL+"/api/v1/protected/data", nil)
+	req.Header.Set("X-Client-ID", clientID)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Request failed:", err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func TestCluster_GlobalLimitAcrossNodes(t *testing.T) {
+	nodes := setupCluster(t, 3)
+	clientID := "cluster-client"
+
+	configureOn(t, nodes[0], clientID, 5)
+
+	successCount := 0
+	for i := 0; i < 12; i++ {
+		if requestOn(t, nodes[i%len(nodes)], clientID) == http.StatusOK {
+			successCount++
+		}
+	}
+
+	if successCount != 5 {
+		t.Errorf("Expected 5 successful requests across the cluster, got %d", successCount)
+	}
+}
+
+func TestCluster_RebalanceOnPeerRemoval(t *testing.T) {
+	nodes := setupCluster(t, 3)
+	clientID := "rebalance-client"
+
+	owner := nodes[0].Ring.Owner(clientID)
+
+	var remaining []*clusterNode
+	var addrs []string
+	for _, node := range nodes {
+		if node.Server.URL != owner {
+			remaining = append(remaining, node)
+			addrs = append(addrs, node.Server.URL)
+		}
+	}
+
+	for _, node := range remaining {
+		node.Ring.SetPeers(addrs)
+	}
+
+	newOwner := remaining[0].Ring.Owner(clientID)
+	if newOwner == owner {
+		t.Fatal("Ownership should move away from the removed peer")
+	}
+	if remaining[1].Ring.Owner(clientID) != newOwner {
+		t.Fatal("Remaining peers should agree on the new owner")
+	}
+
+	configureOn(t, remaining[0], clientID, 3)
+
+	successCount := 0
+	for i := 0; i < 8; i++ {
+		if requestOn(t, remaining[i%len(remaining)], clientID) == http.StatusOK {
+			successCount++
+		}
+	}
+
+	if successCount != 3 {
+		t.Errorf("Expected 3 successful requests after rebalance, got %d", successCount)
+	}
+}
+
+func TestCluster_ForwardsConfigVersion(t *testing.T) {
+	nodes := setupCluster(t, 3)
+	clientID := "versioned-client"
+
+	configureOn(t, nodes[0], clientID, 5)
+
+	for i, node := range nodes {
+		bodyJSON, _ := json.Marshal(map[string]int{"max_requests": 10 + i, "cycle_duration": 1})
+
+		req, _ := http.NewRequest(http.MethodPut, node.Server.URL+"/api/v1/rate-limit/"+clientID, bytes.NewReader(bodyJSON))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"1"`)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Configure failed:", err)
+		}
+		resp.Body.Close()
+
+		want, etag := http.StatusPreconditionFailed, ""
+		if i == 0 {
+			want, etag = http.StatusOK, `"2"`
+		}
+		if resp.StatusCode != want || resp.Header.Get("ETag") != etag {
+			t.Errorf("Node %d: expected %d with ETag %q, got %d %q", i, want, etag, resp.StatusCode, resp.Header.Get("ETag"))
+		}
+	}
+}
+
+func TestCluster_ForwardsClientCalls(t *testing.T) {
+	nodes := setupCluster(t, 3)
+	clientID := "forwarded-client"
+
+	var other *clusterNode
+	for _, node := range nodes {
+		if node.Server.URL != node.Ring.Owner(clientID) {
+			other = node
+			break
+		}
+	}
+
+	resp := sendOn(t, other, http.MethodPut, "/api/v1/tiers/gold", map[string]int{"max_requests": 2, "cycle_duration": 1})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 saving tier, got %d", resp.StatusCode)
+	}
+	for i, node := range nodes {
+		if resp := sendOn(t, node, http.MethodGet, "/api/v1/tiers/gold", nil); resp.StatusCode != http.StatusOK {
+			t.Errorf("Node %d: expected the tier to be replicated, got %d", i, resp.StatusCode)
+		}
+	}
+
+	resp = sendOn(t, other, http.MethodPut, "/api/v1/rate-limit/"+clientID+"/tier", map[string]string{"tier": "gold"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 assigning tier, got %d", resp.StatusCode)
+	}
+
+	successCount := 0
+	for i := 0; i < 4; i++ {
+		if requestOn(t, nodes[i%len(nodes)], clientID) == http.StatusOK {
+			successCount++
+		}
+	}
+	if successCount != 2 {
+		t.Errorf("Expected the assigned tier to allow 2 requests, got %d", successCount)
+	}
+
+	resp = sendOn(t, other, http.MethodGet, "/api/v1/rate-limit/"+clientID+"/usage", nil)
+	var body struct {
+		Data struct {
+			MaxRequests int `json:"max_requests"`
+			Current     struct {
+				Count int `json:"count"`
+			} `json:"current"`
+		} `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.Data.MaxRequests != 2 || body.Data.Current.Count != 2 {
+		t.Errorf("Expected usage from the owner, got %+v", body.Data)
+	}
+}
+
+func TestCluster_RejectsParentLimits(t *testing.T) {
+	nodes := setupCluster(t, 2)
+
+	for i, node := range nodes {
+		resp := sendOn(t, node, http.MethodPut, fmt.Sprintf("/api/v1/rate-limit/child-%d", i), map[string]interface{}{
+			"max_requests": 5, "cycle_duration": 1, "parent_id": "parent",
+		})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Node %d: expected 400 for a parent in cluster mode, got %d", i, resp.StatusCode)
+		}
+	}
+}
+
+func TestCluster_PeerFailureMode(t *testing.T) {
+	for _, tc := range []struct {
+		mode cluster.FailureMode
+		want int
+	}{
+		{cluster.FailAllow, http.StatusOK},
+		{cluster.FailDeny, http.StatusTooManyRequests},
+	} {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			nodes := setupClusterWithFailureMode(t, 2, tc.mode)
+
+			clientID := "unreachable-client"
+			for i := 0; nodes[0].Ring.Owner(clientID) != nodes[1].Server.URL; i++ {
+				clientID = fmt.Sprintf("unreachable-client-%d", i)
+			}
+			nodes[1].Server.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, nodes[0].Server.URL+"/api/v1/protected/data", nil)
+			req.Header.Set("X-Client-ID", clientID)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal("Request failed:", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.want {
+				t.Errorf("Expected %d with the owner down, got %d", tc.want, resp.StatusCode)
+			}
+			if limit := resp.Header.Get("X-RateLimit-Limit"); limit != "" {
+				t.Errorf("Expected no rate limit headers without a resolved limit, got limit %s", limit)
+			}
+		})
+	}
+}
+
+func sendOn(t *testing.T, node *clusterNode, method, path string, body interface{}) *http.Response {
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+
+	req, _ := http.NewRequest(method, node.Server.URL+path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Request failed:", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
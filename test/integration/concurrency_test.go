@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/handler"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestConcurrency_LimitsInFlightRequests(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddlewareWithConfig(app.UseCase, handler.RateLimiterConfig{
+		Mode:        handler.LimitConcurrency,
+		Concurrency: handler.ConcurrencyConfig{Limit: 2, LeaseTTL: time.Minute},
+	}))
+	e.GET("/slow", func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		req.Header.Set("X-Client-ID", "uploader")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	results := make(chan *httptest.ResponseRecorder, 2)
+	for i := 0; i < 2; i++ {
+		go func() { results <- request() }()
+	}
+	<-started
+	<-started
+
+	rec := request()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 for third concurrent request, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-Concurrency-Limit") != "2" || rec.Header().Get("X-Concurrency-Remaining") != "0" {
+		t.Errorf("Unexpected concurrency headers %v", rec.Header())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After on rejection")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if rec := <-results; rec.Code != http.StatusOK {
+			t.Errorf("Expected in-flight request to succeed, got %d", rec.Code)
+		}
+	}
+
+	rec = request()
+	if rec.Code != http.StatusOK {
+		t.Errorf("Slots should be released after completion, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-Concurrency-Remaining") != "1" {
+		t.Errorf("Expected 1 remaining slot, got %s", rec.Header().Get("X-Concurrency-Remaining"))
+	}
+}
+
+func TestConcurrency_ScopesKeepSeparateSlots(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	scoped := func(scope string, handle echo.HandlerFunc) *echo.Echo {
+		e := echo.New()
+		e.Use(handler.RateLimiterMiddlewareWithConfig(app.UseCase, handler.RateLimiterConfig{
+			Mode:        handler.LimitConcurrency,
+			Concurrency: handler.ConcurrencyConfig{Limit: 1, LeaseTTL: time.Minute, Scope: scope},
+		}))
+		e.GET("/work", handle)
+		return e
+	}
+
+	uploads := scoped("uploads", func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+	reports := scoped("reports", func(c echo.Context) error {
+		return c.String(http.StatusOK, "done")
+	})
+
+	request := func(e *echo.Echo) int {
+		req := httptest.NewRequest(http.MethodGet, "/work", nil)
+		req.Header.Set("X-Client-ID", "worker")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	done := make(chan int, 1)
+	go func() { done <- request(uploads) }()
+	<-started
+
+	if code := request(reports); code != http.StatusOK {
+		t.Errorf("Another scope should have its own slot, got %d", code)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Expected in-flight request to succeed, got %d", code)
+	}
+}
+
+func TestConcurrency_BothModeRefundsRefusedRequests(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	configure(t, app, "both-client", map[string]interface{}{"max_requests": 5, "cycle_duration": 60})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddlewareWithConfig(app.UseCase, handler.RateLimiterConfig{
+		Mode:        handler.LimitBoth,
+		Concurrency: handler.ConcurrencyConfig{Limit: 1, LeaseTTL: time.Minute},
+	}))
+	e.GET("/slow", func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		req.Header.Set("X-Client-ID", "both-client")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- request() }()
+	<-started
+
+	for i := 0; i < 3; i++ {
+		if rec := request(); rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected 429 for concurrent request, got %d", rec.Code)
+		}
+	}
+
+	close(release)
+	<-done
+
+	usage, err := app.UseCase.GetUsage(context.Background(), "both-client")
+	if err != nil {
+		t.Fatal("GetUsage failed:", err)
+	}
+	if usage.Current.Count != 1 {
+		t.Errorf("Expected only the admitted request to be charged, got %d", usage.Current.Count)
+	}
+}
@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/handler"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestHeaders_Legacy(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	configure(t, app, "header-client", map[string]interface{}{"max_requests": 2, "cycle_duration": 1})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/protected/data", nil)
+	req.Header.Set("X-Client-ID", "header-client")
+	rec := httptest.NewRecorder()
+	app.Echo.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("Expected X-RateLimit-Limit 2, got %q", got)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "1" {
+		t.Errorf("Expected X-RateLimit-Remaining 1, got %q", got)
+	}
+	if rec.Header().Get("RateLimit") != "" {
+		t.Error("IETF headers should not be sent in legacy mode")
+	}
+
+	protectedRequest(app, "header-client")
+
+	rec = httptest.NewRecorder()
+	app.Echo.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" || rec.Header().Get("Retry-After") == "0" {
+		t.Errorf("Expected Retry-After on 429, got %q", rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestHeaders_IETF(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/pro", map[string]int{"max_requests": 10, "cycle_duration": 1})
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/ietf-client/tier", map[string]string{"tier": "pro"})
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddlewareWithConfig(app.UseCase, handler.RateLimiterConfig{
+		Headers: handler.HeadersIETF,
+	}))
+	e.GET("/data", func(c echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/data", nil)
+	req.Header.Set("X-Client-ID", "ietf-client")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("RateLimit-Policy"); got != `"pro";q=10;w=60` {
+		t.Errorf("Unexpected RateLimit-Policy %q", got)
+	}
+	if got := rec.Header().Get("RateLimit"); !strings.HasPrefix(got, `"pro";r=9;t=`) {
+		t.Errorf("Unexpected RateLimit %q", got)
+	}
+	if rec.Header().Get("X-RateLimit-Limit") != "" {
+		t.Error("Legacy headers should not be sent in ietf mode")
+	}
+}
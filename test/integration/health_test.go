@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/health"
+	"rate-limiter-go/internal/repository"
+	"rate-limiter-go/internal/repository/memory"
+	redisRepo "rate-limiter-go/internal/repository/redis"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+func healthRequest(e *echo.Echo, path string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body
+}
+
+func setupHealth(backend string, pinger repository.Pinger) *echo.Echo {
+	checker := health.NewChecker(backend, pinger, health.NewBreaker(1, time.Minute), time.Second, func() string { return "v1" })
+
+	e := echo.New()
+	handler.NewHealthHandler(checker).Register(e)
+	return e
+}
+
+func TestHealth_MemoryBackendIsReady(t *testing.T) {
+	e := setupHealth("memory", memory.NewRateLimiterMemoryRepository(100, 1).(repository.Pinger))
+
+	if code, _ := healthRequest(e, "/healthz"); code != http.StatusOK {
+		t.Errorf("Expected liveness 200, got %d", code)
+	}
+
+	code, body := healthRequest(e, "/readyz")
+	if code != http.StatusOK {
+		t.Fatalf("Expected readiness 200, got %d", code)
+	}
+
+	data := body["data"].(map[string]interface{})
+	if data["backend"] != "memory" || data["circuit"] != "closed" || data["config_version"] != "v1" {
+		t.Errorf("Unexpected readiness body: %v", data)
+	}
+	if _, ok := data["build"].(map[string]interface{}); !ok {
+		t.Error("Expected build info")
+	}
+}
+
+func TestHealth_RedisDownIsNotReady(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	repo := redisRepo.NewRateLimiterRedisRepository(client, 100, 1)
+	e := setupHealth("redis", repo.(repository.Pinger))
+
+	if code, _ := healthRequest(e, "/readyz"); code != http.StatusOK {
+		t.Fatalf("Expected ready while Redis is up, got %d", code)
+	}
+
+	mr.Close()
+
+	code, body := healthRequest(e, "/readyz")
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 when Redis is down, got %d", code)
+	}
+	data := body["data"].(map[string]interface{})
+	if data["circuit"] != "open" || data["error"] == "" {
+		t.Errorf("Expected open circuit with error, got %v", data)
+	}
+
+	if code, _ := healthRequest(e, "/healthz"); code != http.StatusOK {
+		t.Errorf("Expected liveness to stay 200 when Redis is down, got %d", code)
+	}
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func configure(t *testing.T, app *TestApp, clientID string, body map[string]interface{}) int {
+	bodyJSON, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/rate-limit/"+clientID, bytes.NewReader(bodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.Echo.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func protectedRequest(app *TestApp, clientID string) int {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/protected/data", nil)
+	req.Header.Set("X-Client-ID", clientID)
+	rec := httptest.NewRecorder()
+
+	app.Echo.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestHierarchy_OrganizationLimitIsShared(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	configure(t, app, "org-a", map[string]interface{}{"max_requests": 3, "cycle_duration": 1})
+	configure(t, app, "user-a", map[string]interface{}{"max_requests": 10, "cycle_duration": 1, "parent_id": "org-a"})
+	configure(t, app, "user-b", map[string]interface{}{"parent_id": "org-a"})
+	configure(t, app, "key-a", map[string]interface{}{"parent_id": "user-a"})
+
+	for i := 0; i < 3; i++ {
+		if code := protectedRequest(app, "key-a"); code != http.StatusOK {
+			t.Fatalf("Request %d should pass, got %d", i+1, code)
+		}
+	}
+
+	if code := protectedRequest(app, "key-a"); code != http.StatusTooManyRequests {
+		t.Errorf("Organization limit should block the API key, got %d", code)
+	}
+	if code := protectedRequest(app, "user-b"); code != http.StatusTooManyRequests {
+		t.Errorf("Organization limit should block sibling users, got %d", code)
+	}
+}
+
+func TestHierarchy_LimitsAreInherited(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	configure(t, app, "org-b", map[string]interface{}{"max_requests": 50, "cycle_duration": 1})
+	configure(t, app, "user-c", map[string]interface{}{"max_requests": 2, "cycle_duration": 1, "parent_id": "org-b"})
+	configure(t, app, "key-c", map[string]interface{}{"parent_id": "user-c"})
+	configure(t, app, "key-d", map[string]interface{}{"parent_id": "user-c"})
+
+	protectedRequest(app, "key-c")
+	protectedRequest(app, "key-d")
+
+	if code := protectedRequest(app, "key-c"); code != http.StatusTooManyRequests {
+		t.Errorf("User limit should be shared by its keys, got %d", code)
+	}
+}
+
+func TestHierarchy_RejectsCycles(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	configure(t, app, "org-c", map[string]interface{}{"max_requests": 5, "cycle_duration": 1})
+	configure(t, app, "user-d", map[string]interface{}{"parent_id": "org-c"})
+
+	if code := configure(t, app, "org-c", map[string]interface{}{"parent_id": "user-d"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for cyclic parent, got %d", code)
+	}
+}
+
+func TestConfigureRejectsEmptyBody(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	if code := configure(t, app, "empty-user", map[string]interface{}{}); code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for empty configuration, got %d", code)
+	}
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLoadShedding_LowPriorityShedFirst(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/free", map[string]interface{}{"max_requests": 100, "cycle_duration": 60})
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/premium", map[string]interface{}{"max_requests": 100, "cycle_duration": 60, "priority": 1})
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rules/orders-ceiling", map[string]interface{}{
+		"path":           "/api/v1/protected/orders/**",
+		"max_requests":   4,
+		"cycle_duration": 60,
+		"global":         true,
+		"priority_share": 50,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 creating global rule, got %d", rec.Code)
+	}
+
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/free-1/tier", map[string]string{"tier": "free"})
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/free-2/tier", map[string]string{"tier": "free"})
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/premium-1/tier", map[string]string{"tier": "premium"})
+
+	routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "free-1")
+	routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "free-1")
+
+	if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "free-2"); code != http.StatusServiceUnavailable {
+		t.Errorf("Free tier should be shed once half the budget is used, got %d", code)
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "premium-1"); code != http.StatusOK {
+			t.Fatalf("Premium request %d should be admitted, got %d", i+1, code)
+		}
+	}
+
+	if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "premium-1"); code != http.StatusServiceUnavailable {
+		t.Errorf("Global ceiling should apply to premium too, got %d", code)
+	}
+
+	if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/data", "free-2"); code != http.StatusOK {
+		t.Errorf("Routes outside the global rule should not be shed, got %d", code)
+	}
+}
+
+func TestLoadShedding_Validation(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rules/invalid", map[string]interface{}{
+		"path":           "/api/**",
+		"max_requests":   10,
+		"cycle_duration": 1,
+		"priority_share": 50,
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Priority share requires a global rule, got %d", rec.Code)
+	}
+
+	rec = sendJSON(app, http.MethodPut, "/api/v1/tiers/negative", map[string]interface{}{"max_requests": 10, "cycle_duration": 1, "priority": -1})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for negative priority, got %d", rec.Code)
+	}
+}
+
+func TestLoadShedding_RefusedClientsKeepGlobalBudget(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/rules/orders-ceiling", map[string]interface{}{
+		"path":           "/api/v1/protected/orders/**",
+		"max_requests":   3,
+		"cycle_duration": 60,
+		"global":         true,
+	})
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/greedy", map[string]int{"max_requests": 1, "cycle_duration": 60})
+
+	routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "greedy")
+	for i := 0; i < 5; i++ {
+		if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "greedy"); code != http.StatusTooManyRequests {
+			t.Fatalf("Greedy request %d should hit its own limit, got %d", i+2, code)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "other"); code != http.StatusOK {
+			t.Fatalf("Refused requests should not use the global budget, request %d got %d", i+1, code)
+		}
+	}
+
+	if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "other"); code != http.StatusServiceUnavailable {
+		t.Errorf("Global ceiling should still apply, got %d", code)
+	}
+}
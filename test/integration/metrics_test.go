@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/handler"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestMetrics_ExposesDecisionsAndRepositoryLatency(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/metrics-client", map[string]interface{}{
+		"max_requests":   1,
+		"cycle_duration": 60,
+	})
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddleware(app.UseCase))
+	e.GET("/data", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	routeRequest(e, http.MethodGet, "/data", "metrics-client")
+	routeRequest(e, http.MethodGet, "/data", "metrics-client")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	app.Echo.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`rate_limiter_decisions_total{result="allowed",rule="",tier="custom"}`,
+		`rate_limiter_decisions_total{result="denied",rule="",tier="custom"}`,
+		`rate_limiter_repository_duration_seconds_count{backend="test",operation="consume_batch"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+	if strings.Contains(body, "metrics-client") {
+		t.Error("Client IDs must not be used as label values")
+	}
+}
+
+func TestMetrics_InheritedLimitsAreCustom(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/metrics-parent", map[string]interface{}{
+		"max_requests":   1,
+		"cycle_duration": 60,
+	})
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/metrics-child", map[string]interface{}{
+		"parent_id": "metrics-parent",
+	})
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddleware(app.UseCase))
+	e.GET("/data", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	routeRequest(e, http.MethodGet, "/data", "metrics-child")
+	routeRequest(e, http.MethodGet, "/data", "metrics-child")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	app.Echo.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `rate_limiter_decisions_total{result="denied",rule="",tier="custom"}`) {
+		t.Error("Expected the inherited limit to be labelled custom")
+	}
+	if strings.Contains(body, "metrics-parent") || strings.Contains(body, "metrics-child") {
+		t.Error("Client IDs must not be used as label values")
+	}
+}
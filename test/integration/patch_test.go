@@ -0,0 +1,140 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/pkg/response"
+	"testing"
+)
+
+func conditionalJSON(app *TestApp, method, path, ifMatch string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+
+	app.Echo.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) response.Problem {
+	if contentType := rec.Header().Get("Content-Type"); contentType != response.ProblemContentType {
+		t.Fatalf("Expected %s, got %q", response.ProblemContentType, contentType)
+	}
+
+	var problem response.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Invalid problem document: %v", err)
+	}
+	return problem
+}
+
+func TestPatch_UpdatesOnlySuppliedFields(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/pro", map[string]interface{}{"max_requests": 100, "cycle_duration": 1})
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rate-limit/patched", map[string]interface{}{
+		"max_requests":   5,
+		"cycle_duration": 1,
+		"tier":           "pro",
+	})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"1"` {
+		t.Fatalf("Expected 200 with ETag \"1\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	rec = sendJSON(app, http.MethodPatch, "/api/v1/rate-limit/patched", map[string]interface{}{"max_requests": 2})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected 200 with ETag \"2\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/clients/patched", nil)
+	data := responseData(t, rec.Body.Bytes())
+	if data["max_requests"].(float64) != 2 || data["cycle_duration"].(float64) != 1 || data["tier"] != "pro" {
+		t.Errorf("Expected only max_requests to change, got %v", data)
+	}
+	if rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("Expected GET to return ETag \"2\", got %q", rec.Header().Get("ETag"))
+	}
+
+	rec = sendJSON(app, http.MethodPatch, "/api/v1/rate-limit/patched", map[string]interface{}{"max_requests": 0})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected clearing the custom limit to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/clients/patched", nil)
+	if data := responseData(t, rec.Body.Bytes()); data["custom"] != false || data["tier"] != "pro" {
+		t.Errorf("Expected the client to fall back to its tier, got %v", data)
+	}
+}
+
+func TestPatch_IfMatch(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	configure(t, app, "guarded", map[string]interface{}{"max_requests": 5, "cycle_duration": 1})
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rate-limit/guarded", map[string]interface{}{"max_requests": 5, "cycle_duration": 1})
+	if rec.Header().Get("ETag") != `"1"` {
+		t.Errorf("Expected an unchanged PUT to keep ETag \"1\", got %q", rec.Header().Get("ETag"))
+	}
+
+	rec = conditionalJSON(app, http.MethodPatch, "/api/v1/rate-limit/guarded", `"1"`, map[string]interface{}{"max_requests": 10})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected matching If-Match to succeed, got %d", rec.Code)
+	}
+
+	for _, ifMatch := range []string{`"1"`, `W/"2"`, "2"} {
+		rec = conditionalJSON(app, http.MethodPut, "/api/v1/rate-limit/guarded", ifMatch, map[string]interface{}{"max_requests": 1, "cycle_duration": 1})
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected 412 for If-Match %s, got %d", ifMatch, rec.Code)
+		}
+	}
+
+	rec = conditionalJSON(app, http.MethodPut, "/api/v1/rate-limit/guarded", "*", map[string]interface{}{"max_requests": 1, "cycle_duration": 1})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Errorf("Expected If-Match * to succeed with ETag \"3\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rate-limit/invalid", map[string]interface{}{
+		"max_requests": 5,
+		"window":       "fortnight",
+		"timezone":     "Mars/Olympus",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", rec.Code)
+	}
+
+	problem := decodeProblem(t, rec)
+	if problem.Status != http.StatusBadRequest || problem.Title != "Bad Request" || problem.Instance != "/api/v1/rate-limit/invalid" {
+		t.Errorf("Unexpected problem %+v", problem)
+	}
+
+	fields := make(map[string]bool)
+	for _, err := range problem.Errors {
+		fields[err.Field] = true
+	}
+	if len(fields) != 3 || !fields["cycle_duration"] || !fields["window"] || !fields["timezone"] {
+		t.Errorf("Expected errors for cycle_duration, window and timezone, got %+v", problem.Errors)
+	}
+
+	rec = sendJSON(app, http.MethodPatch, "/api/v1/rate-limit/invalid", map[string]interface{}{"tier": "missing"})
+	if problem := decodeProblem(t, rec); len(problem.Errors) != 1 || problem.Errors[0].Field != "tier" {
+		t.Errorf("Expected a tier field error, got %+v", problem)
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/unknown", nil)
+	if problem := decodeProblem(t, rec); problem.Status != http.StatusNotFound {
+		t.Errorf("Expected a 404 problem for unknown routes, got %+v", problem)
+	}
+}
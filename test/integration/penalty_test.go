@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/usecase"
+	"testing"
+	"time"
+)
+
+func TestPenalty_BanAndUnban(t *testing.T) {
+	app := SetupTestAppWithConfig(t, false, usecase.Config{
+		Penalty: domain.PenaltyConfig{
+			Threshold: 2,
+			Window:    time.Minute,
+			Durations: []time.Duration{time.Minute, 10 * time.Minute},
+			Reset:     time.Hour,
+		},
+	})
+
+	configure(t, app, "noisy", map[string]interface{}{"max_requests": 1, "cycle_duration": 60})
+
+	protectedRequest(app, "noisy")
+	protectedRequest(app, "noisy")
+	protectedRequest(app, "noisy")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/protected/data", nil)
+	req.Header.Set("X-Client-ID", "noisy")
+	rec := httptest.NewRecorder()
+	app.Echo.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after ban, got %d", rec.Code)
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Expected Retry-After 60 while banned, got %s", retryAfter)
+	}
+
+	usage := sendJSON(app, http.MethodGet, "/api/v1/rate-limit/noisy/usage", nil)
+
+	var body struct {
+		Data struct {
+			Penalty struct {
+				Banned   bool `json:"banned"`
+				Offenses int  `json:"offenses"`
+			} `json:"penalty"`
+		} `json:"data"`
+	}
+	json.Unmarshal(usage.Body.Bytes(), &body)
+
+	if !body.Data.Penalty.Banned || body.Data.Penalty.Offenses != 1 {
+		t.Errorf("Expected ban in usage, got %s", usage.Body.String())
+	}
+
+	rec = sendJSON(app, http.MethodDelete, "/api/v1/rate-limit/noisy/ban", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 unbanning, got %d", rec.Code)
+	}
+
+	rec = sendJSON(app, http.MethodDelete, "/api/v1/rate-limit/noisy/ban", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 unbanning twice, got %d", rec.Code)
+	}
+
+	if code := protectedRequest(app, "noisy"); code != http.StatusTooManyRequests {
+		t.Errorf("Unbanned client is still over its limit, got %d", code)
+	}
+	if code := protectedRequest(app, "other"); code != http.StatusOK {
+		t.Errorf("Other clients should not be affected, got %d", code)
+	}
+}
+
+func TestPenalty_BatchCheck(t *testing.T) {
+	app := SetupTestAppWithConfig(t, false, usecase.Config{
+		Penalty: domain.PenaltyConfig{
+			Threshold: 2,
+			Window:    time.Minute,
+			Durations: []time.Duration{time.Minute},
+			Reset:     time.Hour,
+		},
+	})
+
+	configure(t, app, "batch-noisy", map[string]interface{}{"max_requests": 1, "cycle_duration": 60})
+
+	noisy := map[string]interface{}{"requests": []map[string]interface{}{{"client_id": "batch-noisy"}}}
+	for i := 0; i < 4; i++ {
+		checkBatch(t, app, noisy)
+	}
+
+	usage := sendJSON(app, http.MethodGet, "/api/v1/rate-limit/batch-noisy/usage", nil)
+	if penalty, _ := responseData(t, usage.Body.Bytes())["penalty"].(map[string]interface{}); penalty["banned"] != true {
+		t.Fatalf("Expected batch denials to ban the client, got %s", usage.Body.String())
+	}
+
+	_, response := checkBatch(t, app, map[string]interface{}{
+		"requests": []map[string]interface{}{
+			{"client_id": "batch-innocent"},
+			{"client_id": "batch-noisy"},
+		},
+	})
+
+	data := response["data"].(map[string]interface{})
+	if data["allowed"] != false {
+		t.Fatal("Batch with a banned client should be rejected")
+	}
+
+	results := data["results"].([]interface{})
+	if banned := results[1].(map[string]interface{})["banned"]; banned != true {
+		t.Errorf("Expected banned client to be reported, got %v", results[1])
+	}
+	if remaining := results[0].(map[string]interface{})["remaining"].(float64); remaining != 100 {
+		t.Errorf("Rejected batch should not charge other clients, remaining %v", remaining)
+	}
+}
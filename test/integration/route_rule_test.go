@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/domain"
+	"rate-limiter-go/internal/handler"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func routeRequest(e *echo.Echo, method, path, clientID string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Client-ID", clientID)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestRouteRules_MostSpecific(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rules/create-order", map[string]interface{}{
+		"method":         "POST",
+		"path":           "/api/v1/protected/orders/*",
+		"max_requests":   2,
+		"cycle_duration": 1,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 creating rule, got %d", rec.Code)
+	}
+
+	sendJSON(app, http.MethodPut, "/api/v1/rules/orders", map[string]interface{}{
+		"path":           "/api/v1/protected/orders/*",
+		"max_requests":   50,
+		"cycle_duration": 1,
+	})
+
+	for i := 0; i < 2; i++ {
+		if code := routeRequest(app.Echo, http.MethodPost, "/api/v1/protected/orders/1", "rule-client"); code != http.StatusOK {
+			t.Fatalf("Request %d should pass, got %d", i+1, code)
+		}
+	}
+
+	if code := routeRequest(app.Echo, http.MethodPost, "/api/v1/protected/orders/1", "rule-client"); code != http.StatusTooManyRequests {
+		t.Errorf("POST rule should block the third request, got %d", code)
+	}
+
+	if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "rule-client"); code != http.StatusOK {
+		t.Errorf("GET should use its own rule counter, got %d", code)
+	}
+
+	if code := routeRequest(app.Echo, http.MethodPost, "/api/v1/protected/orders/1", "other-client"); code != http.StatusOK {
+		t.Errorf("Rule counters should be per client, got %d", code)
+	}
+}
+
+func TestRouteRules_AllMatching(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	app.UseCase.SaveRule(t.Context(), &domain.RouteRule{ID: "everything", Pattern: "/**", MaxRequests: 3, CycleDuration: 1})
+	app.UseCase.SaveRule(t.Context(), &domain.RouteRule{ID: "orders", Method: "POST", Pattern: "/orders/*", MaxRequests: 10, CycleDuration: 1})
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddlewareWithConfig(app.UseCase, handler.RateLimiterConfig{
+		RuleMatch: domain.RuleMatchAll,
+	}))
+	e.Any("/*", func(c echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	for i := 0; i < 3; i++ {
+		routeRequest(e, http.MethodPost, "/orders/1", "all-client")
+	}
+
+	if code := routeRequest(e, http.MethodPost, "/orders/1", "all-client"); code != http.StatusTooManyRequests {
+		t.Errorf("Broadest rule should also apply, got %d", code)
+	}
+}
+
+func TestRouteRules_Validation(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rules/bad", map[string]interface{}{
+		"path":           "orders",
+		"max_requests":   5,
+		"cycle_duration": 1,
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for relative path, got %d", rec.Code)
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/rules/missing", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown rule, got %d", rec.Code)
+	}
+}
+
+func TestRouteRules_ClientCannotAddressRuleCounter(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/rules/api", map[string]interface{}{
+		"path":           "/api/v1/protected/orders/*",
+		"max_requests":   5,
+		"cycle_duration": 60,
+	})
+
+	if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/data", "victim|rule:api"); code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for a client ID holding the counter separator, got %d", code)
+	}
+
+	if code := routeRequest(app.Echo, http.MethodGet, "/api/v1/protected/orders/1", "victim"); code != http.StatusOK {
+		t.Errorf("Victim's rule counter should be untouched, got %d", code)
+	}
+
+	if rec := sendJSON(app, http.MethodPut, "/api/v1/rate-limit/victim%7Crule:api", map[string]int{"max_requests": 1, "cycle_duration": 1}); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 configuring a derived counter, got %d", rec.Code)
+	}
+}
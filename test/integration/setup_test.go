@@ -3,11 +3,15 @@ package integration
 import (
 	"context"
 	"rate-limiter-go/internal/handler"
+	"rate-limiter-go/internal/health"
 	"rate-limiter-go/internal/repository"
 	"rate-limiter-go/internal/repository/memory"
 	redisRepo "rate-limiter-go/internal/repository/redis"
+	"rate-limiter-go/internal/server"
 	"rate-limiter-go/internal/usecase"
+	"rate-limiter-go/pkg/response"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/redis/go-redis/v9"
@@ -21,6 +25,10 @@ type TestApp struct {
 }
 
 func SetupTestApp(t *testing.T, useRedis bool) *TestApp {
+	return SetupTestAppWithConfig(t, useRedis, usecase.Config{})
+}
+
+func SetupTestAppWithConfig(t *testing.T, useRedis bool, config usecase.Config) *TestApp {
 	var repo repository.RateLimiterRepository
 
 	if useRedis {
@@ -42,25 +50,32 @@ func SetupTestApp(t *testing.T, useRedis bool) *TestApp {
 		repo = memory.NewRateLimiterMemoryRepository(100, 1)
 	}
 
-	uc := usecase.NewRateLimiterUseCase(repo)
-	h := handler.NewRateLimiterHandler(uc)
+	uc := usecase.NewRateLimiterUseCaseWithConfig(repository.NewInstrumentedRepository(repo, "test"), config)
 
 	e := echo.New()
+	e.HTTPErrorHandler = response.HTTPErrorHandler
 
-	api := e.Group("/api/v1")
-	api.GET("/rate-limit/:clientID", h.CheckRateLimit)
-	api.PUT("/rate-limit/:clientID", h.ConfigureRateLimit)
+	limiter := handler.RateLimiterMiddleware(uc)
+	server.RegisterRoutes(e, e, server.Services{
+		UseCase:       uc,
+		LocalUseCase:  uc,
+		Checker:       health.NewChecker("test", repo.(repository.Pinger), health.NewBreaker(1, time.Minute), time.Second, nil),
+		Clustered:     true,
+		ClusterSecret: "test-secret",
+		AdminAuth:     handler.AdminAuthMiddleware(handler.AdminAuthConfig{Disabled: true}),
+		Limiter:       limiter,
+	})
 
-	protected := api.Group("/protected")
-	protected.Use(handler.RateLimiterMiddleware(uc))
-	protected.GET("/data", func(c echo.Context) error {
+	// Route rule tests also need protected routes under /orders, which only
+	// exist in tests.
+	e.Any("/api/v1/protected/orders/*", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{"message": "OK"})
-	})
+	}, limiter)
 
 	return &TestApp{
 		Echo:       e,
 		Repository: repo,
 		UseCase:    uc,
-		Handler:    h,
+		Handler:    handler.NewRateLimiterHandler(uc),
 	}
 }
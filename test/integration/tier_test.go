@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func sendJSON(app *TestApp, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	app.Echo.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestTiers_ChangesApplyToAssignedClients(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/tiers/free", map[string]int{"max_requests": 2, "cycle_duration": 1})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 creating tier, got %d", rec.Code)
+	}
+
+	rec = sendJSON(app, http.MethodPut, "/api/v1/rate-limit/tier-client/tier", map[string]string{"tier": "free"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 assigning tier, got %d", rec.Code)
+	}
+
+	protectedRequest(app, "tier-client")
+	protectedRequest(app, "tier-client")
+	if code := protectedRequest(app, "tier-client"); code != http.StatusTooManyRequests {
+		t.Fatalf("Free tier should block the third request, got %d", code)
+	}
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/free", map[string]int{"max_requests": 5, "cycle_duration": 1})
+
+	if code := protectedRequest(app, "tier-client"); code != http.StatusOK {
+		t.Errorf("Raised tier limit should apply immediately, got %d", code)
+	}
+}
+
+func TestTiers_DefaultTierAndValidation(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodGet, "/api/v1/tiers/default", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected built-in default tier, got %d", rec.Code)
+	}
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/default", map[string]int{"max_requests": 1, "cycle_duration": 1})
+
+	protectedRequest(app, "default-client")
+	if code := protectedRequest(app, "default-client"); code != http.StatusTooManyRequests {
+		t.Errorf("Default tier should apply to new clients, got %d", code)
+	}
+
+	rec = sendJSON(app, http.MethodPut, "/api/v1/rate-limit/other-client/tier", map[string]string{"tier": "missing"})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown tier, got %d", rec.Code)
+	}
+
+	rec = sendJSON(app, http.MethodDelete, "/api/v1/tiers/missing", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting unknown tier, got %d", rec.Code)
+	}
+}
+
+func TestTiers_DeleteAssignedOrBuiltin(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/tiers/gold", map[string]int{"max_requests": 2, "cycle_duration": 1})
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/gold-client/tier", map[string]string{"tier": "gold"})
+
+	rec := sendJSON(app, http.MethodDelete, "/api/v1/tiers/gold", nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 deleting assigned tier, got %d", rec.Code)
+	}
+
+	sendJSON(app, http.MethodDelete, "/api/v1/rate-limit/gold-client/tier", nil)
+
+	rec = sendJSON(app, http.MethodDelete, "/api/v1/tiers/gold", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 deleting unassigned tier, got %d", rec.Code)
+	}
+
+	rec = sendJSON(app, http.MethodDelete, "/api/v1/tiers/default", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting built-in default tier, got %d", rec.Code)
+	}
+}
+
+func TestTiers_DefaultTierCalendarWindow(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/tiers/default", map[string]interface{}{"max_requests": 2, "window": "month"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 saving default tier, got %d", rec.Code)
+	}
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if protectedRequest(app, "monthly-client") == http.StatusOK {
+			allowed++
+		}
+	}
+
+	if allowed != 2 {
+		t.Errorf("Expected 2 requests allowed by the monthly default tier, got %d", allowed)
+	}
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rate-limiter-go/internal/handler"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupTestTracing(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		provider.Shutdown(t.Context())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return exporter
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func spanAttribute(span *tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing_PropagatesContextAndRecordsDecision(t *testing.T) {
+	exporter := setupTestTracing(t)
+	app := SetupTestApp(t, false)
+
+	sendJSON(app, http.MethodPut, "/api/v1/rate-limit/traced-client", map[string]interface{}{
+		"max_requests":   1,
+		"cycle_duration": 60,
+	})
+
+	e := echo.New()
+	e.Use(handler.RateLimiterMiddleware(app.UseCase))
+	e.GET("/data", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		req.Header.Set("X-Client-ID", "traced-client")
+		req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := exporter.GetSpans()
+
+	middleware := findSpan(spans, "RateLimiterMiddleware")
+	check := findSpan(spans, "CheckRateLimit")
+	if middleware == nil || check == nil {
+		t.Fatalf("Expected middleware and usecase spans, got %d spans", len(spans))
+	}
+
+	if got := middleware.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("Expected incoming trace ID %s, got %s", traceID, got)
+	}
+	if got := middleware.Parent.SpanID().String(); got != parentID {
+		t.Errorf("Expected middleware span to be a child of %s, got %s", parentID, got)
+	}
+	if check.Parent.SpanID() != middleware.SpanContext.SpanID() {
+		t.Error("Expected CheckRateLimit span to be a child of the middleware span")
+	}
+
+	if !spanAttribute(check, "ratelimit.allowed").AsBool() || spanAttribute(check, "ratelimit.remaining").AsInt64() != 0 {
+		t.Errorf("Unexpected decision attributes on first check: %v", check.Attributes)
+	}
+	if spanAttribute(check, "ratelimit.policy").AsString() != "traced-client" {
+		t.Errorf("Expected client policy, got %q", spanAttribute(check, "ratelimit.policy").AsString())
+	}
+
+	var denied bool
+	for i := range spans {
+		if spans[i].Name == "RateLimiterMiddleware" && !spanAttribute(&spans[i], "ratelimit.allowed").AsBool() {
+			denied = true
+		}
+	}
+	if !denied {
+		t.Error("Expected the second request to be recorded as denied")
+	}
+}
@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestUsage_CalendarWindow(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rate-limit/monthly-client", map[string]interface{}{
+		"max_requests": 3,
+		"window":       "month",
+		"timezone":     "Asia/Jakarta",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 configuring monthly quota, got %d", rec.Code)
+	}
+
+	for i := 0; i < 4; i++ {
+		protectedRequest(app, "monthly-client")
+	}
+
+	rec = sendJSON(app, http.MethodGet, "/api/v1/rate-limit/monthly-client/usage", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+
+	data := response["data"].(map[string]interface{})
+	if data["window"] != "month" || data["timezone"] != "Asia/Jakarta" {
+		t.Errorf("Unexpected window %v / %v", data["window"], data["timezone"])
+	}
+
+	current := data["current"].(map[string]interface{})
+	if current["count"].(float64) != 3 {
+		t.Errorf("Expected 3 requests this month, got %v", current["count"])
+	}
+	if len(current["start"].(string)) < 10 || current["start"].(string)[8:10] != "01" {
+		t.Errorf("Expected period to start on the first of the month, got %v", current["start"])
+	}
+}
+
+func TestUsage_RejectsInvalidWindow(t *testing.T) {
+	app := SetupTestApp(t, false)
+
+	rec := sendJSON(app, http.MethodPut, "/api/v1/rate-limit/bad-window", map[string]interface{}{
+		"max_requests": 3,
+		"window":       "fortnight",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown window, got %d", rec.Code)
+	}
+
+	rec = sendJSON(app, http.MethodPut, "/api/v1/rate-limit/bad-zone", map[string]interface{}{
+		"max_requests": 3,
+		"window":       "day",
+		"timezone":     "Mars/Olympus",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown time zone, got %d", rec.Code)
+	}
+}